@@ -5,55 +5,381 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/google/gnxi/utils/credentials"
 	"github.com/onosproject/onos-lib-go/pkg/logging"
+	"github.com/onosproject/sdcore-adapter/internal/pkg/config"
 	"github.com/onosproject/sdcore-adapter/internal/pkg/version"
 	"github.com/onosproject/sdcore-adapter/pkg/diagapi"
+	"github.com/onosproject/sdcore-adapter/pkg/election"
 	"github.com/onosproject/sdcore-adapter/pkg/gnmi"
 	synchronizer "github.com/onosproject/sdcore-adapter/pkg/synchronizer"
 	"github.com/onosproject/sdcore-adapter/pkg/target"
+	"github.com/onosproject/sdcore-adapter/pkg/tracing"
 	pb "github.com/openconfig/gnmi/proto/gnmi"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
 )
 
 var (
+	configFile           = flag.String("config", "", "YAML configuration file; flags that are set on the command line take precedence over it")
+	checkConfig          = flag.Bool("check_config", false, "Validate the configuration and exit")
 	bindAddr             = flag.String("bind_address", ":10161", "Bind to address:port or just :port")
 	metricAddr           = flag.String("metric_address", ":9851", "Prometheus metric endpoint bind to address:port or just :port")
 	partialUpdateDisable = flag.Bool("partial_update_disable", false, "Disable partial update; send full updates to core on every change")
 	postDisable          = flag.Bool("post_disable", false, "Disable posting to connectivity service endpoints")
+	foreignImsiDisable   = flag.Bool("foreign_imsi_disable", false, "Drop IMSIs whose MCC/MNC do not match the site's IMSI definition")
 	postTimeout          = flag.Duration("post_timeout", time.Second*10, "Timeout duration when making post requests")
+	retryInterval        = flag.Duration("retry_interval", synchronizer.DefaultRetryInterval, "Interval between attempts when a push fails")
+	priorityAutoAssign   = flag.Bool("priority_auto_assign", false, "Automatically assign distinct priorities to application filter rules")
+	portRangeSplit       = flag.Bool("port_range_split", false, "Split application port ranges into ranges that can be expressed as a port and mask")
+	defaultBehaviorFile  = flag.String("default_behavior_file", "", "YAML file defining slice default behaviors, overriding or extending the built-in behaviors")
 	aetherConfigAddr     = flag.String("aether_config_addr", "", "If specified, pull initial state from aether-config at this address")
 	aetherConfigTarget   = flag.String("aether_config_target", "connectivity-service-v4", "Target to use when pulling from aether-config")
+	kafkaURI             = flag.String("kafka_uri", "", "URI of kafka")
+	kafkaTopic           = flag.String("kafka_topic", synchronizer.DefaultKafkaTopic, "kafka topic to fetch from")
 	showModelList        = flag.Bool("show_models", false, "Show list of available modes")
 	diagsPort            = flag.Uint("diags_port", 8080, "Port to use for Diagnostics API")
+	traceExporter        = flag.String("trace_exporter", tracing.ExporterNone, "Trace exporter to use: none, stdout, or otlp")
+	traceEndpoint        = flag.String("trace_endpoint", "", "Address of the OTLP trace collector, if trace_exporter is otlp")
+	traceInsecure        = flag.Bool("trace_insecure", false, "Connect to the OTLP trace collector without TLS")
+	healthInterval       = flag.Duration("health_interval", time.Second*5, "Interval at which the gRPC health service is updated")
+	shutdownTimeout      = flag.Duration("shutdown_timeout", time.Second*30, "Time allowed on SIGTERM or SIGINT for in-flight requests and synchronization to complete")
+	diagsTLSCert         = flag.String("diags_tls_cert", "", "If specified, serve the Diagnostics API over https using this certificate file")
+	diagsTLSKey          = flag.String("diags_tls_key", "", "Key file for the Diagnostics API certificate")
+	diagsAuthEnable      = flag.Bool("diags_auth_enable", false, "Require an OIDC bearer token, validated against OIDC_SERVER_URL, on Diagnostics API requests")
+	diagsReadGroups      = flag.String("diags_read_groups", "", "Comma-separated groups that may call read-only Diagnostics API endpoints; any authenticated caller if empty")
+	diagsWriteGroups     = flag.String("diags_write_groups", "AetherROCAdmin", "Comma-separated groups that may call every Diagnostics API endpoint")
+	leaderElection       = flag.String("leader_election", election.ModeNone, "Leader election among replicas, so that only the leader pushes: none, file, or kubernetes")
+	leaderLockFile       = flag.String("leader_election_lock_file", "/tmp/sdcore-adapter.lock", "Lock file held by the leader, if leader_election is file")
+	leaderLeaseName      = flag.String("leader_election_lease", "sdcore-adapter", "Name of the Lease held by the leader, if leader_election is kubernetes")
+	leaderNamespace      = flag.String("leader_election_namespace", "", "Namespace of the Lease; $POD_NAMESPACE if empty")
+	setJournal           = flag.String("set_journal", "", "If specified, append every gNMI Set request to this journal file, for replay by sdcore-replay")
+	subscriberQueueSize  = flag.Int("subscriber_queue_size", gnmi.DefaultSubscriberQueueSize, "Number of responses that may be queued for a gNMI subscriber")
+	subscriberOverflow   = flag.String("subscriber_overflow", string(gnmi.OverflowDropOldest), "What is done when a gNMI subscriber falls behind: drop-oldest, or disconnect")
+	revisionHistory      = flag.Int("revision_history", gnmi.DefaultRevisionLimit, "Number of revisions of each target's config tree kept for the Diagnostics API; 0 keeps none")
 )
 
 var log = logging.GetLogger("sdcore-adapter")
 
-func serveMetrics() {
+// splitGroups splits a comma-separated list of groups, dropping empty entries
+func splitGroups(groups string) []string {
+	result := []string{}
+	for _, group := range strings.Split(groups, ",") {
+		if group = strings.TrimSpace(group); group != "" {
+			result = append(result, group)
+		}
+	}
+	return result
+}
+
+// loadConfig returns the configuration from the configuration file, if there is one, with the
+// flags that were set on the command line taking precedence.
+func loadConfig() (*config.Config, error) {
+	cfg := config.Default()
+	if *configFile != "" {
+		loaded, err := config.Load(*configFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg = loaded
+	}
+
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "bind_address":
+			cfg.Listeners.GNMI = *bindAddr
+		case "metric_address":
+			cfg.Listeners.Metrics = *metricAddr
+		case "diags_port":
+			cfg.Listeners.DiagAPI = *diagsPort
+		case "post_disable":
+			cfg.Synchronizer.PostEnable = !*postDisable
+		case "post_timeout":
+			cfg.Synchronizer.PostTimeout = *postTimeout
+		case "retry_interval":
+			cfg.Synchronizer.RetryInterval = *retryInterval
+		case "partial_update_disable":
+			cfg.Synchronizer.PartialUpdateEnable = !*partialUpdateDisable
+		case "foreign_imsi_disable":
+			cfg.Synchronizer.ForeignImsiEnable = !*foreignImsiDisable
+		case "priority_auto_assign":
+			cfg.Synchronizer.PriorityAutoAssign = *priorityAutoAssign
+		case "port_range_split":
+			cfg.Synchronizer.PortRangeSplit = *portRangeSplit
+		case "default_behavior_file":
+			cfg.Synchronizer.DefaultBehaviorFile = *defaultBehaviorFile
+		case "kafka_uri":
+			cfg.Kafka.URI = *kafkaURI
+		case "kafka_topic":
+			cfg.Kafka.Topic = *kafkaTopic
+		case "aether_config_addr":
+			cfg.AetherConfig.Address = *aetherConfigAddr
+		case "aether_config_target":
+			cfg.AetherConfig.Target = *aetherConfigTarget
+		case "diags_tls_cert":
+			cfg.DiagAPI.TLSCert = *diagsTLSCert
+		case "diags_tls_key":
+			cfg.DiagAPI.TLSKey = *diagsTLSKey
+		case "diags_auth_enable":
+			cfg.DiagAPI.AuthEnable = *diagsAuthEnable
+		case "diags_read_groups":
+			cfg.DiagAPI.ReadGroups = splitGroups(*diagsReadGroups)
+		case "diags_write_groups":
+			cfg.DiagAPI.WriteGroups = splitGroups(*diagsWriteGroups)
+		case "trace_exporter":
+			cfg.Tracing.Exporter = *traceExporter
+		case "trace_endpoint":
+			cfg.Tracing.Endpoint = *traceEndpoint
+		case "trace_insecure":
+			cfg.Tracing.Insecure = *traceInsecure
+		case "leader_election":
+			cfg.LeaderElection.Mode = *leaderElection
+		case "leader_election_lock_file":
+			cfg.LeaderElection.LockFile = *leaderLockFile
+		case "leader_election_lease":
+			cfg.LeaderElection.LeaseName = *leaderLeaseName
+		case "leader_election_namespace":
+			cfg.LeaderElection.Namespace = *leaderNamespace
+		case "set_journal":
+			cfg.GNMI.SetJournal = *setJournal
+		case "subscriber_queue_size":
+			cfg.GNMI.SubscriberQueueSize = *subscriberQueueSize
+		case "subscriber_overflow":
+			cfg.GNMI.SubscriberOverflow = *subscriberOverflow
+		case "revision_history":
+			cfg.GNMI.Revisions = *revisionHistory
+		}
+	})
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// synchronizerOptions returns the synchronizer options selected by the reloadable sections of
+// the configuration, loading the default behaviors and reading the pusher's secrets.
+func synchronizerOptions(cfg *config.Config) ([]synchronizer.SynchronizerOption, error) {
+	defaultBehaviors := synchronizer.NewDefaultBehaviorPolicy()
+	if cfg.Synchronizer.DefaultBehaviorFile != "" {
+		log.Infof("Loading default behaviors from %s", cfg.Synchronizer.DefaultBehaviorFile)
+		if err := defaultBehaviors.LoadFromYamlFile(cfg.Synchronizer.DefaultBehaviorFile); err != nil {
+			return nil, fmt.Errorf("failed to load default behaviors: %v", err)
+		}
+	}
+
+	username, password, token, err := cfg.PusherCredentials()
+	if err != nil {
+		return nil, err
+	}
+
+	return []synchronizer.SynchronizerOption{
+		synchronizer.WithDefaultBehaviorPolicy(defaultBehaviors),
+		synchronizer.WithPostEnable(cfg.Synchronizer.PostEnable),
+		synchronizer.WithPartialUpdateEnable(cfg.Synchronizer.PartialUpdateEnable),
+		synchronizer.WithForeignImsiEnable(cfg.Synchronizer.ForeignImsiEnable),
+		synchronizer.WithPriorityAutoAssignEnable(cfg.Synchronizer.PriorityAutoAssign),
+		synchronizer.WithPortRangeSplitEnable(cfg.Synchronizer.PortRangeSplit),
+		synchronizer.WithPostTimeout(cfg.Synchronizer.PostTimeout),
+		synchronizer.WithRetryInterval(cfg.Synchronizer.RetryInterval),
+		synchronizer.WithPushCredentials(synchronizer.PushCredentials{
+			Username:    username,
+			Password:    password,
+			BearerToken: token,
+		}),
+	}, nil
+}
+
+// configReloader re-reads the configuration file on SIGHUP or on request from the diagnostic
+// API, and applies the reloadable sections to the synchronizer.
+type configReloader struct {
+	mu      sync.Mutex
+	started *config.Config
+	sync    *synchronizer.Synchronizer
+}
+
+// reload applies the configuration file, and returns the sections that changed since startup
+// but only take effect on restart. On error, the running configuration is left unchanged.
+func (r *configReloader) reload() ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if *configFile == "" {
+		return nil, fmt.Errorf("no configuration file")
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+	opts, err := synchronizerOptions(cfg)
+	if err != nil {
+		return nil, err
+	}
+	r.sync.Reconfigure(opts...)
+
+	restartRequired := r.started.RestartRequired(cfg)
+	if len(restartRequired) > 0 {
+		log.Warnf("configuration of %s changed and takes effect on restart", strings.Join(restartRequired, ", "))
+	}
+	log.Infof("reloaded configuration from %s", *configFile)
+	return restartRequired, nil
+}
+
+// diagAPIOptions returns the diagnostic API options selected by the configuration
+func diagAPIOptions(cfg *config.Config) ([]diagapi.DiagnosticAPIOption, error) {
+	opts := []diagapi.DiagnosticAPIOption{}
+	if cfg.DiagAPI.TLSCert != "" {
+		opts = append(opts, diagapi.WithTLS(cfg.DiagAPI.TLSCert, cfg.DiagAPI.TLSKey))
+	}
+	if cfg.DiagAPI.AuthEnable {
+		validator, err := diagapi.NewOIDCValidator()
+		if err != nil {
+			return nil, fmt.Errorf("diags_auth_enable is set, but no token could be validated: %v", err)
+		}
+		opts = append(opts, diagapi.WithAuthentication(validator, cfg.DiagAPI.ReadGroups, cfg.DiagAPI.WriteGroups))
+	}
+	return opts, nil
+}
+
+// startElection campaigns for leadership if leader election is enabled, making the synchronizer
+// leader when elected. Returns a function that releases leadership.
+func startElection(cfg *config.Config, sync *synchronizer.Synchronizer) func() {
+	identity := cfg.LeaderElection.Identity
+	if identity == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			log.Fatalf("failed to get hostname for leader election identity: %v", err)
+		}
+		identity = hostname
+	}
+
+	var elector election.Elector
+	switch cfg.LeaderElection.Mode {
+	case election.ModeFile:
+		elector = election.NewFileLockElector(cfg.LeaderElection.LockFile, identity, election.DefaultRetryPeriod)
+	case election.ModeKubernetes:
+		namespace := cfg.LeaderElection.Namespace
+		if namespace == "" {
+			namespace = os.Getenv("POD_NAMESPACE")
+		}
+		if namespace == "" {
+			log.Fatal("leader election namespace is not set, and neither is POD_NAMESPACE")
+		}
+		e, err := election.NewKubernetesElector(cfg.LeaderElection.LeaseName, namespace, identity)
+		if err != nil {
+			log.Fatalf("failed to start leader election: %v", err)
+		}
+		elector = e
+	default:
+		return func() {}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		elector.Run(ctx, election.Callbacks{
+			OnElected: sync.BecomeLeader,
+			OnDeposed: sync.BecomeStandby,
+		})
+		close(done)
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+func serveMetrics(metricAddr string) {
 	http.Handle("/metrics", promhttp.Handler())
-	if err := http.ListenAndServe(*metricAddr, nil); err != nil {
+	if err := http.ListenAndServe(metricAddr, nil); err != nil {
 		log.Fatalf("failed to serve metrics: %v", err)
 	}
 }
 
+// Keep the gRPC health service in step with the synchronizer's readiness. Both the overall
+// server status and the gNMI service status are reported.
+func watchHealth(healthServer *health.Server, sync synchronizer.SynchronizerInterface) {
+	for {
+		status := healthpb.HealthCheckResponse_SERVING
+		if !sync.GetReadiness().Healthy {
+			status = healthpb.HealthCheckResponse_NOT_SERVING
+		}
+		healthServer.SetServingStatus("", status)
+		healthServer.SetServingStatus(pb.GNMI_ServiceDesc.ServiceName, status)
+		time.Sleep(*healthInterval)
+	}
+}
+
+// shutdown stops the adapter in order: stop accepting gNMI requests, stop the diagnostic API,
+// let the synchronizer finish or cancel the update in progress, release leadership, close the
+// target, and flush traces. Each step is bounded by the shutdown deadline, after which the gRPC
+// server is stopped forcibly and the synchronizer's update is cancelled.
+func shutdown(g *grpc.Server, healthServer *health.Server, diags *diagapi.DiagnosticAPI,
+	sync *synchronizer.Synchronizer, stopElection func(), closeTarget func(), shutdownTracing func(context.Context) error) {
+	ctx, cancel := context.WithTimeout(context.Background(), *shutdownTimeout)
+	defer cancel()
+
+	log.Infof("shutting down, timeout=%s", *shutdownTimeout)
+	healthServer.Shutdown()
+
+	// GracefulStop waits for streaming RPCs such as Subscribe, so do not wait past the deadline
+	stopped := make(chan struct{})
+	go func() {
+		g.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		log.Info("gRPC server stopped")
+	case <-ctx.Done():
+		log.Warn("gRPC server did not stop in time; stopping forcibly")
+		g.Stop()
+	}
+
+	if err := diags.Shutdown(ctx); err != nil {
+		log.Warnf("failed to shut down out-of-band API: %v", err)
+	}
+
+	if err := sync.Stop(ctx); err != nil {
+		log.Warnf("synchronizer did not finish in time: %v", err)
+	}
+
+	// Released only once the synchronizer has stopped pushing, so that a standby can take over
+	stopElection()
+
+	closeTarget()
+
+	if err := shutdownTracing(ctx); err != nil {
+		log.Warnf("failed to flush traces: %v", err)
+	}
+	log.Info("shutdown complete")
+}
+
 // Synchronize and eat the error. This lets aether-config know we applied the
 // configuration, but leaves us to retry applying it to the southbound device
-// ourselves.
+// ourselves. A forced synchronization is requested through the diagnostic API,
+// not by aether-config, so its error, such as ErrStandby, is returned.
 func synchronizerWrapper(s synchronizer.SynchronizerInterface) gnmi.ConfigCallback {
-	return func(config *gnmi.ConfigForest, callbackType gnmi.ConfigCallbackType, target string, path *pb.Path) error {
-		err := s.Synchronize(config, callbackType, target, path)
-		if err != nil {
+	return func(ctx context.Context, config *gnmi.ConfigForest, callbackType gnmi.ConfigCallbackType, target string, path *pb.Path, scope []*pb.Path) error {
+		err := s.Synchronize(ctx, config, callbackType, target, path, scope)
+		if err != nil && callbackType == gnmi.Forced {
+			return err
+		} else if err != nil {
 			// Report the error, but do not send the error upstream.
 			log.Warnf("Error during synchronize: %v", err)
 		}
@@ -62,7 +388,7 @@ func synchronizerWrapper(s synchronizer.SynchronizerInterface) gnmi.ConfigCallba
 }
 
 func main() {
-	var sync synchronizer.SynchronizerInterface
+	var sync *synchronizer.Synchronizer
 
 	flag.Usage = func() {
 		_, err := fmt.Fprintf(os.Stderr, "Usage of %s:\n", os.Args[0])
@@ -73,15 +399,46 @@ func main() {
 	}
 	flag.Parse()
 
+	cfg, err := loadConfig()
+	if err == nil {
+		// also check the files that the configuration refers to
+		_, err = synchronizerOptions(cfg)
+	}
+	if *checkConfig {
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "configuration is invalid: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stdout, "configuration is valid\n")
+		return
+	}
+	if err != nil {
+		log.Fatalf("failed to load configuration: %v", err)
+	}
+
 	log.Infof("sdcore-adapter")
 	version.LogVersion("  ")
 
+	shutdownTracing, err := tracing.Init(context.Background(), tracing.Config{
+		Exporter:    cfg.Tracing.Exporter,
+		Endpoint:    cfg.Tracing.Endpoint,
+		Insecure:    cfg.Tracing.Insecure,
+		ServiceName: "sdcore-adapter"})
+	if err != nil {
+		log.Fatalf("failed to initialize tracing: %v", err)
+	}
+
+	syncOpts, err := synchronizerOptions(cfg)
+	if err != nil {
+		log.Fatalf("failed to configure synchronizer: %v", err)
+	}
+
 	// Initialize the synchronizer's service-specific code.
 	log.Infof("Initializing synchronizer")
-	sync = synchronizer.NewSynchronizer(
-		synchronizer.WithPostEnable(!*postDisable),
-		synchronizer.WithPartialUpdateEnable(!*partialUpdateDisable),
-		synchronizer.WithPostTimeout(*postTimeout))
+	sync = synchronizer.NewSynchronizer(append(syncOpts,
+		synchronizer.WithKafka(cfg.Kafka.URI, cfg.Kafka.Topic),
+		synchronizer.WithLeaderElection(cfg.LeaderElection.Mode != election.ModeNone))...)
+	reloader := &configReloader{started: cfg, sync: sync}
 
 	// The synchronizer will convey its list of models.
 	model := sync.GetModels()
@@ -104,38 +461,66 @@ func main() {
 	opts := credentials.ServerCredentials()
 	g := grpc.NewServer(opts...)
 
-	c := make(chan os.Signal, 1)
-	signal.Notify(c)
-
 	s, err := target.NewTarget(model, synchronizerWrapper(sync))
 	if err != nil {
 		log.Fatalf("error in creating gnmi target: %v", err)
 	}
+	s.SetSubscriberPolicy(cfg.GNMI.SubscriberQueueSize, gnmi.OverflowPolicy(cfg.GNMI.SubscriberOverflow))
+	s.SetRevisionLimit(cfg.GNMI.Revisions)
+	if cfg.GNMI.SetJournal != "" {
+		journal, err := gnmi.OpenJournal(cfg.GNMI.SetJournal)
+		if err != nil {
+			log.Fatalf("failed to open set journal: %v", err)
+		}
+		log.Infof("Recording Set requests to %s", cfg.GNMI.SetJournal)
+		s.SetJournal(journal)
+	}
 
 	sync.Start()
-
-	go func() {
-		for {
-			oscall := <-c
-			if oscall.String() == "terminated" || oscall.String() == "interrupt" {
-				log.Warnf("system call:%+v", oscall)
-				s.Close()
-				os.Exit(0)
-			}
-		}
-	}()
+	stopElection := startElection(cfg, sync)
 
 	pb.RegisterGNMIServer(g, s)
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(g, healthServer)
+	go watchHealth(healthServer, sync)
 	reflection.Register(g)
 
 	log.Info("starting metric handler")
-	go serveMetrics()
+	go serveMetrics(cfg.Listeners.Metrics)
 
-	log.Infof("starting out-of-band API on %d", *diagsPort)
-	diagapi.StartDiagnosticAPI(s, *aetherConfigAddr, *aetherConfigTarget, *diagsPort)
+	diagOpts, err := diagAPIOptions(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *configFile != "" {
+		diagOpts = append(diagOpts, diagapi.WithConfigReload(reloader.reload))
+	}
+	log.Infof("starting out-of-band API on %d", cfg.Listeners.DiagAPI)
+	diags := diagapi.StartDiagnosticAPI(s, sync, cfg.AetherConfig.Address, cfg.AetherConfig.Target, cfg.Listeners.DiagAPI, diagOpts...)
 
-	log.Infof("starting to listen on %s", *bindAddr)
-	listen, err := net.Listen("tcp", *bindAddr)
+	// SIGHUP reloads the configuration file
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	done := make(chan struct{})
+	go func() {
+		for oscall := range c {
+			log.Warnf("system call:%+v", oscall)
+			if oscall == syscall.SIGHUP {
+				if *configFile == "" {
+					log.Warn("no configuration file; ignoring SIGHUP")
+				} else if _, err := reloader.reload(); err != nil {
+					log.Warnf("failed to reload configuration; keeping the running configuration: %v", err)
+				}
+				continue
+			}
+			shutdown(g, healthServer, diags, sync, stopElection, s.Close, shutdownTracing)
+			close(done)
+			return
+		}
+	}()
+
+	log.Infof("starting to listen on %s", cfg.Listeners.GNMI)
+	listen, err := net.Listen("tcp", cfg.Listeners.GNMI)
 	if err != nil {
 		log.Fatalf("failed to listen: %v", err)
 	}
@@ -144,4 +529,7 @@ func main() {
 	if err := g.Serve(listen); err != nil {
 		log.Fatalf("failed to serve: %v", err)
 	}
+
+	// Serve returns as soon as shutdown stops the gRPC server; wait for the rest of shutdown
+	<-done
 }
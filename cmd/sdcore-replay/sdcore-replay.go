@@ -0,0 +1,91 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+/*
+ * Replays a journal of gNMI Set requests, recorded by sdcore-adapter with -set_journal,
+ * through the synchronizer, and outputs the pushes that each request caused. Nothing is
+ * pushed to the core or UPF.
+ *
+ * The synchronizer flags should match those of the adapter that recorded the journal.
+ *
+ * Example invocation:
+ *
+ * /usr/local/bin/sdcore-replay -journal /var/log/sdcore-adapter/set.journal -o pushes.json
+ */
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+
+	"github.com/onosproject/onos-lib-go/pkg/logging"
+	"github.com/onosproject/sdcore-adapter/internal/pkg/version"
+	"github.com/onosproject/sdcore-adapter/pkg/gnmi"
+	"github.com/onosproject/sdcore-adapter/pkg/replay"
+	"github.com/onosproject/sdcore-adapter/pkg/synchronizer"
+)
+
+var (
+	journalFile          = flag.String("journal", "", "journal of Set requests to replay")
+	output               = flag.String("o", "", "filename to send output to instead of STDOUT")
+	partialUpdateDisable = flag.Bool("partial_update_disable", false, "Disable partial update; send full updates to core on every change")
+	foreignImsiDisable   = flag.Bool("foreign_imsi_disable", false, "Drop IMSIs whose MCC/MNC do not match the site's IMSI definition")
+	priorityAutoAssign   = flag.Bool("priority_auto_assign", false, "Automatically assign distinct priorities to application filter rules")
+	portRangeSplit       = flag.Bool("port_range_split", false, "Split application port ranges into ranges that can be expressed as a port and mask")
+	defaultBehaviorFile  = flag.String("default_behavior_file", "", "YAML file defining slice default behaviors, overriding or extending the built-in behaviors")
+)
+
+var log = logging.GetLogger("sdcore-replay")
+
+func main() {
+	flag.Parse()
+
+	log.Infof("sdcore-replay")
+	version.LogVersion("  ")
+
+	if *journalFile == "" {
+		log.Fatalf("--journal not specified")
+	}
+
+	entries, err := gnmi.ReadJournal(*journalFile)
+	if err != nil {
+		log.Fatalf("Error reading journal %s", err.Error())
+	}
+
+	defaultBehaviors := synchronizer.NewDefaultBehaviorPolicy()
+	if *defaultBehaviorFile != "" {
+		if err := defaultBehaviors.LoadFromYamlFile(*defaultBehaviorFile); err != nil {
+			log.Fatalf("Error loading default behaviors %s", err.Error())
+		}
+	}
+
+	steps, err := replay.Replay(context.Background(), entries,
+		synchronizer.WithPartialUpdateEnable(!*partialUpdateDisable),
+		synchronizer.WithForeignImsiEnable(!*foreignImsiDisable),
+		synchronizer.WithPriorityAutoAssignEnable(*priorityAutoAssign),
+		synchronizer.WithPortRangeSplitEnable(*portRangeSplit),
+		synchronizer.WithDefaultBehaviorPolicy(defaultBehaviors))
+	if err != nil {
+		log.Fatalf("Error replaying journal %s", err.Error())
+	}
+	log.Infof("Replayed %d Set requests", len(steps))
+
+	data, err := json.MarshalIndent(steps, "", "  ")
+	if err != nil {
+		log.Fatalf("Error marshaling output %s", err.Error())
+	}
+	data = append(data, '\n')
+
+	if *output == "" {
+		_, err = os.Stdout.Write(data)
+	} else {
+		err = os.WriteFile(*output, data, 0644)
+	}
+	if err != nil {
+		log.Fatalf("Error writing output %s", err.Error())
+	}
+}
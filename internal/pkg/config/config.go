@@ -0,0 +1,311 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package config implements the sdcore-adapter configuration file
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/onosproject/sdcore-adapter/pkg/election"
+	"github.com/onosproject/sdcore-adapter/pkg/gnmi"
+	"github.com/onosproject/sdcore-adapter/pkg/synchronizer"
+	"github.com/onosproject/sdcore-adapter/pkg/tracing"
+	"gopkg.in/yaml.v2"
+)
+
+/*
+ * The configuration file is YAML, and carries a version so that the format can change
+ * without silently misreading an older file. Settings that are omitted keep their defaults,
+ * which are the same as the defaults of the corresponding command line flags. Unknown
+ * settings are an error.
+ *
+ * The synchronizer and pusher sections can be changed at runtime by reloading the file. The
+ * other sections are only read at startup; a reload that changes them is reported, and takes
+ * effect on the next restart.
+ */
+
+// CurrentVersion is the version of the configuration file format
+const CurrentVersion = 1
+
+// Config is the configuration of sdcore-adapter
+type Config struct {
+	Version        int                  `yaml:"version"`
+	Listeners      ListenersConfig      `yaml:"listeners"`
+	Synchronizer   SynchronizerConfig   `yaml:"synchronizer"`
+	Pusher         PusherConfig         `yaml:"pusher"`
+	Kafka          KafkaConfig          `yaml:"kafka"`
+	AetherConfig   AetherConfigConfig   `yaml:"aether-config"`
+	DiagAPI        DiagAPIConfig        `yaml:"diag-api"`
+	Tracing        TracingConfig        `yaml:"tracing"`
+	LeaderElection LeaderElectionConfig `yaml:"leader-election"`
+	GNMI           GNMIConfig           `yaml:"gnmi"`
+}
+
+// ListenersConfig is the addresses and ports that sdcore-adapter serves on
+type ListenersConfig struct {
+	GNMI    string `yaml:"gnmi"`
+	Metrics string `yaml:"metrics"`
+	DiagAPI uint   `yaml:"diag-api"`
+}
+
+// SynchronizerConfig is the behavior of the synchronizer
+type SynchronizerConfig struct {
+	PostEnable          bool          `yaml:"post-enable"`
+	PostTimeout         time.Duration `yaml:"post-timeout"`
+	RetryInterval       time.Duration `yaml:"retry-interval"`
+	PartialUpdateEnable bool          `yaml:"partial-update-enable"`
+	ForeignImsiEnable   bool          `yaml:"foreign-imsi-enable"`
+	PriorityAutoAssign  bool          `yaml:"priority-auto-assign"`
+	PortRangeSplit      bool          `yaml:"port-range-split"`
+	DefaultBehaviorFile string        `yaml:"default-behavior-file"`
+}
+
+// PusherConfig is the credentials presented to the core and UPF when pushing. Either a
+// username and password, or a bearer token, may be given. Secrets may be read from files,
+// such as a mounted kubernetes secret, and are re-read on every reload.
+type PusherConfig struct {
+	Username        string `yaml:"username"`
+	Password        string `yaml:"password"`
+	PasswordFile    string `yaml:"password-file"`
+	BearerToken     string `yaml:"bearer-token"`
+	BearerTokenFile string `yaml:"bearer-token-file"`
+}
+
+// KafkaConfig is the Kafka topic that operational state is read from
+type KafkaConfig struct {
+	URI   string `yaml:"uri"`
+	Topic string `yaml:"topic"`
+}
+
+// AetherConfigConfig is the aether-config that initial state is pulled from
+type AetherConfigConfig struct {
+	Address string `yaml:"address"`
+	Target  string `yaml:"target"`
+}
+
+// DiagAPIConfig is the security of the diagnostic API
+type DiagAPIConfig struct {
+	TLSCert     string   `yaml:"tls-cert"`
+	TLSKey      string   `yaml:"tls-key"`
+	AuthEnable  bool     `yaml:"auth-enable"`
+	ReadGroups  []string `yaml:"read-groups"`
+	WriteGroups []string `yaml:"write-groups"`
+}
+
+// TracingConfig is the OpenTelemetry trace exporter
+type TracingConfig struct {
+	Exporter string `yaml:"exporter"`
+	Endpoint string `yaml:"endpoint"`
+	Insecure bool   `yaml:"insecure"`
+}
+
+// LeaderElectionConfig is how replicas of the adapter elect the one that synchronizes. The
+// lease namespace defaults to $POD_NAMESPACE, and the identity to the hostname.
+type LeaderElectionConfig struct {
+	Mode      string `yaml:"mode"`
+	LockFile  string `yaml:"lock-file"`
+	LeaseName string `yaml:"lease-name"`
+	Namespace string `yaml:"namespace"`
+	Identity  string `yaml:"identity"`
+}
+
+// GNMIConfig is the behavior of the gNMI server. If SetJournal is set, every Set request is
+// appended to it, so that it can be replayed by sdcore-replay. A subscriber that falls behind
+// by more than SubscriberQueueSize responses is handled by SubscriberOverflow. The last
+// Revisions revisions of each target's config tree are kept, for the diagnostic API.
+type GNMIConfig struct {
+	SetJournal          string `yaml:"set-journal"`
+	SubscriberQueueSize int    `yaml:"subscriber-queue-size"`
+	SubscriberOverflow  string `yaml:"subscriber-overflow"`
+	Revisions           int    `yaml:"revisions"`
+}
+
+// Default returns the default configuration
+func Default() *Config {
+	return &Config{
+		Version: CurrentVersion,
+		Listeners: ListenersConfig{
+			GNMI:    ":10161",
+			Metrics: ":9851",
+			DiagAPI: 8080,
+		},
+		Synchronizer: SynchronizerConfig{
+			PostEnable:          true,
+			PostTimeout:         synchronizer.DefaultPostTimeout,
+			RetryInterval:       synchronizer.DefaultRetryInterval,
+			PartialUpdateEnable: synchronizer.DefaultPartialUpdateEnable,
+			ForeignImsiEnable:   synchronizer.DefaultForeignImsiEnable,
+			PriorityAutoAssign:  synchronizer.DefaultPriorityAutoAssignEnable,
+			PortRangeSplit:      synchronizer.DefaultPortRangeSplitEnable,
+		},
+		Kafka: KafkaConfig{
+			Topic: synchronizer.DefaultKafkaTopic,
+		},
+		AetherConfig: AetherConfigConfig{
+			Target: "connectivity-service-v4",
+		},
+		DiagAPI: DiagAPIConfig{
+			WriteGroups: []string{"AetherROCAdmin"},
+		},
+		Tracing: TracingConfig{
+			Exporter: tracing.ExporterNone,
+		},
+		LeaderElection: LeaderElectionConfig{
+			Mode:      election.ModeNone,
+			LockFile:  "/tmp/sdcore-adapter.lock",
+			LeaseName: "sdcore-adapter",
+		},
+		GNMI: GNMIConfig{
+			SubscriberQueueSize: gnmi.DefaultSubscriberQueueSize,
+			SubscriberOverflow:  string(gnmi.OverflowDropOldest),
+			Revisions:           gnmi.DefaultRevisionLimit,
+		},
+	}
+}
+
+// Load reads and validates a configuration file
+func Load(fn string) (*Config, error) {
+	yamlFile, err := os.ReadFile(fn)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read config file: %v", err)
+	}
+	return Parse(yamlFile)
+}
+
+// Parse parses and validates a configuration. Settings that are not present keep their
+// default values.
+func Parse(data []byte) (*Config, error) {
+	// Check the version first, so that a file in a newer format is not reported as having
+	// unknown settings.
+	versioned := struct {
+		Version int `yaml:"version"`
+	}{}
+	if err := yaml.Unmarshal(data, &versioned); err != nil {
+		return nil, fmt.Errorf("Failed to unmarshal yaml: %v", err)
+	}
+	if versioned.Version != CurrentVersion {
+		return nil, fmt.Errorf("Unsupported config version %d; expected %d", versioned.Version, CurrentVersion)
+	}
+
+	config := Default()
+	if err := yaml.UnmarshalStrict(data, config); err != nil {
+		return nil, fmt.Errorf("Failed to unmarshal yaml: %v", err)
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// Validate returns an error if the configuration cannot be used
+func (c *Config) Validate() error {
+	errs := []string{}
+	if c.Listeners.GNMI == "" {
+		errs = append(errs, "listeners.gnmi must be set")
+	}
+	if c.Synchronizer.PostTimeout <= 0 {
+		errs = append(errs, "synchronizer.post-timeout must be positive")
+	}
+	if c.Synchronizer.RetryInterval <= 0 {
+		errs = append(errs, "synchronizer.retry-interval must be positive")
+	}
+	if (c.Pusher.Password != "") && (c.Pusher.PasswordFile != "") {
+		errs = append(errs, "pusher.password and pusher.password-file are mutually exclusive")
+	}
+	if (c.Pusher.BearerToken != "") && (c.Pusher.BearerTokenFile != "") {
+		errs = append(errs, "pusher.bearer-token and pusher.bearer-token-file are mutually exclusive")
+	}
+	if (c.Pusher.Username != "") && ((c.Pusher.BearerToken != "") || (c.Pusher.BearerTokenFile != "")) {
+		errs = append(errs, "pusher.username and a pusher bearer token are mutually exclusive")
+	}
+	if (c.DiagAPI.TLSCert == "") != (c.DiagAPI.TLSKey == "") {
+		errs = append(errs, "diag-api.tls-cert and diag-api.tls-key must be set together")
+	}
+	switch c.Tracing.Exporter {
+	case tracing.ExporterNone, tracing.ExporterStdout, tracing.ExporterOTLP:
+	default:
+		errs = append(errs, fmt.Sprintf("tracing.exporter %s is not one of none, stdout, or otlp", c.Tracing.Exporter))
+	}
+	switch c.LeaderElection.Mode {
+	case election.ModeNone:
+	case election.ModeFile:
+		if c.LeaderElection.LockFile == "" {
+			errs = append(errs, "leader-election.lock-file must be set if leader-election.mode is file")
+		}
+	case election.ModeKubernetes:
+		if c.LeaderElection.LeaseName == "" {
+			errs = append(errs, "leader-election.lease-name must be set if leader-election.mode is kubernetes")
+		}
+	default:
+		errs = append(errs, fmt.Sprintf("leader-election.mode %s is not one of none, file, or kubernetes", c.LeaderElection.Mode))
+	}
+	if c.GNMI.SubscriberQueueSize <= 0 {
+		errs = append(errs, "gnmi.subscriber-queue-size must be positive")
+	}
+	if _, err := gnmi.ParseOverflowPolicy(c.GNMI.SubscriberOverflow); err != nil {
+		errs = append(errs, fmt.Sprintf("gnmi.subscriber-overflow %s is not one of drop-oldest or disconnect", c.GNMI.SubscriberOverflow))
+	}
+	if c.GNMI.Revisions < 0 {
+		errs = append(errs, "gnmi.revisions must not be negative")
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("Invalid config: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// PusherCredentials returns the username, password, and bearer token that the pusher presents,
+// reading any secrets that are given as files.
+func (c *Config) PusherCredentials() (string, string, string, error) {
+	password := c.Pusher.Password
+	if c.Pusher.PasswordFile != "" {
+		data, err := os.ReadFile(c.Pusher.PasswordFile)
+		if err != nil {
+			return "", "", "", fmt.Errorf("Failed to read pusher password: %v", err)
+		}
+		password = strings.TrimSpace(string(data))
+	}
+	token := c.Pusher.BearerToken
+	if c.Pusher.BearerTokenFile != "" {
+		data, err := os.ReadFile(c.Pusher.BearerTokenFile)
+		if err != nil {
+			return "", "", "", fmt.Errorf("Failed to read pusher bearer token: %v", err)
+		}
+		token = strings.TrimSpace(string(data))
+	}
+	return c.Pusher.Username, password, token, nil
+}
+
+// RestartRequired returns the sections of the configuration that differ between c and newConfig
+// and that cannot be changed at runtime.
+func (c *Config) RestartRequired(newConfig *Config) []string {
+	changed := []string{}
+	if !reflect.DeepEqual(c.Listeners, newConfig.Listeners) {
+		changed = append(changed, "listeners")
+	}
+	if !reflect.DeepEqual(c.Kafka, newConfig.Kafka) {
+		changed = append(changed, "kafka")
+	}
+	if !reflect.DeepEqual(c.AetherConfig, newConfig.AetherConfig) {
+		changed = append(changed, "aether-config")
+	}
+	if !reflect.DeepEqual(c.DiagAPI, newConfig.DiagAPI) {
+		changed = append(changed, "diag-api")
+	}
+	if !reflect.DeepEqual(c.Tracing, newConfig.Tracing) {
+		changed = append(changed, "tracing")
+	}
+	if !reflect.DeepEqual(c.LeaderElection, newConfig.LeaderElection) {
+		changed = append(changed, "leader-election")
+	}
+	if !reflect.DeepEqual(c.GNMI, newConfig.GNMI) {
+		changed = append(changed, "gnmi")
+	}
+	return changed
+}
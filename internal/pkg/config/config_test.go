@@ -0,0 +1,127 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDefaults(t *testing.T) {
+	config, err := Parse([]byte("version: 1\n"))
+	require.NoError(t, err)
+	assert.Equal(t, Default(), config)
+	assert.Equal(t, ":10161", config.Listeners.GNMI)
+	assert.True(t, config.Synchronizer.PostEnable)
+	assert.Equal(t, time.Second*10, config.Synchronizer.PostTimeout)
+}
+
+func TestParse(t *testing.T) {
+	config, err := Parse([]byte(`
+version: 1
+listeners:
+  gnmi: ":5150"
+synchronizer:
+  post-enable: false
+  retry-interval: 30s
+pusher:
+  username: admin
+  password: secret
+diag-api:
+  read-groups: [AetherROCUser]
+gnmi:
+  set-journal: /tmp/set.journal
+  subscriber-overflow: disconnect
+  revisions: 3
+`))
+	require.NoError(t, err)
+	assert.Equal(t, ":5150", config.Listeners.GNMI)
+	assert.Equal(t, ":9851", config.Listeners.Metrics)
+	assert.False(t, config.Synchronizer.PostEnable)
+	assert.True(t, config.Synchronizer.PartialUpdateEnable)
+	assert.Equal(t, time.Second*30, config.Synchronizer.RetryInterval)
+	assert.Equal(t, []string{"AetherROCUser"}, config.DiagAPI.ReadGroups)
+	assert.Equal(t, []string{"AetherROCAdmin"}, config.DiagAPI.WriteGroups)
+	assert.Equal(t, "/tmp/set.journal", config.GNMI.SetJournal)
+	assert.Equal(t, 100, config.GNMI.SubscriberQueueSize)
+	assert.Equal(t, "disconnect", config.GNMI.SubscriberOverflow)
+	assert.Equal(t, 3, config.GNMI.Revisions)
+
+	username, password, token, err := config.PusherCredentials()
+	assert.NoError(t, err)
+	assert.Equal(t, "admin", username)
+	assert.Equal(t, "secret", password)
+	assert.Equal(t, "", token)
+}
+
+func TestParseErrors(t *testing.T) {
+	_, err := Parse([]byte("listeners:\n  gnmi: \":5150\"\n"))
+	assert.EqualError(t, err, "Unsupported config version 0; expected 1")
+
+	_, err = Parse([]byte("version: 2\nnew-section: {}\n"))
+	assert.EqualError(t, err, "Unsupported config version 2; expected 1")
+
+	_, err = Parse([]byte("version: 1\nsynchronizer:\n  post-disable: true\n"))
+	assert.ErrorContains(t, err, "field post-disable not found")
+
+	_, err = Parse([]byte("version: 1\nsynchronizer:\n  post-timeout: 0s\ndiag-api:\n  tls-cert: cert.pem\n"))
+	assert.EqualError(t, err, "Invalid config: synchronizer.post-timeout must be positive; diag-api.tls-cert and diag-api.tls-key must be set together")
+
+	_, err = Parse([]byte("version: 1\npusher:\n  username: admin\n  bearer-token: abc\n"))
+	assert.EqualError(t, err, "Invalid config: pusher.username and a pusher bearer token are mutually exclusive")
+
+	_, err = Parse([]byte("version: 1\ntracing:\n  exporter: jaeger\n"))
+	assert.EqualError(t, err, "Invalid config: tracing.exporter jaeger is not one of none, stdout, or otlp")
+
+	_, err = Parse([]byte("version: 1\nleader-election:\n  mode: file\n  lock-file: \"\"\n"))
+	assert.EqualError(t, err, "Invalid config: leader-election.lock-file must be set if leader-election.mode is file")
+
+	_, err = Parse([]byte("version: 1\nleader-election:\n  mode: etcd\n"))
+	assert.EqualError(t, err, "Invalid config: leader-election.mode etcd is not one of none, file, or kubernetes")
+
+	_, err = Parse([]byte("version: 1\ngnmi:\n  subscriber-queue-size: 0\n  subscriber-overflow: block\n"))
+	assert.EqualError(t, err, "Invalid config: gnmi.subscriber-queue-size must be positive; gnmi.subscriber-overflow block is not one of drop-oldest or disconnect")
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	tokenFile := filepath.Join(dir, "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("abc123\n"), 0600))
+	configFile := filepath.Join(dir, "config.yaml")
+	require.NoError(t, os.WriteFile(configFile, []byte("version: 1\npusher:\n  bearer-token-file: "+tokenFile+"\n"), 0600))
+
+	config, err := Load(configFile)
+	require.NoError(t, err)
+	_, _, token, err := config.PusherCredentials()
+	assert.NoError(t, err)
+	assert.Equal(t, "abc123", token)
+
+	require.NoError(t, os.Remove(tokenFile))
+	_, _, _, err = config.PusherCredentials()
+	assert.ErrorContains(t, err, "Failed to read pusher bearer token")
+
+	_, err = Load(filepath.Join(dir, "missing.yaml"))
+	assert.ErrorContains(t, err, "Failed to read config file")
+}
+
+func TestRestartRequired(t *testing.T) {
+	old := Default()
+	newConfig := Default()
+	newConfig.Synchronizer.PostEnable = false
+	newConfig.Pusher.Username = "admin"
+	assert.Empty(t, old.RestartRequired(newConfig))
+
+	newConfig.Listeners.DiagAPI = 8181
+	newConfig.Kafka.URI = "kafka:9092"
+	newConfig.DiagAPI.AuthEnable = true
+	newConfig.LeaderElection.Mode = "kubernetes"
+	newConfig.GNMI.SetJournal = "/var/log/sdcore-adapter/set.journal"
+	assert.Equal(t, []string{"listeners", "kafka", "diag-api", "leader-election", "gnmi"}, old.RestartRequired(newConfig))
+}
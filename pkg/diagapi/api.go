@@ -24,17 +24,90 @@ package diagapi
  *   AUTH=<...stuff...>
  *   curl --header "Content-Type: application/json" --header "Authorization: Bearer $AUTH" -X POST "http://localhost:8080/pull?target=connectivity-service-v2&aetherConfigAddr=onos-config:5150"
  *
+ *   # force a resync of everything, invalidating the whole push cache
+ *   curl -X POST http://localhost:8080/synchronize
+ *
+ *   # force a resync of a single enterprise, site, slice, or device-group (the arguments may be
+ *   # combined); only the matching resources are invalidated and pushed
+ *   curl -X POST "http://localhost:8080/synchronize?target=acme"
+ *   curl -X POST "http://localhost:8080/synchronize?target=acme&site=acme-chicago"
+ *   curl -X POST "http://localhost:8080/synchronize?slice=acme-chicago-robots"
+ *   curl -X POST "http://localhost:8080/synchronize?dg=acme-chicago-cameras"
+ *
+ *   # pull several targets, or every target aether-config reports, and report the changes per target
+ *   curl -X POST "http://localhost:8080/pull?target=acme,starbucks&aetherConfigAddr=onos-config:5150"
+ *   curl -X POST "http://localhost:8080/pull?target=*&aetherConfigAddr=onos-config:5150"
+ *
+ *   # pull a subtree and merge it into the local tree, reporting what would change without changing it
+ *   curl -g -X POST "http://localhost:8080/pull?target=acme&path=site[site-id=acme-chicago]&dryRun=true"
+ *
+ *   # list the kept revisions of a target's config tree, show one (or the latest), and diff two
+ *   # (to defaults to the latest)
+ *   curl "http://localhost:8080/revisions?target=acme"
+ *   curl "http://localhost:8080/revisions/3?target=acme"
+ *   curl "http://localhost:8080/revisions/latest?target=acme"
+ *   curl "http://localhost:8080/revisions/diff?target=acme&from=3&to=5"
+ *
+ *   # roll a target back to a revision; the rollback is pushed to the core like any other change
+ *   curl -X POST "http://localhost:8080/revisions/3/rollback?target=acme"
+ *
+ *   # show the commit awaiting confirmation after a confirmed-commit Set, and confirm it, so that
+ *   # it is not rolled back when its timeout expires; a rollback that failed, and is to be
+ *   # retried, is reported as its rollback-error
+ *   curl http://localhost:8080/commit/pending
+ *   curl -X POST http://localhost:8080/commit/confirm
+ *
+ *   # reload the configuration file, as on SIGHUP; lists changed settings that need a restart
+ *   curl -X POST http://localhost:8080/config/reload
+ *
  *   # change the synchronizer log level
  *   curl -v -X POST http://localhost:8080/loglevel/root --data "DEBUG"
+ *
+ *   # show what the synchronizer is doing, and the result of the last synchronization
+ *   curl http://localhost:8080/status
+ *
+ *   # list the entries in the synchronizer's push cache, optionally for a single model
+ *   curl http://localhost:8080/pushcache
+ *   curl http://localhost:8080/pushcache/slice
+ *
+ *   # invalidate a single push cache entry, or every entry for a model
+ *   curl -X DELETE http://localhost:8080/pushcache/slice/sample-slice
+ *   curl -X DELETE http://localhost:8080/pushcache/devicegroup
+ *
+ *   # re-push only a single resource, invalidating only its push cache entries
+ *   curl -X POST http://localhost:8080/pushcache/slice/sample-slice/repush
+ *
+ *   # preview the documents the live config would push to the core and UPF, and how they
+ *   # differ from what was last pushed
+ *   curl "http://localhost:8080/preview?target=connectivity-service-v2"
+ *
+ *   # preview the documents a candidate config would push
+ *   curl --header "Content-Type: application/json" -X POST --data @candidate.json "http://localhost:8080/preview?target=connectivity-service-v2"
+ *
+ *   # liveness and readiness probes; return 503 and the failed checks if not healthy
+ *   curl http://localhost:8080/healthz
+ *   curl http://localhost:8080/readyz
+ *
+ * With TLS enabled, use https and, for a self-signed certificate, --cacert or --insecure.
+ *
+ * With authentication enabled, every request other than /healthz and /readyz must carry a
+ * bearer token from the OIDC server, whose groups claim grants the read or write role (see
+ * auth.go). For example:
+ *   curl --header "Authorization: Bearer $AUTH" https://localhost:8080/status
  */
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/onosproject/sdcore-adapter/pkg/gnmiclient"
+	"github.com/onosproject/sdcore-adapter/pkg/synchronizer"
 	"io"
 	"net/http"
+	"net/url"
 	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/onosproject/onos-lib-go/pkg/logging"
@@ -46,22 +119,97 @@ var log = logging.GetLogger("diagapi")
 
 // TargetInterface is an interface to a gNMI Target
 type TargetInterface interface {
-	ExecuteCallbacks(reason gnmi.ConfigCallbackType, target string, path *pb.Path) error
+	ExecuteCallbacks(ctx context.Context, reason gnmi.ConfigCallbackType, target string, path *pb.Path, scope []*pb.Path) error
 	GetJSON(string) ([]byte, error)
 	PutJSON(string, []byte) error
+	MergeJSON(target string, path *pb.Path, b []byte, dryRun bool) (*pb.Notification, error)
+	ListRevisions(target string) []*gnmi.Revision
+	GetRevision(target string, number uint64) (*gnmi.Revision, error)
+	DiffRevisions(target string, from uint64, to uint64) (*pb.Notification, error)
+	RollbackRevision(ctx context.Context, target string, number uint64) (*pb.SetResponse, error)
+	GetPendingCommit() *gnmi.PendingCommit
+	ConfirmCommit() error
+}
+
+// SynchronizerInterface is an interface to the synchronizer
+type SynchronizerInterface interface {
+	GetStatus() synchronizer.Status
+	GetLiveness() synchronizer.Health
+	GetReadiness() synchronizer.Health
+	CacheList(modelName string) ([]synchronizer.CacheEntry, error)
+	CacheDelete(modelName string, modelID string) bool
+	CacheDeleteModel(modelName string) int
+	PreviewJSON(target string, data []byte) (*synchronizer.Preview, error)
 }
 
 // DiagnosticAPI is an api for performing diagnostic operations on the synchronizer
 type DiagnosticAPI struct {
 	targetServer            TargetInterface
+	synchronizer            SynchronizerInterface
 	defaultTarget           string
 	defaultAetherConfigAddr string
+	tlsCertFile             string
+	tlsKeyFile              string
+	auth                    *authConfig
+	reloadConfig            func() ([]string, error)
+	server                  *http.Server
 }
 
+// DiagnosticAPIOption is for options passed when starting the diagnostic API
+type DiagnosticAPIOption func(m *DiagnosticAPI)
+
+// WithTLS serves the diagnostic API over https, using the given certificate and key files
+func WithTLS(certFile string, keyFile string) DiagnosticAPIOption {
+	return func(m *DiagnosticAPI) {
+		m.tlsCertFile = certFile
+		m.tlsKeyFile = keyFile
+	}
+}
+
+// WithConfigReload enables reloading the adapter's configuration file. reload returns the
+// settings that changed but only take effect on restart.
+func WithConfigReload(reload func() ([]string, error)) DiagnosticAPIOption {
+	return func(m *DiagnosticAPI) {
+		m.reloadConfig = reload
+	}
+}
+
+// WithAuthentication requires a bearer token, validated by validator, on every request
+// other than the health probes. Members of readGroups may call read-only endpoints, and
+// members of writeGroups may call every endpoint. If readGroups is empty, any authenticated
+// caller may call read-only endpoints.
+func WithAuthentication(validator TokenValidator, readGroups []string, writeGroups []string) DiagnosticAPIOption {
+	return func(m *DiagnosticAPI) {
+		m.auth = &authConfig{
+			validator:   validator,
+			readGroups:  readGroups,
+			writeGroups: writeGroups,
+		}
+	}
+}
+
+// reSync forces a resynchronization. With no arguments, the whole push cache is invalidated
+// and everything is re-pushed. The target, site, slice, and dg arguments narrow the resync to
+// the matching resources; only their push cache entries are invalidated and only they are
+// pushed.
 func (m *DiagnosticAPI) reSync(w http.ResponseWriter, r *http.Request) {
-	// TODO: tell the target server to synchronize
-	_ = r
-	err := m.targetServer.ExecuteCallbacks(gnmi.Forced, gnmi.AllTargets, nil)
+	queryArgs := r.URL.Query()
+	filter := synchronizer.ResyncFilter{
+		Target:      queryArgs.Get("target"),
+		Site:        queryArgs.Get("site"),
+		Slice:       queryArgs.Get("slice"),
+		DeviceGroup: queryArgs.Get("dg"),
+	}
+
+	target := gnmi.AllTargets
+	if !filter.IsEmpty() {
+		log.Infof("Targeted resync of %s", filter)
+		if filter.Target != "" {
+			target = filter.Target
+		}
+	}
+
+	err := m.targetServer.ExecuteCallbacks(r.Context(), gnmi.Forced, target, nil, filter.Paths())
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -73,6 +221,190 @@ func (m *DiagnosticAPI) reSync(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func (m *DiagnosticAPI) getStatus(w http.ResponseWriter, r *http.Request) {
+	jsonDump, err := json.MarshalIndent(m.synchronizer.GetStatus(), "", "  ")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, err = w.Write(jsonDump)
+	if err != nil {
+		log.Errorf("error writing response: %v", err)
+		return
+	}
+}
+
+func writeHealth(w http.ResponseWriter, health synchronizer.Health) {
+	jsonDump, err := json.MarshalIndent(health, "", "  ")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if !health.Healthy {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	_, err = w.Write(jsonDump)
+	if err != nil {
+		log.Errorf("error writing response: %v", err)
+		return
+	}
+}
+
+func (m *DiagnosticAPI) getHealthz(w http.ResponseWriter, r *http.Request) {
+	writeHealth(w, m.synchronizer.GetLiveness())
+}
+
+func (m *DiagnosticAPI) getReadyz(w http.ResponseWriter, r *http.Request) {
+	writeHealth(w, m.synchronizer.GetReadiness())
+}
+
+// pushCacheModel returns the model from the request path, or writes an error and returns
+// false if the model is not valid.
+func pushCacheModel(w http.ResponseWriter, r *http.Request) (string, bool) {
+	model := mux.Vars(r)["model"]
+	if !synchronizer.IsCacheModel(model) {
+		http.Error(w, fmt.Sprintf("Unknown push cache model %s; must be one of %s", model,
+			strings.Join(synchronizer.CacheModels, ", ")), http.StatusNotFound)
+		return "", false
+	}
+	return model, true
+}
+
+func (m *DiagnosticAPI) getPushCache(w http.ResponseWriter, r *http.Request) {
+	model := ""
+	if _, okay := mux.Vars(r)["model"]; okay {
+		if model, okay = pushCacheModel(w, r); !okay {
+			return
+		}
+	}
+
+	entries, err := m.synchronizer.CacheList(model)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	jsonDump, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, err = w.Write(jsonDump)
+	if err != nil {
+		log.Errorf("error writing response: %v", err)
+		return
+	}
+}
+
+func (m *DiagnosticAPI) deletePushCacheModel(w http.ResponseWriter, r *http.Request) {
+	model, okay := pushCacheModel(w, r)
+	if !okay {
+		return
+	}
+
+	count := m.synchronizer.CacheDeleteModel(model)
+	log.Infof("Invalidated %d push cache entries for %s", count, model)
+
+	_, err := fmt.Fprintf(w, "SUCCESS")
+	if err != nil {
+		log.Errorf("error writing response: %v", err)
+		return
+	}
+}
+
+func (m *DiagnosticAPI) deletePushCacheEntry(w http.ResponseWriter, r *http.Request) {
+	model, okay := pushCacheModel(w, r)
+	if !okay {
+		return
+	}
+	id := mux.Vars(r)["id"]
+
+	if !m.synchronizer.CacheDelete(model, id) {
+		http.Error(w, fmt.Sprintf("Push cache entry %s %s not found", model, id), http.StatusNotFound)
+		return
+	}
+	log.Infof("Invalidated push cache entry %s %s", model, id)
+
+	_, err := fmt.Fprintf(w, "SUCCESS")
+	if err != nil {
+		log.Errorf("error writing response: %v", err)
+		return
+	}
+}
+
+// repushPushCacheEntry re-pushes a single resource, by a targeted resync of it: only its push
+// cache entries are invalidated, and only it is synchronized. A slice is pushed to both the core
+// and the UPF, whichever of its entries is named.
+func (m *DiagnosticAPI) repushPushCacheEntry(w http.ResponseWriter, r *http.Request) {
+	model, okay := pushCacheModel(w, r)
+	if !okay {
+		return
+	}
+	id := mux.Vars(r)["id"]
+
+	filter := synchronizer.ResyncFilter{Slice: id}
+	if model == synchronizer.CacheModelDeviceGroup {
+		filter = synchronizer.ResyncFilter{DeviceGroup: id}
+	}
+	log.Infof("Re-pushing %s %s", model, id)
+
+	err := m.targetServer.ExecuteCallbacks(r.Context(), gnmi.Forced, gnmi.AllTargets, nil, filter.Paths())
+	if errors.Is(err, synchronizer.ErrStandby) {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	_, err = fmt.Fprintf(w, "SUCCESS")
+	if err != nil {
+		log.Errorf("error writing response: %v", err)
+		return
+	}
+}
+
+// preview renders the southbound documents for the live config, or for a candidate config if
+// one is posted.
+func (m *DiagnosticAPI) preview(w http.ResponseWriter, r *http.Request) {
+	queryArgs := r.URL.Query()
+
+	target := queryArgs.Get("target")
+	if target == "" {
+		target = m.defaultTarget
+	}
+
+	var configJSON []byte
+	var err error
+	if r.Method == "POST" {
+		configJSON, err = io.ReadAll(r.Body)
+	} else {
+		configJSON, err = m.targetServer.GetJSON(target)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	preview, err := m.synchronizer.PreviewJSON(target, configJSON)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	jsonDump, err := json.MarshalIndent(preview, "", "  ")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, err = w.Write(jsonDump)
+	if err != nil {
+		log.Errorf("error writing response: %v", err)
+		return
+	}
+}
+
 func (m *DiagnosticAPI) getCache(w http.ResponseWriter, r *http.Request) {
 	queryArgs := r.URL.Query()
 
@@ -141,40 +473,185 @@ func (m *DiagnosticAPI) postCache(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func (m *DiagnosticAPI) pullFromOnosConfig(w http.ResponseWriter, r *http.Request) {
-	queryArgs := r.URL.Query()
+// PullResult is the result of pulling one target from aether-config. Updated and Deleted list
+// the paths that changed, or that would change in a dry run.
+type PullResult struct {
+	Target  string   `json:"target"`
+	Updated []string `json:"updated,omitempty"`
+	Deleted []string `json:"deleted,omitempty"`
+	Error   string   `json:"error,omitempty"`
+}
 
-	target := queryArgs.Get("target")
-	if target == "" {
-		target = m.defaultTarget
+// PullResponse is the response to a pull
+type PullResponse struct {
+	Path    string        `json:"path,omitempty"`
+	DryRun  bool          `json:"dry-run"`
+	Targets []*PullResult `json:"targets"`
+}
+
+// errDiscoverTargets is wrapped by the error of pullTargets when aether-config cannot be asked
+// for its targets, as opposed to the target arguments being wrong.
+var errDiscoverTargets = errors.New("Cannot discover the targets")
+
+// pullTargets returns the targets named by the target arguments, which may be repeated or
+// comma-separated, or the default target if there are none. The target "*" pulls every target
+// that aether-config reports; it cannot be combined with other targets.
+func (m *DiagnosticAPI) pullTargets(ctx context.Context, queryArgs url.Values, aetherConfigAddr string) ([]string, error) {
+	targets := []string{}
+	wildcard := false
+	for _, arg := range queryArgs["target"] {
+		for _, target := range strings.Split(arg, ",") {
+			if target = strings.TrimSpace(target); target == "" {
+				continue
+			}
+			if target == "*" {
+				wildcard = true
+				continue
+			}
+			if strings.Contains(target, "*") {
+				return nil, fmt.Errorf("Target %s is not supported; use * to pull every target", target)
+			}
+			targets = append(targets, target)
+		}
+	}
+	if wildcard {
+		if len(targets) > 0 {
+			return nil, fmt.Errorf("Target * cannot be combined with %s", strings.Join(targets, ", "))
+		}
+		targets, err := gnmiclient.GetTargets(ctx, aetherConfigAddr)
+		if err != nil {
+			return nil, fmt.Errorf("%w from %s: %v", errDiscoverTargets, aetherConfigAddr, err)
+		}
+		if len(targets) == 0 {
+			return nil, fmt.Errorf("%w from %s: it reports no targets", errDiscoverTargets, aetherConfigAddr)
+		}
+		return targets, nil
 	}
+	if len(targets) == 0 {
+		if m.defaultTarget == "" {
+			return nil, fmt.Errorf("No target given, and there is no default target; list the targets to pull, or use *")
+		}
+		targets = append(targets, m.defaultTarget)
+	}
+	return targets, nil
+}
+
+// pullTarget pulls the subtree at path of one target from aether-config, and merges it into
+// the local tree.
+func (m *DiagnosticAPI) pullTarget(ctx context.Context, target string, path string, aetherConfigAddr string, dryRun bool) *PullResult {
+	result := &PullResult{Target: target}
+
+	srcVal, err := gnmiclient.GetPath(ctx, path, target, aetherConfigAddr)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	srcJSONBytes := srcVal.GetJsonVal()
+	if srcJSONBytes == nil {
+		if path != "" {
+			result.Error = fmt.Sprintf("aether-config has no configuration at %s", path)
+			return result
+		}
+		// an empty target replaces the local tree with an empty one
+		srcJSONBytes = []byte("{}")
+	}
+
+	changes, err := m.targetServer.MergeJSON(target, gnmiclient.StringToPath(path, target), srcJSONBytes, dryRun)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	for _, update := range changes.GetUpdate() {
+		result.Updated = append(result.Updated, gnmi.PrefixAndPathToString(changes.GetPrefix(), update.GetPath()))
+	}
+	for _, deleted := range changes.GetDelete() {
+		result.Deleted = append(result.Deleted, gnmi.PrefixAndPathToString(changes.GetPrefix(), deleted))
+	}
+	return result
+}
+
+// pullFromOnosConfig pulls one or more targets from aether-config and merges them into the
+// local tree, reporting the changes per target. With a path argument, only that subtree is
+// pulled and replaced; the rest of the local tree is kept. With dryRun=true, the changes are
+// reported but not made.
+func (m *DiagnosticAPI) pullFromOnosConfig(w http.ResponseWriter, r *http.Request) {
+	queryArgs := r.URL.Query()
 
 	aetherConfigAddr := queryArgs.Get("aetherConfigAddr")
 	if aetherConfigAddr == "" {
 		aetherConfigAddr = m.defaultAetherConfigAddr
 	}
 
-	log.Infof("Pull, aetherConfig=%s, target=%s", aetherConfigAddr, target)
+	path := queryArgs.Get("path")
+	dryRun := queryArgs.Get("dryRun") == "true"
 
 	ctx := context.Background()
 	if auth := r.Header.Get("Authorization"); auth != "" {
 		ctx = gnmiclient.WithAuthorization(ctx, auth)
 	}
 
-	srcVal, err := gnmiclient.GetPath(ctx, "", target, aetherConfigAddr)
+	targets, err := m.pullTargets(ctx, queryArgs, aetherConfigAddr)
+	if errors.Is(err, errDiscoverTargets) {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	log.Infof("Pull, aetherConfig=%s, targets=%v, path=%s, dryRun=%v", aetherConfigAddr, targets, path, dryRun)
+
+	response := PullResponse{Path: path, DryRun: dryRun, Targets: []*PullResult{}}
+	code := http.StatusOK
+	for _, target := range targets {
+		result := m.pullTarget(ctx, target, path, aetherConfigAddr, dryRun)
+		if result.Error != "" {
+			log.Warnf("Pull of %s failed: %s", target, result.Error)
+			code = http.StatusInternalServerError
+		}
+		response.Targets = append(response.Targets, result)
+	}
+
+	jsonDump, err := json.MarshalIndent(response, "", "  ")
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_, err = w.Write(jsonDump)
+	if err != nil {
+		log.Errorf("error writing response: %v", err)
+		return
+	}
+}
 
-	srcJSONBytes := srcVal.GetJsonVal()
+// ConfigReloadResponse is the response to a configuration reload
+type ConfigReloadResponse struct {
+	RestartRequired []string `json:"restart-required"`
+}
+
+// reloadConfigFile reloads the adapter's configuration file
+func (m *DiagnosticAPI) reloadConfigFile(w http.ResponseWriter, r *http.Request) {
+	if m.reloadConfig == nil {
+		http.Error(w, "the adapter was not started with a configuration file", http.StatusNotFound)
+		return
+	}
+
+	restartRequired, err := m.reloadConfig()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
-	err = m.targetServer.PutJSON(target, srcJSONBytes)
+	jsonDump, err := json.MarshalIndent(ConfigReloadResponse{RestartRequired: restartRequired}, "", "  ")
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	_, err = fmt.Fprintf(w, "SUCCESS")
+	w.Header().Set("Content-Type", "application/json")
+	_, err = w.Write(jsonDump)
 	if err != nil {
 		log.Errorf("error writing response: %v", err)
 		return
@@ -248,25 +725,76 @@ func (m *DiagnosticAPI) setLogLevel(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func (m *DiagnosticAPI) handleRequests(port uint) {
+func (m *DiagnosticAPI) newRouter() *mux.Router {
 	myRouter := mux.NewRouter().StrictSlash(true)
-	myRouter.HandleFunc("/synchronize", m.reSync).Methods("POST")
-	myRouter.HandleFunc("/cache", m.getCache).Methods("GET")
-	myRouter.HandleFunc("/cache", m.postCache).Methods("POST")
-	myRouter.HandleFunc("/cache", m.deleteCache).Methods("DELETE")
-	myRouter.HandleFunc("/pull", m.pullFromOnosConfig).Methods("POST")
-	myRouter.HandleFunc("/loglevel/{logger}", m.getLogLevel).Methods("GET")
-	myRouter.HandleFunc("/loglevel/{logger}", m.setLogLevel).Methods("POST")
-	log.Fatal(http.ListenAndServe(fmt.Sprintf(":%d", port), myRouter))
+	myRouter.HandleFunc("/synchronize", m.withRole(RoleWrite, m.reSync)).Methods("POST")
+	myRouter.HandleFunc("/status", m.withRole(RoleRead, m.getStatus)).Methods("GET")
+	myRouter.HandleFunc("/pushcache", m.withRole(RoleRead, m.getPushCache)).Methods("GET")
+	myRouter.HandleFunc("/pushcache/{model}", m.withRole(RoleRead, m.getPushCache)).Methods("GET")
+	myRouter.HandleFunc("/pushcache/{model}", m.withRole(RoleWrite, m.deletePushCacheModel)).Methods("DELETE")
+	myRouter.HandleFunc("/pushcache/{model}/{id}", m.withRole(RoleWrite, m.deletePushCacheEntry)).Methods("DELETE")
+	myRouter.HandleFunc("/pushcache/{model}/{id}/repush", m.withRole(RoleWrite, m.repushPushCacheEntry)).Methods("POST")
+	// a preview has no side effects, even when a candidate config is posted
+	myRouter.HandleFunc("/preview", m.withRole(RoleRead, m.preview)).Methods("GET", "POST")
+	myRouter.HandleFunc("/healthz", m.withRole(RoleNone, m.getHealthz)).Methods("GET")
+	myRouter.HandleFunc("/readyz", m.withRole(RoleNone, m.getReadyz)).Methods("GET")
+	myRouter.HandleFunc("/cache", m.withRole(RoleRead, m.getCache)).Methods("GET")
+	myRouter.HandleFunc("/cache", m.withRole(RoleWrite, m.postCache)).Methods("POST")
+	myRouter.HandleFunc("/cache", m.withRole(RoleWrite, m.deleteCache)).Methods("DELETE")
+	myRouter.HandleFunc("/pull", m.withRole(RoleWrite, m.pullFromOnosConfig)).Methods("POST")
+	myRouter.HandleFunc("/loglevel/{logger}", m.withRole(RoleRead, m.getLogLevel)).Methods("GET")
+	myRouter.HandleFunc("/loglevel/{logger}", m.withRole(RoleWrite, m.setLogLevel)).Methods("POST")
+	myRouter.HandleFunc("/config/reload", m.withRole(RoleWrite, m.reloadConfigFile)).Methods("POST")
+	myRouter.HandleFunc("/revisions", m.withRole(RoleRead, m.listRevisions)).Methods("GET")
+	myRouter.HandleFunc("/revisions/diff", m.withRole(RoleRead, m.diffRevisions)).Methods("GET")
+	myRouter.HandleFunc("/revisions/latest", m.withRole(RoleRead, m.getRevision)).Methods("GET")
+	myRouter.HandleFunc("/revisions/{number:[0-9]+}", m.withRole(RoleRead, m.getRevision)).Methods("GET")
+	myRouter.HandleFunc("/revisions/{number:[0-9]+}/rollback", m.withRole(RoleWrite, m.rollbackRevision)).Methods("POST")
+	myRouter.HandleFunc("/commit/pending", m.withRole(RoleRead, m.getPendingCommit)).Methods("GET")
+	myRouter.HandleFunc("/commit/confirm", m.withRole(RoleWrite, m.confirmCommit)).Methods("POST")
+	return myRouter
 }
 
-// StartDiagnosticAPI starts the Diagnostic API, serving requests
+func (m *DiagnosticAPI) handleRequests() {
+	var err error
+	if m.tlsCertFile != "" {
+		err = m.server.ListenAndServeTLS(m.tlsCertFile, m.tlsKeyFile)
+	} else {
+		err = m.server.ListenAndServe()
+	}
+	if err != http.ErrServerClosed {
+		log.Fatal(err)
+	}
+}
+
+// Shutdown stops the Diagnostic API, waiting until ctx is done for requests in progress to
+// complete.
+func (m *DiagnosticAPI) Shutdown(ctx context.Context) error {
+	return m.server.Shutdown(ctx)
+}
+
+// StartDiagnosticAPI starts the Diagnostic API, serving requests until Shutdown is called
 func StartDiagnosticAPI(targetServer TargetInterface,
+	synchronizer SynchronizerInterface,
 	defaultAetherConfigAddr string,
 	defaultTarget string,
-	port uint) {
+	port uint,
+	opts ...DiagnosticAPIOption) *DiagnosticAPI {
 	m := DiagnosticAPI{targetServer: targetServer,
+		synchronizer:            synchronizer,
 		defaultAetherConfigAddr: defaultAetherConfigAddr,
 		defaultTarget:           defaultTarget}
-	go m.handleRequests(port)
+	for _, opt := range opts {
+		opt(&m)
+	}
+	if m.auth == nil {
+		log.Warn("Diagnostic API authentication is disabled")
+	}
+	m.server = &http.Server{
+		Addr:              fmt.Sprintf(":%d", port),
+		Handler:           m.newRouter(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go m.handleRequests()
+	return &m
 }
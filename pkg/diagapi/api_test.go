@@ -0,0 +1,153 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package diagapi
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"flag"
+	"net"
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+
+	models "github.com/onosproject/aether-models/models/aether-2.1.x/v2/api"
+	"github.com/onosproject/onos-lib-go/pkg/certs"
+	"github.com/onosproject/sdcore-adapter/pkg/gnmi"
+	"github.com/onosproject/sdcore-adapter/pkg/synchronizer"
+	pb "github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/ygot/ygot"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+)
+
+// fakeAetherConfig answers a Get of the root of the wildcard target with a list of targets,
+// as onos-config does
+type fakeAetherConfig struct {
+	pb.UnimplementedGNMIServer
+	targets []string
+}
+
+func (f *fakeAetherConfig) Get(ctx context.Context, req *pb.GetRequest) (*pb.GetResponse, error) {
+	if len(req.Path) != 1 || req.Path[0].Target != "*" || len(req.Path[0].Elem) != 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "unexpected get of %v", req.Path)
+	}
+	data, err := json.Marshal(f.targets)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.GetResponse{Notification: []*pb.Notification{{Update: []*pb.Update{{
+		Path: &pb.Path{},
+		Val:  &pb.TypedValue{Value: &pb.TypedValue_JsonVal{JsonVal: data}},
+	}}}}}, nil
+}
+
+// startFakeAetherConfig starts a gNMI server on a local port, returning its address
+func startFakeAetherConfig(t *testing.T, targets []string) string {
+	// The default localhost certificate has no subject alternative name
+	require.NoError(t, flag.Set("hostCheckDisabled", "true"))
+	t.Cleanup(func() { _ = flag.Set("hostCheckDisabled", "false") })
+
+	certificate, err := tls.X509KeyPair([]byte(certs.DefaultLocalhostCrt), []byte(certs.DefaultLocalhostKey))
+	require.NoError(t, err)
+	lis, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+	grpcServer := grpc.NewServer(grpc.Creds(credentials.NewServerTLSFromCert(&certificate)))
+	pb.RegisterGNMIServer(grpcServer, &fakeAetherConfig{targets: targets})
+	go func() {
+		_ = grpcServer.Serve(lis)
+	}()
+	t.Cleanup(grpcServer.Stop)
+	return lis.Addr().String()
+}
+
+func TestPullTargets(t *testing.T) {
+	m := &DiagnosticAPI{defaultTarget: "connectivity-service-v2"}
+	ctx := context.Background()
+
+	targets, err := m.pullTargets(ctx, url.Values{"target": {"acme, starbucks", "", "other"}}, "")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"acme", "starbucks", "other"}, targets)
+
+	targets, err = m.pullTargets(ctx, url.Values{}, "")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"connectivity-service-v2"}, targets)
+
+	_, err = m.pullTargets(ctx, url.Values{"target": {"acme*"}}, "")
+	assert.EqualError(t, err, "Target acme* is not supported; use * to pull every target")
+
+	_, err = m.pullTargets(ctx, url.Values{"target": {"acme,*"}}, "")
+	assert.EqualError(t, err, "Target * cannot be combined with acme")
+
+	m.defaultTarget = ""
+	_, err = m.pullTargets(ctx, url.Values{"target": {" "}}, "")
+	assert.EqualError(t, err, "No target given, and there is no default target; list the targets to pull, or use *")
+}
+
+func TestPullTargetsDiscovered(t *testing.T) {
+	m := &DiagnosticAPI{}
+	ctx := context.Background()
+
+	addr := startFakeAetherConfig(t, []string{"acme", "starbucks"})
+	targets, err := m.pullTargets(ctx, url.Values{"target": {"*"}}, addr)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"acme", "starbucks"}, targets)
+
+	addr = startFakeAetherConfig(t, []string{})
+	_, err = m.pullTargets(ctx, url.Values{"target": {"*"}}, addr)
+	assert.ErrorIs(t, err, errDiscoverTargets)
+	assert.EqualError(t, err, "Cannot discover the targets from "+addr+": it reports no targets")
+
+	// A failure to discover the targets is not a bad request
+	w := serve(&DiagnosticAPI{defaultAetherConfigAddr: addr}, "POST", "/pull?target=*")
+	assert.Equal(t, http.StatusBadGateway, w.Code)
+	w = serve(&DiagnosticAPI{defaultAetherConfigAddr: addr}, "POST", "/pull?target=acme*")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestRepushPushCacheEntry(t *testing.T) {
+	var scopes [][]string
+	var callbackErr error
+	callback := func(ctx context.Context, config *gnmi.ConfigForest, callbackType gnmi.ConfigCallbackType,
+		target string, path *pb.Path, scope []*pb.Path) error {
+		assert.Equal(t, gnmi.Forced, callbackType)
+		paths := []string{}
+		for _, p := range scope {
+			paths = append(paths, gnmi.PathToString(p))
+		}
+		scopes = append(scopes, paths)
+		return callbackErr
+	}
+	model := gnmi.NewModel(nil, reflect.TypeOf((*models.Device)(nil)), models.SchemaTree["Device"],
+		models.Unmarshal, map[string]map[int64]ygot.EnumDefinition{})
+	s, err := gnmi.NewServer(model, callback)
+	require.NoError(t, err)
+	t.Cleanup(s.Close)
+	m := &DiagnosticAPI{targetServer: s}
+
+	// Only the named resource is resynchronized
+	w := serve(m, "POST", "/pushcache/slice/sample-slice/repush")
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = serve(m, "POST", "/pushcache/slice-upf/sample-slice/repush")
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = serve(m, "POST", "/pushcache/devicegroup/sample-dg/repush")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, [][]string{
+		{"site[site-id=*]/slice[slice-id=sample-slice]"},
+		{"site[site-id=*]/slice[slice-id=sample-slice]"},
+		{"site[site-id=*]/device-group[device-group-id=sample-dg]"},
+	}, scopes)
+
+	// A standby replica does not push
+	callbackErr = synchronizer.ErrStandby
+	w = serve(m, "POST", "/pushcache/slice/sample-slice/repush")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
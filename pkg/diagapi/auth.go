@@ -0,0 +1,203 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package diagapi
+
+/*
+ * auth.go: authentication and authorization for the diagnostic API
+ *
+ * When enabled, every request other than the health probes must carry an OIDC bearer token
+ * in the Authorization header, as issued by the same Keycloak server that gnmiclient uses
+ * (OIDC_SERVER_URL). HS256 tokens signed with SHARED_SECRET_KEY are also accepted, but only
+ * if that secret is set. The token's "groups" claim determines the caller's role:
+ *
+ *   read  - may call endpoints that only report state (GET /status, GET /cache, ...)
+ *   write - may also call endpoints that change state (POST /cache, POST /pull, ...)
+ *
+ * A caller with the write role also has the read role. If no read groups are configured,
+ * any authenticated caller has the read role.
+ */
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/onosproject/onos-lib-go/pkg/auth"
+	"github.com/onosproject/sdcore-adapter/pkg/gnmi"
+	"github.com/onosproject/sdcore-adapter/pkg/gnmiclient"
+)
+
+// Role is the access required to call an endpoint
+type Role int
+
+const (
+	// RoleNone endpoints may be called without authentication, such as health probes
+	RoleNone Role = iota
+	// RoleRead endpoints only report state
+	RoleRead
+	// RoleWrite endpoints change the state of the adapter or the core
+	RoleWrite
+)
+
+func (r Role) String() string {
+	return [...]string{"none", "read", "write"}[r]
+}
+
+// GroupsClaim is the token claim that lists the groups the caller belongs to
+const GroupsClaim = "groups"
+
+// UserClaims are the token claims that name the caller, in order of preference
+var UserClaims = []string{"preferred_username", "email", "sub"}
+
+// TokenValidator validates a bearer token and returns its claims
+type TokenValidator interface {
+	Validate(token string) (map[string]interface{}, error)
+}
+
+// OIDCValidator validates tokens against the keys published by the OIDC server named by
+// the OIDC_SERVER_URL environment variable, or against SHARED_SECRET_KEY.
+type OIDCValidator struct {
+	authenticator auth.JwtAuthenticator
+}
+
+// NewOIDCValidator returns a validator for the tokens issued by the OIDC server that
+// gnmiclient uses. It is an error if neither that server nor a shared secret is configured,
+// since no token could then be validated.
+func NewOIDCValidator() (*OIDCValidator, error) {
+	if (gnmiclient.OpenIDIssuer() == "") && (os.Getenv(auth.SharedSecretKey) == "") {
+		return nil, fmt.Errorf("Neither %s nor %s is set", auth.OIDCServerURL, auth.SharedSecretKey)
+	}
+	return &OIDCValidator{}, nil
+}
+
+// Validate validates a token and returns its claims
+func (v *OIDCValidator) Validate(token string) (map[string]interface{}, error) {
+	alg, err := tokenAlgorithm(token)
+	if err != nil {
+		return nil, err
+	}
+	// The authenticator would check an HS* token against an empty key if the secret is not
+	// set, and so accept a token that anyone can sign.
+	if strings.HasPrefix(alg, auth.HS) && (os.Getenv(auth.SharedSecretKey) == "") {
+		return nil, fmt.Errorf("Signing algorithm %s requires %s, which is not set", alg, auth.SharedSecretKey)
+	}
+	return v.authenticator.ParseAndValidate(token)
+}
+
+// tokenAlgorithm returns the signing algorithm named by the header of a JWT, without
+// verifying the token
+func tokenAlgorithm(token string) (string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", fmt.Errorf("Token is not a JWT")
+	}
+	header, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[0], "="))
+	if err != nil {
+		return "", fmt.Errorf("Malformed token header: %v", err)
+	}
+	var fields struct {
+		Alg string `json:"alg"`
+	}
+	if err := json.Unmarshal(header, &fields); err != nil {
+		return "", fmt.Errorf("Malformed token header: %v", err)
+	}
+	return fields.Alg, nil
+}
+
+// authConfig holds the authorization settings of the diagnostic API
+type authConfig struct {
+	validator   TokenValidator
+	readGroups  []string
+	writeGroups []string
+}
+
+// claimGroups returns the groups listed in the token's claims
+func claimGroups(claims map[string]interface{}) []string {
+	groups := []string{}
+	switch value := claims[GroupsClaim].(type) {
+	case []interface{}:
+		for _, group := range value {
+			if s, okay := group.(string); okay {
+				groups = append(groups, s)
+			}
+		}
+	case string:
+		groups = append(groups, value)
+	}
+	return groups
+}
+
+// claimUser returns the name of the caller from the token's claims
+func claimUser(claims map[string]interface{}) string {
+	for _, claim := range UserClaims {
+		if user, okay := claims[claim].(string); okay && user != "" {
+			return user
+		}
+	}
+	return ""
+}
+
+// inGroups returns true if any of groups is in allowed
+func inGroups(groups []string, allowed []string) bool {
+	for _, group := range groups {
+		for _, a := range allowed {
+			if group == a {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// authorize returns an error and an HTTP status code if the request may not call an
+// endpoint that requires role. Otherwise, it returns the name of the caller, if known.
+func (a *authConfig) authorize(r *http.Request, role Role) (string, int, error) {
+	if role == RoleNone {
+		return "", http.StatusOK, nil
+	}
+
+	header := r.Header.Get("Authorization")
+	if !strings.HasPrefix(header, "Bearer ") {
+		return "", http.StatusUnauthorized, fmt.Errorf("Missing bearer token")
+	}
+	claims, err := a.validator.Validate(strings.TrimPrefix(header, "Bearer "))
+	if err != nil {
+		return "", http.StatusUnauthorized, fmt.Errorf("Invalid bearer token: %v", err)
+	}
+
+	groups := claimGroups(claims)
+	if inGroups(groups, a.writeGroups) {
+		return claimUser(claims), http.StatusOK, nil
+	}
+	if (role == RoleRead) && ((len(a.readGroups) == 0) || inGroups(groups, a.readGroups)) {
+		return claimUser(claims), http.StatusOK, nil
+	}
+	return "", http.StatusForbidden, fmt.Errorf("Caller does not have the %s role", role)
+}
+
+// withRole wraps a handler so that it is only called if the request is authorized for role.
+// If authentication is not enabled, the handler is always called. The caller named by the
+// token is passed to the handler in the request's context, so that the gNMI server can
+// record who made a change.
+func (m *DiagnosticAPI) withRole(role Role, handler http.HandlerFunc) http.HandlerFunc {
+	if m.auth == nil {
+		return handler
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, code, err := m.auth.authorize(r, role)
+		if err != nil {
+			log.Warnf("Denied %s %s: %v", r.Method, r.URL.Path, err)
+			http.Error(w, err.Error(), code)
+			return
+		}
+		if user != "" {
+			r = r.WithContext(gnmi.WithUser(r.Context(), user))
+		}
+		handler(w, r)
+	}
+}
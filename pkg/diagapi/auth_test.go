@@ -0,0 +1,112 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package diagapi
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/onosproject/onos-lib-go/pkg/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeValidator accepts tokens that name one of its entries, and returns its claims
+type fakeValidator map[string]map[string]interface{}
+
+func (v fakeValidator) Validate(token string) (map[string]interface{}, error) {
+	claims, okay := v[token]
+	if !okay {
+		return nil, errors.New("bad signature")
+	}
+	return claims, nil
+}
+
+func callWithRole(m *DiagnosticAPI, role Role, token string) int {
+	handler := m.withRole(role, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	req := httptest.NewRequest("GET", "/status", nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	handler(w, req)
+	return w.Code
+}
+
+func TestWithRole(t *testing.T) {
+	validator := fakeValidator{
+		"admin":  {"groups": []interface{}{"AetherROCAdmin", "other"}},
+		"reader": {"groups": []interface{}{"Readers"}},
+		"nobody": {"groups": "other"},
+	}
+
+	// Without authentication, everything is allowed
+	m := &DiagnosticAPI{}
+	assert.Equal(t, http.StatusOK, callWithRole(m, RoleWrite, ""))
+
+	WithAuthentication(validator, []string{"Readers"}, []string{"AetherROCAdmin"})(m)
+	assert.Equal(t, http.StatusOK, callWithRole(m, RoleNone, ""))
+	assert.Equal(t, http.StatusUnauthorized, callWithRole(m, RoleRead, ""))
+	assert.Equal(t, http.StatusUnauthorized, callWithRole(m, RoleRead, "forged"))
+
+	assert.Equal(t, http.StatusOK, callWithRole(m, RoleRead, "admin"))
+	assert.Equal(t, http.StatusOK, callWithRole(m, RoleWrite, "admin"))
+	assert.Equal(t, http.StatusOK, callWithRole(m, RoleRead, "reader"))
+	assert.Equal(t, http.StatusForbidden, callWithRole(m, RoleWrite, "reader"))
+	assert.Equal(t, http.StatusForbidden, callWithRole(m, RoleRead, "nobody"))
+
+	// With no read groups, any authenticated caller may read
+	WithAuthentication(validator, nil, []string{"AetherROCAdmin"})(m)
+	assert.Equal(t, http.StatusOK, callWithRole(m, RoleRead, "nobody"))
+	assert.Equal(t, http.StatusForbidden, callWithRole(m, RoleWrite, "nobody"))
+}
+
+func TestClaimUser(t *testing.T) {
+	assert.Equal(t, "alice", claimUser(map[string]interface{}{"preferred_username": "alice", "sub": "1234"}))
+	assert.Equal(t, "1234", claimUser(map[string]interface{}{"email": "", "sub": "1234"}))
+	assert.Equal(t, "", claimUser(map[string]interface{}{}))
+}
+
+// hs256Token returns a token with the write group, signed by HS256 with key
+func hs256Token(key string) string {
+	encode := base64.RawURLEncoding.EncodeToString
+	unsigned := encode([]byte(`{"alg":"HS256","typ":"JWT"}`)) + "." +
+		encode([]byte(`{"preferred_username":"mallory","groups":["AetherROCAdmin"]}`))
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(unsigned))
+	return unsigned + "." + encode(mac.Sum(nil))
+}
+
+func TestOIDCValidatorSharedSecret(t *testing.T) {
+	t.Setenv(auth.OIDCServerURL, "")
+	t.Setenv(auth.SharedSecretKey, "")
+
+	// With nothing to validate tokens against, there is no validator
+	_, err := NewOIDCValidator()
+	assert.EqualError(t, err, "Neither OIDC_SERVER_URL nor SHARED_SECRET_KEY is set")
+
+	// A token signed with an empty key is not accepted when the secret is not set
+	validator := &OIDCValidator{}
+	_, err = validator.Validate(hs256Token(""))
+	assert.EqualError(t, err, "Signing algorithm HS256 requires SHARED_SECRET_KEY, which is not set")
+	_, err = validator.Validate("not-a-token")
+	assert.Error(t, err)
+
+	t.Setenv(auth.SharedSecretKey, "secret")
+	validator, err = NewOIDCValidator()
+	require.NoError(t, err)
+	claims, err := validator.Validate(hs256Token("secret"))
+	require.NoError(t, err)
+	assert.Equal(t, "mallory", claimUser(claims))
+	_, err = validator.Validate(hs256Token(""))
+	assert.Error(t, err)
+}
@@ -0,0 +1,186 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package diagapi
+
+/*
+ * revisions.go: the revision history of the gNMI server's config trees
+ *
+ * The gNMI server keeps the last few revisions of each target's config tree. These endpoints
+ * list them, show one, diff two, and roll a target back to one. A rollback is applied to the
+ * core by the synchronizer, like any other change.
+ *
+ * A gNMI Set with the confirmed commit extension is rolled back unless it is confirmed before its
+ * timeout. The pending commit can be shown and confirmed here, for an operator whose gNMI client
+ * cannot send the confirmation.
+ */
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/onosproject/sdcore-adapter/pkg/gnmi"
+	"github.com/openconfig/gnmi/value"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RevisionDiff is the changes from one revision of a target to another. Updated maps the
+// paths of the leaves that were added or changed to their new values.
+type RevisionDiff struct {
+	Target  string                 `json:"target"`
+	From    uint64                 `json:"from"`
+	To      uint64                 `json:"to"`
+	Updated map[string]interface{} `json:"updated,omitempty"`
+	Deleted []string               `json:"deleted,omitempty"`
+}
+
+// revisionNumber parses a revision number. An empty string is the latest revision, zero.
+func revisionNumber(s string) (uint64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	number, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid revision number %s", s)
+	}
+	return number, nil
+}
+
+// revisionTarget returns the target argument of the request, or the default target
+func (m *DiagnosticAPI) revisionTarget(r *http.Request) string {
+	target := r.URL.Query().Get("target")
+	if target == "" {
+		target = m.defaultTarget
+	}
+	return target
+}
+
+// writeRevisionError writes err, as not found if the revision does not exist
+func writeRevisionError(w http.ResponseWriter, err error) {
+	code := http.StatusInternalServerError
+	if status.Code(err) == codes.NotFound {
+		code = http.StatusNotFound
+	}
+	http.Error(w, err.Error(), code)
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	jsonDump, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, err = w.Write(jsonDump)
+	if err != nil {
+		log.Errorf("error writing response: %v", err)
+		return
+	}
+}
+
+func (m *DiagnosticAPI) listRevisions(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, m.targetServer.ListRevisions(m.revisionTarget(r)))
+}
+
+func (m *DiagnosticAPI) getRevision(w http.ResponseWriter, r *http.Request) {
+	number, err := revisionNumber(mux.Vars(r)["number"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	revision, err := m.targetServer.GetRevision(m.revisionTarget(r), number)
+	if err != nil {
+		writeRevisionError(w, err)
+		return
+	}
+	writeJSON(w, revision)
+}
+
+// diffRevisions reports the changes from revision "from" to revision "to", which is the latest
+// revision if it is not given
+func (m *DiagnosticAPI) diffRevisions(w http.ResponseWriter, r *http.Request) {
+	queryArgs := r.URL.Query()
+	if queryArgs.Get("from") == "" {
+		http.Error(w, "The revision to diff from must be given", http.StatusBadRequest)
+		return
+	}
+	from, err := revisionNumber(queryArgs.Get("from"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	to, err := revisionNumber(queryArgs.Get("to"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	target := m.revisionTarget(r)
+	changes, err := m.targetServer.DiffRevisions(target, from, to)
+	if err != nil {
+		writeRevisionError(w, err)
+		return
+	}
+
+	diff := RevisionDiff{Target: target, From: from, To: to, Updated: map[string]interface{}{}}
+	for _, update := range changes.GetUpdate() {
+		leaf, err := value.ToScalar(update.GetVal())
+		if err != nil {
+			leaf = update.GetVal().String()
+		}
+		diff.Updated[gnmi.PathToString(update.GetPath())] = leaf
+	}
+	for _, deleted := range changes.GetDelete() {
+		diff.Deleted = append(diff.Deleted, gnmi.PathToString(deleted))
+	}
+	writeJSON(w, diff)
+}
+
+func (m *DiagnosticAPI) rollbackRevision(w http.ResponseWriter, r *http.Request) {
+	number, err := revisionNumber(mux.Vars(r)["number"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	_, err = m.targetServer.RollbackRevision(r.Context(), m.revisionTarget(r), number)
+	if err != nil {
+		writeRevisionError(w, err)
+		return
+	}
+	_, err = fmt.Fprintf(w, "SUCCESS")
+	if err != nil {
+		log.Errorf("error writing response: %v", err)
+		return
+	}
+}
+
+// getPendingCommit reports the commit awaiting confirmation, or not found if there is none
+func (m *DiagnosticAPI) getPendingCommit(w http.ResponseWriter, r *http.Request) {
+	pending := m.targetServer.GetPendingCommit()
+	if pending == nil {
+		http.Error(w, "No commit is pending confirmation", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, pending)
+}
+
+func (m *DiagnosticAPI) confirmCommit(w http.ResponseWriter, r *http.Request) {
+	err := m.targetServer.ConfirmCommit()
+	if status.Code(err) == codes.FailedPrecondition {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	_, err = fmt.Fprintf(w, "SUCCESS")
+	if err != nil {
+		log.Errorf("error writing response: %v", err)
+		return
+	}
+}
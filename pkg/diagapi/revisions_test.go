@@ -0,0 +1,114 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package diagapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	models "github.com/onosproject/aether-models/models/aether-2.1.x/v2/api"
+	"github.com/onosproject/sdcore-adapter/pkg/gnmi"
+	pb "github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/gnmi/proto/gnmi_ext"
+	"github.com/openconfig/ygot/ygot"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRevisionsAPI(t *testing.T) (*DiagnosticAPI, *gnmi.Server) {
+	model := gnmi.NewModel(nil, reflect.TypeOf((*models.Device)(nil)), models.SchemaTree["Device"],
+		models.Unmarshal, map[string]map[int64]ygot.EnumDefinition{})
+	s, err := gnmi.NewServer(model, nil)
+	require.NoError(t, err)
+	t.Cleanup(s.Close)
+
+	jsonConfigRoot, err := os.ReadFile("../gnmi/testdata/sample-config-root.json")
+	require.NoError(t, err)
+	require.NoError(t, s.PutJSON("ent", jsonConfigRoot))
+	_, err = s.Set(context.Background(), &pb.SetRequest{
+		Prefix: &pb.Path{Target: "ent", Elem: []*pb.PathElem{
+			{Name: "site", Key: map[string]string{"site-id": "acme-site"}},
+			{Name: "ip-domain", Key: map[string]string{"ip-domain-id": "acme-chicago-ip"}}}},
+		Update: []*pb.Update{{
+			Path: &pb.Path{Elem: []*pb.PathElem{{Name: "dns-primary"}}},
+			Val:  &pb.TypedValue{Value: &pb.TypedValue_StringVal{StringVal: "8.8.8.8"}}}}})
+	require.NoError(t, err)
+
+	return &DiagnosticAPI{targetServer: s, defaultTarget: "ent"}, s
+}
+
+func serve(m *DiagnosticAPI, method string, url string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	m.newRouter().ServeHTTP(w, httptest.NewRequest(method, url, nil))
+	return w
+}
+
+func TestRevisionsAPI(t *testing.T) {
+	m, s := newRevisionsAPI(t)
+
+	w := serve(m, "GET", "/revisions")
+	require.Equal(t, http.StatusOK, w.Code)
+	revisions := []map[string]interface{}{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &revisions))
+	require.Len(t, revisions, 2)
+	assert.Equal(t, "put", revisions[0]["source"])
+	assert.Equal(t, "set", revisions[1]["source"])
+	assert.NotContains(t, revisions[1], "config")
+
+	w = serve(m, "GET", "/revisions/latest?target=ent")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"number": 2`)
+	assert.Contains(t, w.Body.String(), `"config"`)
+
+	w = serve(m, "GET", "/revisions/diff?from=1")
+	require.Equal(t, http.StatusOK, w.Code)
+	diff := RevisionDiff{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &diff))
+	assert.Equal(t, map[string]interface{}{
+		"site[site-id=acme-site]/ip-domain[ip-domain-id=acme-chicago-ip]/dns-primary": "8.8.8.8"}, diff.Updated)
+
+	assert.Equal(t, http.StatusBadRequest, serve(m, "GET", "/revisions/diff").Code)
+	assert.Equal(t, http.StatusNotFound, serve(m, "GET", "/revisions/9").Code)
+	assert.Equal(t, http.StatusNotFound, serve(m, "POST", "/revisions/9/rollback").Code)
+
+	w = serve(m, "POST", "/revisions/1/rollback")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, s.ListRevisions("ent"), 3)
+	changes, err := s.DiffRevisions("ent", 1, 0)
+	require.NoError(t, err)
+	assert.Empty(t, changes.Update)
+}
+
+func TestCommitAPI(t *testing.T) {
+	m, s := newRevisionsAPI(t)
+
+	assert.Equal(t, http.StatusNotFound, serve(m, "GET", "/commit/pending").Code)
+	assert.Equal(t, http.StatusNotFound, serve(m, "POST", "/commit/confirm").Code)
+
+	_, err := s.Set(context.Background(), &pb.SetRequest{
+		Prefix: &pb.Path{Target: "ent", Elem: []*pb.PathElem{
+			{Name: "site", Key: map[string]string{"site-id": "acme-site"}},
+			{Name: "ip-domain", Key: map[string]string{"ip-domain-id": "acme-chicago-ip"}}}},
+		Update: []*pb.Update{{
+			Path: &pb.Path{Elem: []*pb.PathElem{{Name: "dns-primary"}}},
+			Val:  &pb.TypedValue{Value: &pb.TypedValue_StringVal{StringVal: "8.8.8.9"}}}},
+		Extension: []*gnmi_ext.Extension{gnmi.ConfirmedCommitExtension(time.Hour)}})
+	require.NoError(t, err)
+
+	w := serve(m, "GET", "/commit/pending")
+	require.Equal(t, http.StatusOK, w.Code)
+	pending := gnmi.PendingCommit{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &pending))
+	assert.Equal(t, []string{"ent"}, pending.Targets)
+
+	assert.Equal(t, http.StatusOK, serve(m, "POST", "/commit/confirm").Code)
+	assert.Nil(t, s.GetPendingCommit())
+}
@@ -0,0 +1,75 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package election implements leader election among replicas of the adapter
+package election
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/onosproject/onos-lib-go/pkg/logging"
+)
+
+var log = logging.GetLogger("election")
+
+// Leader election modes
+const (
+	ModeNone       = "none"
+	ModeFile       = "file"
+	ModeKubernetes = "kubernetes"
+)
+
+// Timing of leader election. A leader that has not renewed its lease within LeaseDuration may
+// be replaced; a replica that is not leader retries every RetryPeriod.
+const (
+	DefaultLeaseDuration = 15 * time.Second
+	DefaultRenewDeadline = 10 * time.Second
+	DefaultRetryPeriod   = 2 * time.Second
+)
+
+// Callbacks are called as the replica gains and loses leadership. They are never called
+// concurrently, and OnDeposed is only called after OnElected.
+type Callbacks struct {
+	OnElected func()
+	OnDeposed func()
+}
+
+// Elector campaigns for leadership among the replicas
+type Elector interface {
+	// Run campaigns for leadership until ctx is done. Leadership is released, and OnDeposed
+	// called if this replica is leader, before Run returns.
+	Run(ctx context.Context, callbacks Callbacks)
+}
+
+// serializedCallbacks wraps callbacks so that they are called in order, whatever the
+// goroutines that the elector calls them from
+type serializedCallbacks struct {
+	mu        sync.Mutex
+	elected   bool
+	callbacks Callbacks
+}
+
+// elect calls OnElected, unless leadership, as represented by ctx, has already been lost
+func (c *serializedCallbacks) elect(ctx context.Context) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.elected || (ctx.Err() != nil) {
+		return
+	}
+	c.elected = true
+	c.callbacks.OnElected()
+}
+
+// depose calls OnDeposed, if OnElected was called
+func (c *serializedCallbacks) depose() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.elected {
+		return
+	}
+	c.elected = false
+	c.callbacks.OnDeposed()
+}
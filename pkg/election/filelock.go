@@ -0,0 +1,81 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package election
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"syscall"
+	"time"
+)
+
+// FileLockElector elects the replica that holds an exclusive lock on a file. The lock is
+// released when the process exits, however it exits. It only elects among replicas that share
+// a filesystem, so is intended for testing and for replicas on a single host.
+type FileLockElector struct {
+	path        string
+	identity    string
+	retryPeriod time.Duration
+}
+
+// NewFileLockElector creates an elector that locks path. The identity of the leader is written
+// to the file.
+func NewFileLockElector(path string, identity string, retryPeriod time.Duration) *FileLockElector {
+	return &FileLockElector{
+		path:        path,
+		identity:    identity,
+		retryPeriod: retryPeriod,
+	}
+}
+
+// tryLock returns the locked file, or nil if another replica holds the lock
+func (e *FileLockElector) tryLock() (*os.File, error) {
+	f, err := os.OpenFile(e.path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to open lock file: %v", err)
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		f.Close()
+		if err == syscall.EWOULDBLOCK {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("Failed to lock %s: %v", e.path, err)
+	}
+
+	// Record the leader, for the benefit of whoever is looking
+	if err := f.Truncate(0); err == nil {
+		_, _ = f.WriteAt([]byte(e.identity+"\n"), 0)
+	}
+	return f, nil
+}
+
+// Run campaigns for leadership until ctx is done
+func (e *FileLockElector) Run(ctx context.Context, callbacks Callbacks) {
+	cb := &serializedCallbacks{callbacks: callbacks}
+	log.Infof("Campaigning for leadership as %s using lock file %s", e.identity, e.path)
+	for {
+		f, err := e.tryLock()
+		if err != nil {
+			log.Warnf("Leader election: %v", err)
+		}
+		if f != nil {
+			log.Infof("Elected leader as %s", e.identity)
+			cb.elect(ctx)
+			<-ctx.Done()
+			cb.depose()
+			_ = syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+			f.Close()
+			log.Infof("Released leadership")
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(e.retryPeriod):
+		}
+	}
+}
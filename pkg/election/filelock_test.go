@@ -0,0 +1,82 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package election
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// replica runs an elector, recording whether it is leader
+type replica struct {
+	leader int32
+	done   chan struct{}
+	cancel context.CancelFunc
+}
+
+func startReplica(e Elector) *replica {
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &replica{done: make(chan struct{}), cancel: cancel}
+	go func() {
+		e.Run(ctx, Callbacks{
+			OnElected: func() { atomic.StoreInt32(&r.leader, 1) },
+			OnDeposed: func() { atomic.StoreInt32(&r.leader, 0) },
+		})
+		close(r.done)
+	}()
+	return r
+}
+
+func (r *replica) isLeader() bool {
+	return atomic.LoadInt32(&r.leader) != 0
+}
+
+func TestFileLockElector(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sdcore-adapter.lock")
+
+	first := startReplica(NewFileLockElector(path, "first", 10*time.Millisecond))
+	assert.Eventually(t, first.isLeader, 5*time.Second, 10*time.Millisecond)
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "first\n", string(data))
+
+	second := startReplica(NewFileLockElector(path, "second", 10*time.Millisecond))
+	time.Sleep(100 * time.Millisecond)
+	assert.False(t, second.isLeader())
+
+	// The standby takes over when the leader releases the lock
+	first.cancel()
+	<-first.done
+	assert.False(t, first.isLeader())
+	assert.Eventually(t, second.isLeader, 5*time.Second, 10*time.Millisecond)
+	data, err = os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "second\n", string(data))
+
+	second.cancel()
+	<-second.done
+	assert.False(t, second.isLeader())
+}
+
+func TestFileLockElectorStandbyCancelled(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sdcore-adapter.lock")
+	first := startReplica(NewFileLockElector(path, "first", 10*time.Millisecond))
+	assert.Eventually(t, first.isLeader, 5*time.Second, 10*time.Millisecond)
+
+	second := startReplica(NewFileLockElector(path, "second", 10*time.Millisecond))
+	second.cancel()
+	<-second.done
+	assert.False(t, second.isLeader())
+	assert.True(t, first.isLeader())
+
+	first.cancel()
+	<-first.done
+}
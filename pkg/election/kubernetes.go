@@ -0,0 +1,81 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package election
+
+import (
+	"context"
+	"fmt"
+
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+// KubernetesElector elects the replica that holds a Kubernetes Lease. The leader renews the
+// lease periodically; if it fails to, another replica takes the lease once it expires.
+type KubernetesElector struct {
+	lock *resourcelock.LeaseLock
+}
+
+// NewKubernetesElector creates an elector for the Lease name in namespace, using the
+// in-cluster configuration. identity must be distinct for each replica, such as the pod name.
+func NewKubernetesElector(name string, namespace string, identity string) (*KubernetesElector, error) {
+	config, err := rest.InClusterConfig()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get in-cluster configuration: %v", err)
+	}
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create kubernetes client: %v", err)
+	}
+
+	return &KubernetesElector{
+		lock: &resourcelock.LeaseLock{
+			LeaseMeta: metaV1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+			},
+			Client: clientset.CoordinationV1(),
+			LockConfig: resourcelock.ResourceLockConfig{
+				Identity: identity,
+			},
+		},
+	}, nil
+}
+
+// Run campaigns for leadership until ctx is done. If leadership is lost, the replica campaigns
+// again.
+func (e *KubernetesElector) Run(ctx context.Context, callbacks Callbacks) {
+	cb := &serializedCallbacks{callbacks: callbacks}
+	log.Infof("Campaigning for leadership as %s using lease %s/%s",
+		e.lock.Identity(), e.lock.LeaseMeta.Namespace, e.lock.LeaseMeta.Name)
+	for {
+		leaderelection.RunOrDie(ctx, leaderelection.LeaderElectionConfig{
+			Lock:            e.lock,
+			ReleaseOnCancel: true,
+			LeaseDuration:   DefaultLeaseDuration,
+			RenewDeadline:   DefaultRenewDeadline,
+			RetryPeriod:     DefaultRetryPeriod,
+			Callbacks: leaderelection.LeaderCallbacks{
+				OnStartedLeading: func(ctx context.Context) {
+					log.Infof("Elected leader as %s", e.lock.Identity())
+					cb.elect(ctx)
+				},
+				OnStoppedLeading: func() {
+					cb.depose()
+				},
+				OnNewLeader: func(identity string) {
+					log.Infof("Leader is %s", identity)
+				},
+			},
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		log.Warnf("Lost leadership; campaigning again")
+	}
+}
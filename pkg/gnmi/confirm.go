@@ -0,0 +1,266 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package gnmi implements a gnmi server to mock a device with YANG models.
+package gnmi
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"time"
+
+	pb "github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/gnmi/proto/gnmi_ext"
+	"github.com/openconfig/ygot/ygot"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+/*
+ * Confirmed Commit
+ *
+ * A Set may carry a ConfirmedCommit extension with a timeout. The Set is committed and applied
+ * by the callback as usual, but unless a Set carrying a ConfirmedCommit extension with Confirm
+ * set arrives before the timeout, or the commit is confirmed through the diagnostic API, every
+ * target it changed is restored to the tree it had before the Set. The rollback is a Set that
+ * replaces the whole tree, which deletes the list entries added since, after which the targets
+ * are resynchronized by a Forced callback. This is like commit confirmed in Junos and NETCONF,
+ * and protects against a change that cuts off the operator's own access.
+ *
+ * If the rollback fails, the commit stays pending, reporting the error, and the rollback is
+ * retried after ConfirmedCommitRetryInterval, until it succeeds or the commit is confirmed.
+ *
+ * Only one commit is pending at a time. Another confirmed Set while a commit is pending restarts
+ * the timer with the new timeout, and adds the targets it changes; the rollback still restores
+ * the trees from before the first of them. A plain Set while a commit is pending does not
+ * confirm it, and is undone by the rollback if it changes the same targets. A Set that both
+ * confirms the pending commit and carries a timeout starts a new pending commit, which restores
+ * the trees from before that Set.
+ */
+
+// ConfirmedCommitExtensionID is the ID of the registered extension carrying a ConfirmedCommit
+const ConfirmedCommitExtensionID = gnmi_ext.ExtensionID_EID_EXPERIMENTAL
+
+// ConfirmedCommitRollbackUser is the user recorded for the revisions made by the rollback of a
+// commit that was not confirmed in time
+const ConfirmedCommitRollbackUser = "confirmed-commit-timeout"
+
+// ConfirmedCommitRetryInterval is how long after a failed rollback of a commit that was not
+// confirmed in time the rollback is retried
+var ConfirmedCommitRetryInterval = 30 * time.Second
+
+// ConfirmedCommit is the JSON message of the confirmed commit extension of a Set. A Timeout,
+// such as "5m", asks for the Set to be rolled back unless it is confirmed within that time.
+// Confirm confirms the pending commit; such a Set need not carry any other changes.
+type ConfirmedCommit struct {
+	Timeout string `json:"timeout,omitempty"`
+	Confirm bool   `json:"confirm,omitempty"`
+}
+
+// ConfirmedCommitExtension returns the extension asking for a Set to be rolled back unless it
+// is confirmed within timeout
+func ConfirmedCommitExtension(timeout time.Duration) *gnmi_ext.Extension {
+	return confirmedCommitExtension(&ConfirmedCommit{Timeout: timeout.String()})
+}
+
+// ConfirmExtension returns the extension confirming the pending commit
+func ConfirmExtension() *gnmi_ext.Extension {
+	return confirmedCommitExtension(&ConfirmedCommit{Confirm: true})
+}
+
+func confirmedCommitExtension(cc *ConfirmedCommit) *gnmi_ext.Extension {
+	msg, _ := json.Marshal(cc)
+	return &gnmi_ext.Extension{Ext: &gnmi_ext.Extension_RegisteredExt{
+		RegisteredExt: &gnmi_ext.RegisteredExtension{Id: ConfirmedCommitExtensionID, Msg: msg},
+	}}
+}
+
+// parseConfirmedCommit returns the confirmed commit extension of a Set and its timeout, or nil
+// if it has none
+func parseConfirmedCommit(extensions []*gnmi_ext.Extension) (*ConfirmedCommit, time.Duration, error) {
+	for _, ext := range extensions {
+		registered := ext.GetRegisteredExt()
+		if registered == nil || registered.GetId() != ConfirmedCommitExtensionID {
+			continue
+		}
+		cc := &ConfirmedCommit{}
+		if err := json.Unmarshal(registered.GetMsg(), cc); err != nil {
+			return nil, 0, status.Errorf(codes.InvalidArgument, "invalid confirmed commit extension: %v", err)
+		}
+		if cc.Timeout == "" {
+			if !cc.Confirm {
+				return nil, 0, status.Error(codes.InvalidArgument, "confirmed commit extension has neither a timeout nor a confirm")
+			}
+			return cc, 0, nil
+		}
+		timeout, err := time.ParseDuration(cc.Timeout)
+		if err != nil || timeout <= 0 {
+			return nil, 0, status.Errorf(codes.InvalidArgument, "invalid confirmed commit timeout %s", cc.Timeout)
+		}
+		return cc, timeout, nil
+	}
+	return nil, 0, nil
+}
+
+// PendingCommit is a commit awaiting confirmation. If its rollback failed, RollbackError is
+// the error, and Deadline is when the rollback is retried.
+type PendingCommit struct {
+	Targets       []string  `json:"targets"`
+	User          string    `json:"user,omitempty"`
+	Deadline      time.Time `json:"deadline"`
+	RollbackError string    `json:"rollback-error,omitempty"`
+
+	id       uint64            // distinguishes the timers of successive confirmed Sets
+	previous map[string][]byte // the IETF JSON of each target's tree before the commit
+	timer    *time.Timer
+}
+
+// currentTrees returns the IETF JSON of the current trees of targets, for those that are not
+// already kept by pending, if there is a pending commit. The caller must hold s.config.Mu.
+func (s *Server) currentTrees(targets []string, pending *PendingCommit) (map[string][]byte, error) {
+	trees := map[string][]byte{}
+	for _, target := range targets {
+		if pending != nil {
+			if _, okay := pending.previous[target]; okay {
+				continue
+			}
+		}
+		tree, err := s.targetTree(target)
+		if err != nil {
+			return nil, err
+		}
+		trees[target] = tree
+	}
+	return trees, nil
+}
+
+// targetTree returns the IETF JSON of the current tree of target, which is empty if the target
+// has no tree. The caller must hold s.config.Mu.
+func (s *Server) targetTree(target string) ([]byte, error) {
+	config, okay := s.config.Configs[target]
+	if !okay {
+		return []byte("{}"), nil
+	}
+	jsonTree, err := ygot.ConstructIETFJSON(config, &ygot.RFC7951JSONConfig{})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "error in constructing IETF JSON tree of target %s: %v", target, err)
+	}
+	tree, err := json.Marshal(jsonTree)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "error in marshaling IETF JSON tree of target %s: %v", target, err)
+	}
+	return tree, nil
+}
+
+// startPendingCommit starts, or restarts, the timer of the pending commit, adding the trees that
+// targets had before the commit. The caller must hold s.config.Mu.
+func (s *Server) startPendingCommit(user string, previous map[string][]byte, timeout time.Duration) {
+	if s.pendingCommit == nil {
+		s.pendingCommit = &PendingCommit{previous: map[string][]byte{}}
+	} else {
+		s.pendingCommit.timer.Stop()
+	}
+	pending := s.pendingCommit
+	for target, tree := range previous {
+		pending.previous[target] = tree
+	}
+	pending.Targets = []string{}
+	for target := range pending.previous {
+		pending.Targets = append(pending.Targets, target)
+	}
+	sort.Strings(pending.Targets)
+	pending.User = user
+	pending.Deadline = time.Now().Add(timeout)
+	pending.id++
+
+	id := pending.id
+	pending.timer = time.AfterFunc(timeout, func() { s.rollbackPendingCommit(id) })
+	log.Infof("Commit to targets %v must be confirmed by %s", pending.Targets, pending.Deadline.Format(time.RFC3339))
+}
+
+// confirmPendingCommit confirms the pending commit. The caller must hold s.config.Mu.
+func (s *Server) confirmPendingCommit() error {
+	if s.pendingCommit == nil {
+		return status.Error(codes.FailedPrecondition, "no commit is pending confirmation")
+	}
+	s.pendingCommit.timer.Stop()
+	log.Infof("Commit to targets %v confirmed", s.pendingCommit.Targets)
+	s.pendingCommit = nil
+	return nil
+}
+
+// GetPendingCommit returns the commit awaiting confirmation, or nil if there is none
+func (s *Server) GetPendingCommit() *PendingCommit {
+	s.config.Mu.RLock()
+	defer s.config.Mu.RUnlock()
+	if s.pendingCommit == nil {
+		return nil
+	}
+	return &PendingCommit{
+		Targets:       append([]string{}, s.pendingCommit.Targets...),
+		User:          s.pendingCommit.User,
+		Deadline:      s.pendingCommit.Deadline,
+		RollbackError: s.pendingCommit.RollbackError,
+	}
+}
+
+// ConfirmCommit confirms the commit awaiting confirmation
+func (s *Server) ConfirmCommit() error {
+	s.config.Mu.Lock()
+	defer s.config.Mu.Unlock()
+	return s.confirmPendingCommit()
+}
+
+// rollbackPendingCommit restores the trees the targets of the pending commit had before it, if
+// it is still the pending commit with the given id, then forces them to be resynchronized. If
+// the trees cannot be restored, the commit is kept pending, and the rollback is retried.
+func (s *Server) rollbackPendingCommit(id uint64) {
+	s.config.Mu.Lock()
+	pending := s.pendingCommit
+	if pending == nil || pending.id != id {
+		s.config.Mu.Unlock()
+		return
+	}
+	s.pendingCommit = nil
+	s.config.Mu.Unlock()
+
+	log.Warnf("Commit to targets %v was not confirmed in time; rolling back", pending.Targets)
+	req := &pb.SetRequest{}
+	for _, target := range pending.Targets {
+		req.Replace = append(req.Replace, &pb.Update{
+			Path: &pb.Path{Target: target},
+			Val:  &pb.TypedValue{Value: &pb.TypedValue_JsonIetfVal{JsonIetfVal: pending.previous[target]}},
+		})
+	}
+	ctx := WithUser(context.Background(), ConfirmedCommitRollbackUser)
+	if _, err := s.Set(ctx, req); err != nil {
+		log.Errorf("Failed to roll back unconfirmed commit to targets %v: %v; retrying in %s",
+			pending.Targets, err, ConfirmedCommitRetryInterval)
+		s.retryRollback(pending, err)
+		return
+	}
+
+	target := AllTargets
+	if len(pending.Targets) == 1 {
+		target = pending.Targets[0]
+	}
+	if err := s.ExecuteCallbacks(ctx, Forced, target, nil, TargetPaths(pending.Targets)); err != nil {
+		log.Warnf("Failed to resynchronize targets %v after rollback: %v", pending.Targets, err)
+	}
+}
+
+// retryRollback makes a commit whose rollback failed pending again, reporting err, so that its
+// rollback is retried. If another commit became pending meanwhile, the failed one is merged
+// into it, and its older trees are the ones restored.
+func (s *Server) retryRollback(failed *PendingCommit, err error) {
+	s.config.Mu.Lock()
+	defer s.config.Mu.Unlock()
+
+	if s.pendingCommit == nil {
+		s.pendingCommit = failed
+	}
+	s.startPendingCommit(failed.User, failed.previous, ConfirmedCommitRetryInterval)
+	s.pendingCommit.RollbackError = err.Error()
+}
@@ -0,0 +1,206 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gnmi
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pb "github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/gnmi/proto/gnmi_ext"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// confirmedRequest is multiTargetRequest, to be rolled back unless confirmed within timeout
+func confirmedRequest(timeout time.Duration) *pb.SetRequest {
+	req := multiTargetRequest()
+	req.Extension = []*gnmi_ext.Extension{ConfirmedCommitExtension(timeout)}
+	return req
+}
+
+func TestConfirmedCommitRollback(t *testing.T) {
+	calls := []recordedCallback{}
+	s := newCallbackServer(t, &calls, func(ConfigCallbackType) error { return nil })
+	defer s.Close()
+
+	_, err := s.Set(WithUser(context.Background(), "alice"), confirmedRequest(50*time.Millisecond))
+	require.NoError(t, err)
+	assert.Equal(t, "8.8.8.8", dnsPrimary(s, "ent"))
+	pending := s.GetPendingCommit()
+	require.NotNil(t, pending)
+	assert.Equal(t, []string{"ent", "other"}, pending.Targets)
+	assert.Equal(t, "alice", pending.User)
+
+	// Once the timeout expires, both targets are restored, the site added to the new target is
+	// deleted, and they are resynchronized
+	require.Eventually(t, func() bool {
+		s.config.Mu.RLock()
+		defer s.config.Mu.RUnlock()
+		return len(calls) == 4
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, []recordedCallback{
+		{callbackType: Apply, target: AllTargets, targets: []string{"ent", "other"}},
+		{callbackType: Apply, target: AllTargets, targets: []string{"ent", "other"}},
+		{callbackType: Deleted, target: "other", targets: []string{"ent", "other"}, path: "other:site[site-id=acme-site]"},
+		{callbackType: Forced, target: AllTargets, targets: []string{"ent", "other"}}}, calls)
+	assert.Equal(t, "8.8.8.4", dnsPrimary(s, "ent"))
+	assert.Equal(t, "", dnsPrimary(s, "other"))
+	assert.Nil(t, s.GetPendingCommit())
+
+	latest, err := s.GetRevision("ent", 0)
+	require.NoError(t, err)
+	assert.Equal(t, ConfirmedCommitRollbackUser, latest.User)
+}
+
+func TestConfirmedCommitRollbackRetry(t *testing.T) {
+	defer func(interval time.Duration) { ConfirmedCommitRetryInterval = interval }(ConfirmedCommitRetryInterval)
+	ConfirmedCommitRetryInterval = 50 * time.Millisecond
+
+	// The first attempt to roll back fails to apply
+	applies := 0
+	calls := []recordedCallback{}
+	s := newCallbackServer(t, &calls, func(callbackType ConfigCallbackType) error {
+		if callbackType != Apply {
+			return nil
+		}
+		if applies++; applies == 2 {
+			return status.Error(codes.Unavailable, "southbound is unavailable")
+		}
+		return nil
+	})
+	defer s.Close()
+
+	_, err := s.Set(context.Background(), confirmedRequest(10*time.Millisecond))
+	require.NoError(t, err)
+
+	// The commit is still pending, reporting why it was not rolled back
+	require.Eventually(t, func() bool {
+		pending := s.GetPendingCommit()
+		return pending != nil && pending.RollbackError != ""
+	}, time.Second, 5*time.Millisecond)
+	pending := s.GetPendingCommit()
+	assert.Equal(t, []string{"ent", "other"}, pending.Targets)
+	assert.Contains(t, pending.RollbackError, "southbound is unavailable")
+	assert.Equal(t, "8.8.8.8", dnsPrimary(s, "ent"))
+
+	// The retry rolls it back
+	require.Eventually(t, func() bool {
+		return s.GetPendingCommit() == nil && dnsPrimary(s, "ent") == "8.8.8.4"
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(t, "", dnsPrimary(s, "other"))
+}
+
+func TestConfirmedCommitConfirm(t *testing.T) {
+	s := newSampleServer(t)
+	defer s.Close()
+
+	_, err := s.Set(context.Background(), confirmedRequest(50*time.Millisecond))
+	require.NoError(t, err)
+	_, err = s.Set(context.Background(), &pb.SetRequest{Extension: []*gnmi_ext.Extension{ConfirmExtension()}})
+	require.NoError(t, err)
+	assert.Nil(t, s.GetPendingCommit())
+
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, "8.8.8.8", dnsPrimary(s, "ent"))
+	assert.Equal(t, "8.8.8.8", dnsPrimary(s, "other"))
+
+	// There is nothing left to confirm
+	assert.Equal(t, codes.FailedPrecondition, status.Code(s.ConfirmCommit()))
+	_, err = s.Set(context.Background(), &pb.SetRequest{Extension: []*gnmi_ext.Extension{ConfirmExtension()}})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+}
+
+func TestConfirmOnlyNoCallback(t *testing.T) {
+	calls := []recordedCallback{}
+	s := newCallbackServer(t, &calls, func(ConfigCallbackType) error { return nil })
+	defer s.Close()
+
+	_, err := s.Set(context.Background(), confirmedRequest(time.Minute))
+	require.NoError(t, err)
+	require.Len(t, calls, 1)
+
+	// Confirming changes nothing, so the callback is not called
+	_, err = s.Set(context.Background(), &pb.SetRequest{Extension: []*gnmi_ext.Extension{ConfirmExtension()}})
+	require.NoError(t, err)
+	assert.Nil(t, s.GetPendingCommit())
+	assert.Len(t, calls, 1)
+
+	// Nor by a Set that is empty
+	_, err = s.Set(context.Background(), &pb.SetRequest{})
+	require.NoError(t, err)
+	assert.Len(t, calls, 1)
+}
+
+func TestConfirmedCommitRestart(t *testing.T) {
+	s := newSampleServer(t)
+	defer s.Close()
+
+	// A second confirmed Set restarts the timer, and the rollback restores the first tree
+	_, err := s.Set(context.Background(), confirmedRequest(50*time.Millisecond))
+	require.NoError(t, err)
+	req := confirmedRequest(time.Hour)
+	req.Update[0].Val = &pb.TypedValue{Value: &pb.TypedValue_StringVal{StringVal: "8.8.8.9"}}
+	_, err = s.Set(context.Background(), req)
+	require.NoError(t, err)
+
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, "8.8.8.9", dnsPrimary(s, "ent"))
+	pending := s.GetPendingCommit()
+	require.NotNil(t, pending)
+	assert.True(t, pending.Deadline.After(time.Now().Add(time.Minute)))
+
+	s.config.Mu.Lock()
+	id := s.pendingCommit.id
+	s.config.Mu.Unlock()
+	s.rollbackPendingCommit(id)
+	assert.Equal(t, "8.8.8.4", dnsPrimary(s, "ent"))
+	assert.Equal(t, "", dnsPrimary(s, "other"))
+}
+
+func TestConfirmedCommitConfirmAndRestart(t *testing.T) {
+	s := newSampleServer(t)
+	defer s.Close()
+
+	// A Set that confirms the pending commit and carries a timeout restores the trees from
+	// before it, not those from before the commit it confirmed
+	_, err := s.Set(context.Background(), confirmedRequest(time.Hour))
+	require.NoError(t, err)
+	req := multiTargetRequest()
+	req.Update[0].Val = &pb.TypedValue{Value: &pb.TypedValue_StringVal{StringVal: "8.8.8.9"}}
+	req.Extension = []*gnmi_ext.Extension{confirmedCommitExtension(&ConfirmedCommit{Timeout: time.Hour.String(), Confirm: true})}
+	_, err = s.Set(context.Background(), req)
+	require.NoError(t, err)
+	pending := s.GetPendingCommit()
+	require.NotNil(t, pending)
+	assert.Equal(t, []string{"ent", "other"}, pending.Targets)
+
+	s.config.Mu.Lock()
+	id := s.pendingCommit.id
+	s.config.Mu.Unlock()
+	s.rollbackPendingCommit(id)
+	assert.Equal(t, "8.8.8.8", dnsPrimary(s, "ent"))
+	assert.Equal(t, "8.8.8.8", dnsPrimary(s, "other"))
+}
+
+func TestParseConfirmedCommit(t *testing.T) {
+	cc, timeout, err := parseConfirmedCommit([]*gnmi_ext.Extension{ConfirmedCommitExtension(time.Minute)})
+	require.NoError(t, err)
+	assert.Equal(t, time.Minute, timeout)
+	assert.False(t, cc.Confirm)
+
+	cc, _, err = parseConfirmedCommit(nil)
+	assert.NoError(t, err)
+	assert.Nil(t, cc)
+
+	for _, msg := range []string{`{}`, `{"timeout": "soon"}`, `{"timeout": "-1s"}`, `not json`} {
+		_, _, err = parseConfirmedCommit([]*gnmi_ext.Extension{{Ext: &gnmi_ext.Extension_RegisteredExt{
+			RegisteredExt: &gnmi_ext.RegisteredExtension{Id: ConfirmedCommitExtensionID, Msg: []byte(msg)}}}})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err), msg)
+	}
+}
@@ -6,7 +6,9 @@
 package gnmi
 
 import (
+	"context"
 	"sync"
+	"time"
 
 	"github.com/eapache/channels"
 	"github.com/onosproject/onos-lib-go/pkg/logging"
@@ -49,10 +51,15 @@ func (c ConfigCallbackType) String() string {
 type ConfigForest struct {
 	Configs map[string]ygot.ValidatedGoStruct
 	Mu      sync.RWMutex // mu is the RW lock to protect the access to config
+
+	changed func(targets []string) // called by Changed, if the forest belongs to a server
 }
 
 // ConfigCallback is the signature of the function to apply a validated config to the physical device.
-type ConfigCallback func(*ConfigForest, ConfigCallbackType, string, *pb.Path) error
+// The context carries the trace of the request that caused the callback. The last argument is the
+// scope of the callback: the paths of the subtrees it covers, such as one path per target changed
+// by a Set, or nil if it covers every tree.
+type ConfigCallback func(context.Context, *ConfigForest, ConfigCallbackType, string, *pb.Path, []*pb.Path) error
 
 var (
 	pbRootPath         = &pb.Path{}
@@ -81,11 +88,19 @@ var (
 //			// Do something ...
 //	}
 type Server struct {
-	model        *Model
-	callback     ConfigCallback
-	config       *ConfigForest
-	ConfigUpdate *channels.RingChannel
-	subscribed   map[string][]*streamClient
+	model         *Model
+	callback      ConfigCallback
+	config        *ConfigForest
+	ConfigUpdate  *channels.InfiniteChannel // changes to be delivered to ON_CHANGE subscribers
+	subscriptions *subscriptionManager
+	journal       *Journal                          // if not nil, every SetRequest is recorded to it
+	published     map[string]ygot.ValidatedGoStruct // trees last published to ON_CHANGE subscribers
+	publishSeq    uint64                            // sequence number of the last change published
+	inPlace       map[string]bool                   // targets changed in place, awaiting publishing
+	inPlaceTimer  *time.Timer                       // publishes inPlace, if any are awaiting publishing
+	leafModes     map[string]pb.SubscriptionMode    // overrides of the modes of leaves of TARGET_DEFINED subscriptions
+	revisions     *revisionHistory                  // the last revisions of each target
+	pendingCommit *PendingCommit                    // the commit awaiting confirmation, if any
 }
 
 var (
@@ -95,8 +110,17 @@ var (
 type streamClient struct {
 	sr             *pb.SubscribeRequest
 	stream         pb.GNMI_SubscribeServer
-	UpdateChan     chan *pb.Update
 	sampleInterval uint64
+	onChangeSince  uint64                     // first change not covered by the initial values; accessed atomically
+	queue          chan *pb.SubscribeResponse // responses waiting to be sent
+	overflow       OverflowPolicy
+	done           chan struct{} // closed when the client is disconnected
+
+	mu            sync.Mutex // protects the fields below
+	onChange      []*pb.Path // full paths, with target, of the ON_CHANGE subscriptions
+	targetDefined []*pb.Path // full paths, with target, of the TARGET_DEFINED subscriptions
+	resync        bool       // responses were dropped, so the current values must be resent
+	err           error      // the error the client was disconnected with
 }
 
 var log = logging.GetLogger("gnmi")
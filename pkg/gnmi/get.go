@@ -100,34 +100,77 @@ func (s *Server) Get(req *pb.GetRequest) (*pb.GetResponse, error) {
 			return nil, status.Error(codes.Unimplemented, "deprecated path element type is unsupported")
 		}
 
-		nodes, err := ytypes.GetNode(s.model.schemaTreeRoot, config, fullPath)
-		if len(nodes) == 0 || err != nil || util.IsValueNil(nodes[0].Data) {
+		ts := time.Now().UnixNano()
+
+		if hasWildcards(fullPath) {
+			notification, err := s.getWildcardNotification(config, prefix, fullPath, req.GetEncoding(), dataType, req.GetUseModels())
+			if err != nil {
+				gnmiRequestsFailedTotal.WithLabelValues("GET").Inc()
+				return nil, err
+			}
+			notification.Timestamp = ts
+			notifications[i] = notification
+			continue
+		}
+
+		update, err := s.getNodeUpdate(config, path, fullPath, req.GetEncoding(), dataType, req.GetUseModels())
+		if err != nil {
 			gnmiRequestsFailedTotal.WithLabelValues("GET").Inc()
-			log.Warnf("Get: Returning PathNotFound %s: %v", PathToString(fullPath), err)
-			return nil, status.Errorf(codes.NotFound, "path %v not found: %v", PathToString(fullPath), err)
+			return nil, err
 		}
-		node := nodes[0].Data
+		notifications[i] = &pb.Notification{
+			Timestamp: ts,
+			Prefix:    prefix,
+			Update:    []*pb.Update{update},
+		}
+	}
+	resp := &pb.GetResponse{Notification: notifications}
 
-		ts := time.Now().UnixNano()
+	gnmiRequestDuration.WithLabelValues("GET").Observe(time.Since(tStart).Seconds())
+
+	return resp, nil
+}
 
-		nodeStruct, ok := node.(ygot.GoStruct)
-		// Return leaf node.
-		if !ok {
-			var val *pb.TypedValue
+// getNodeUpdate returns an update carrying the value of the node of config at fullPath. The
+// update's path is path.
+func (s *Server) getNodeUpdate(config ygot.ValidatedGoStruct, path *pb.Path, fullPath *pb.Path, encoding pb.Encoding, dataType pb.GetRequest_DataType, useModels []*pb.ModelData) (*pb.Update, error) {
+	nodes, err := ytypes.GetNode(s.model.schemaTreeRoot, config, fullPath)
+	if len(nodes) == 0 || err != nil || util.IsValueNil(nodes[0].Data) {
+		log.Warnf("Get: Returning PathNotFound %s: %v", PathToString(fullPath), err)
+		return nil, status.Errorf(codes.NotFound, "path %v not found: %v", PathToString(fullPath), err)
+	}
+	node := nodes[0].Data
+
+	nodeStruct, ok := node.(ygot.GoStruct)
+	// Return leaf node.
+	if !ok {
+		var val *pb.TypedValue
+		switch kind := reflect.ValueOf(node).Kind(); kind {
+		case reflect.Ptr, reflect.Interface:
+			var err error
+			val, err = value.FromScalar(reflect.ValueOf(node).Elem().Interface())
+			if err != nil {
+				msg := fmt.Sprintf("leaf node %v does not contain a scalar type value: %v", path, err)
+				log.Error(msg)
+				return nil, status.Error(codes.Internal, msg)
+			}
+		case reflect.Int64:
+			enumMap, ok := s.model.enumData[reflect.TypeOf(node).Name()]
+			if !ok {
+				return nil, status.Error(codes.Internal, "not a GoStruct enumeration type")
+			}
+			val = &pb.TypedValue{
+				Value: &pb.TypedValue_StringVal{
+					StringVal: enumMap[reflect.ValueOf(node).Int()].Name,
+				},
+			}
+		case reflect.Slice:
+			var err error
 			switch kind := reflect.ValueOf(node).Kind(); kind {
-			case reflect.Ptr, reflect.Interface:
-				var err error
-				val, err = value.FromScalar(reflect.ValueOf(node).Elem().Interface())
-				if err != nil {
-					msg := fmt.Sprintf("leaf node %v does not contain a scalar type value: %v", path, err)
-					log.Error(msg)
-					gnmiRequestsFailedTotal.WithLabelValues("GET").Inc()
-					return nil, status.Error(codes.Internal, msg)
-				}
 			case reflect.Int64:
+				//fmt.Println(reflect.TypeOf(node[0].Data).Elem())
 				enumMap, ok := s.model.enumData[reflect.TypeOf(node).Name()]
 				if !ok {
-					gnmiRequestsFailedTotal.WithLabelValues("GET").Inc()
 					return nil, status.Error(codes.Internal, "not a GoStruct enumeration type")
 				}
 				val = &pb.TypedValue{
@@ -135,89 +178,79 @@ func (s *Server) Get(req *pb.GetRequest) (*pb.GetResponse, error) {
 						StringVal: enumMap[reflect.ValueOf(node).Int()].Name,
 					},
 				}
-			case reflect.Slice:
-				var err error
-				switch kind := reflect.ValueOf(node).Kind(); kind {
-				case reflect.Int64:
-					//fmt.Println(reflect.TypeOf(node[0].Data).Elem())
-					enumMap, ok := s.model.enumData[reflect.TypeOf(node).Name()]
-					if !ok {
-						gnmiRequestsFailedTotal.WithLabelValues("GET").Inc()
-						return nil, status.Error(codes.Internal, "not a GoStruct enumeration type")
-					}
-					val = &pb.TypedValue{
-						Value: &pb.TypedValue_StringVal{
-							StringVal: enumMap[reflect.ValueOf(node).Int()].Name,
-						},
-					}
-				default:
-					val, err = value.FromScalar(reflect.ValueOf(node).Elem().Interface())
-					if err != nil {
-						msg := fmt.Sprintf("leaf node %v does not contain a scalar type value: %v", path, err)
-						log.Error(msg)
-						gnmiRequestsFailedTotal.WithLabelValues("GET").Inc()
-						return nil, status.Error(codes.Internal, msg)
-					}
-				}
 			default:
-				gnmiRequestsFailedTotal.WithLabelValues("GET").Inc()
-				return nil, status.Errorf(codes.Internal, "unexpected kind of leaf node type: %v %v", node, kind)
-			}
-
-			update := &pb.Update{Path: path, Val: val}
-			notifications[i] = &pb.Notification{
-				Timestamp: ts,
-				Prefix:    prefix,
-				Update:    []*pb.Update{update},
+				val, err = value.FromScalar(reflect.ValueOf(node).Elem().Interface())
+				if err != nil {
+					msg := fmt.Sprintf("leaf node %v does not contain a scalar type value: %v", path, err)
+					log.Error(msg)
+					return nil, status.Error(codes.Internal, msg)
+				}
 			}
-			continue
+		default:
+			return nil, status.Errorf(codes.Internal, "unexpected kind of leaf node type: %v %v", node, kind)
 		}
-		dataTypeString := strings.ToLower(dataType.String())
 
-		if req.GetUseModels() != nil {
-			gnmiRequestsFailedTotal.WithLabelValues("GET").Inc()
-			return nil, status.Errorf(codes.Unimplemented, "filtering Get using use_models is unsupported, got: %v", req.GetUseModels())
-		}
+		return &pb.Update{Path: path, Val: val}, nil
+	}
+	dataTypeString := strings.ToLower(dataType.String())
 
-		jsonType := "IETF"
+	if useModels != nil {
+		return nil, status.Errorf(codes.Unimplemented, "filtering Get using use_models is unsupported, got: %v", useModels)
+	}
 
-		if req.GetEncoding() == pb.Encoding_JSON {
-			jsonType = "Internal"
-		}
+	jsonType := "IETF"
 
-		var jsonTree map[string]interface{}
-		if reflect.ValueOf(nodeStruct).Pointer() == 0 {
-			gnmiRequestsFailedTotal.WithLabelValues("GET").Inc()
-			return nil, status.Error(codes.NotFound, "value is 0")
+	if encoding == pb.Encoding_JSON {
+		jsonType = "Internal"
+	}
 
-		}
-		jsonTree, err = jsonEncoder(jsonType, nodeStruct)
-		jsonTree = pruneConfigData(jsonTree, strings.ToLower(dataTypeString), fullPath).(map[string]interface{})
-		if err != nil {
-			msg := fmt.Sprintf("error in constructing %s JSON tree from requested node: %v", jsonType, err)
-			log.Error(msg)
-			gnmiRequestsFailedTotal.WithLabelValues("GET").Inc()
-			return nil, status.Error(codes.Internal, msg)
-		}
+	var jsonTree map[string]interface{}
+	if reflect.ValueOf(nodeStruct).Pointer() == 0 {
+		return nil, status.Error(codes.NotFound, "value is 0")
 
-		jsonDump, err := json.Marshal(jsonTree)
-		if err != nil {
-			msg := fmt.Sprintf("error in marshaling %s JSON tree to bytes: %v", jsonType, err)
-			log.Error(msg)
-			gnmiRequestsFailedTotal.WithLabelValues("GET").Inc()
-			return nil, status.Error(codes.Internal, msg)
-		}
+	}
+	jsonTree, err = jsonEncoder(jsonType, nodeStruct)
+	jsonTree = pruneConfigData(jsonTree, strings.ToLower(dataTypeString), fullPath).(map[string]interface{})
+	if err != nil {
+		msg := fmt.Sprintf("error in constructing %s JSON tree from requested node: %v", jsonType, err)
+		log.Error(msg)
+		return nil, status.Error(codes.Internal, msg)
+	}
 
-		update := buildUpdate(jsonDump, path, jsonType)
-		notifications[i] = &pb.Notification{
-			Timestamp: ts,
-			Prefix:    prefix,
-			Update:    []*pb.Update{update},
-		}
+	jsonDump, err := json.Marshal(jsonTree)
+	if err != nil {
+		msg := fmt.Sprintf("error in marshaling %s JSON tree to bytes: %v", jsonType, err)
+		log.Error(msg)
+		return nil, status.Error(codes.Internal, msg)
 	}
-	resp := &pb.GetResponse{Notification: notifications}
 
-	gnmiRequestDuration.WithLabelValues("GET").Observe(time.Since(tStart).Seconds())
+	return buildUpdate(jsonDump, path, jsonType), nil
+}
 
-	return resp, nil
+// getWildcardNotification returns a notification with an update for each node of config that is
+// matched by the wildcard fullPath. The updates' paths are relative to prefix, unless prefix
+// itself contains wildcards, in which case they are complete.
+func (s *Server) getWildcardNotification(config ygot.ValidatedGoStruct, prefix *pb.Path, fullPath *pb.Path, encoding pb.Encoding, dataType pb.GetRequest_DataType, useModels []*pb.ModelData) (*pb.Notification, error) {
+	concretePaths, err := expandPath(config, fullPath)
+	if err != nil {
+		msg := fmt.Sprintf("error in expanding wildcard path %s: %v", PathToString(fullPath), err)
+		log.Error(msg)
+		return nil, status.Error(codes.Internal, msg)
+	}
+
+	notification := &pb.Notification{Prefix: prefix}
+	prefixLen := len(prefix.GetElem())
+	if hasWildcards(prefix) {
+		notification.Prefix = &pb.Path{Origin: prefix.Origin, Target: prefix.Target}
+		prefixLen = 0
+	}
+	for _, concretePath := range concretePaths {
+		path := &pb.Path{Elem: concretePath.Elem[prefixLen:]}
+		update, err := s.getNodeUpdate(config, path, concretePath, encoding, dataType, useModels)
+		if err != nil {
+			return nil, err
+		}
+		notification.Update = append(notification.Update, update)
+	}
+	return notification, nil
 }
@@ -0,0 +1,188 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gnmi
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	pb "github.com/openconfig/gnmi/proto/gnmi"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+/*
+ * Set Journal
+ *
+ * When a journal is attached to the server (see SetJournal), every SetRequest is appended to
+ * it, one JSON object per line, along with the time, the targets it names, and the error if it
+ * failed. Requests are recorded in the order that they are applied to the configuration, so
+ * that replaying the journal reproduces the configuration, and the southbound pushes, that the
+ * adapter saw. The journal holds configuration such as IMSIs, so is only readable by its owner.
+ *
+ * Changes that are not made by a Set, by PutJSON and MergeJSON for the diagnostic API's cache
+ * and pull endpoints, and the pull from aether-config at startup, are recorded as a Set that
+ * replaces the whole tree of the target with the tree that resulted.
+ */
+
+// JournalEntry is a SetRequest recorded in a journal
+type JournalEntry struct {
+	Time    time.Time
+	Targets []string
+	Request *pb.SetRequest
+	Error   string
+}
+
+// journalRecord is the JSON encoding of a JournalEntry
+type journalRecord struct {
+	Time    time.Time       `json:"time"`
+	Targets []string        `json:"targets"`
+	Request json.RawMessage `json:"request"`
+	Error   string          `json:"error,omitempty"`
+}
+
+// MarshalJSON encodes the entry, with the request in the protobuf JSON encoding
+func (e *JournalEntry) MarshalJSON() ([]byte, error) {
+	request, err := protojson.Marshal(e.Request)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(&journalRecord{
+		Time:    e.Time,
+		Targets: e.Targets,
+		Request: request,
+		Error:   e.Error,
+	})
+}
+
+// UnmarshalJSON decodes an entry encoded by MarshalJSON
+func (e *JournalEntry) UnmarshalJSON(data []byte) error {
+	record := journalRecord{}
+	if err := json.Unmarshal(data, &record); err != nil {
+		return err
+	}
+	request := &pb.SetRequest{}
+	if err := protojson.Unmarshal(record.Request, request); err != nil {
+		return err
+	}
+	*e = JournalEntry{
+		Time:    record.Time,
+		Targets: record.Targets,
+		Request: request,
+		Error:   record.Error,
+	}
+	return nil
+}
+
+// setRequestTargets returns the targets of the paths of req
+func setRequestTargets(req *pb.SetRequest) []string {
+	targets := map[string]bool{}
+	add := func(path *pb.Path) {
+		if target := requestTarget(req.GetPrefix(), path); target != "" {
+			targets[target] = true
+		}
+	}
+	for _, path := range req.GetDelete() {
+		add(path)
+	}
+	for _, upd := range req.GetReplace() {
+		add(upd.GetPath())
+	}
+	for _, upd := range req.GetUpdate() {
+		add(upd.GetPath())
+	}
+
+	result := []string{}
+	for target := range targets {
+		result = append(result, target)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// Journal records SetRequests to a file
+type Journal struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// OpenJournal opens a journal, appending to it if it already exists
+func OpenJournal(fn string) (*Journal, error) {
+	f, err := os.OpenFile(fn, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to open journal: %v", err)
+	}
+	return &Journal{file: f}, nil
+}
+
+// Record appends a SetRequest, and the error it failed with if any, to the journal
+func (j *Journal) Record(req *pb.SetRequest, setErr error) error {
+	entry := &JournalEntry{
+		Time:    time.Now(),
+		Targets: setRequestTargets(req),
+		Request: req,
+	}
+	if setErr != nil {
+		entry.Error = setErr.Error()
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("Failed to marshal journal entry: %v", err)
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.file == nil {
+		return fmt.Errorf("Journal is closed")
+	}
+	if _, err := j.file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("Failed to write journal entry: %v", err)
+	}
+	return nil
+}
+
+// Close closes the journal
+func (j *Journal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.file == nil {
+		return nil
+	}
+	err := j.file.Close()
+	j.file = nil
+	return err
+}
+
+// ReadJournal reads the entries of a journal, in the order they were recorded
+func ReadJournal(fn string) ([]*JournalEntry, error) {
+	f, err := os.Open(fn)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to open journal: %v", err)
+	}
+	defer f.Close()
+
+	entries := []*JournalEntry{}
+	scanner := bufio.NewScanner(f)
+	// a request that replaces a whole enterprise may be large
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		entry := &JournalEntry{}
+		if err := json.Unmarshal(scanner.Bytes(), entry); err != nil {
+			return nil, fmt.Errorf("Failed to parse journal line %d: %v", line, err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("Failed to read journal: %v", err)
+	}
+	return entries, nil
+}
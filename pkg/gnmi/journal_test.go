@@ -0,0 +1,119 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gnmi
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	pb "github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestSetRequestTargets(t *testing.T) {
+	req := &pb.SetRequest{
+		Prefix: &pb.Path{Target: "acme"},
+		Delete: []*pb.Path{{Target: "starbucks", Elem: []*pb.PathElem{{Name: "site"}}}},
+		Update: []*pb.Update{{Path: &pb.Path{Elem: []*pb.PathElem{{Name: "site"}}}}},
+	}
+	assert.Equal(t, []string{"acme", "starbucks"}, setRequestTargets(req))
+	assert.Equal(t, []string{}, setRequestTargets(&pb.SetRequest{}))
+}
+
+func TestJournal(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "journal.jsonl")
+	journal, err := OpenJournal(fn)
+	require.NoError(t, err)
+
+	s, err := NewServer(model, nil)
+	require.NoError(t, err)
+	s.SetJournal(journal)
+
+	update := &pb.SetRequest{
+		Prefix: &pb.Path{Target: "acme"},
+		Update: []*pb.Update{{
+			Path: &pb.Path{Elem: []*pb.PathElem{
+				{Name: "site", Key: map[string]string{"site-id": "acme-site"}},
+				{Name: "description"}}},
+			Val: &pb.TypedValue{Value: &pb.TypedValue_StringVal{StringVal: "ACME Site"}},
+		}},
+	}
+	_, err = s.Set(context.Background(), update)
+	assert.NoError(t, err)
+
+	noTarget := &pb.SetRequest{
+		Update: []*pb.Update{{
+			Path: &pb.Path{Elem: []*pb.PathElem{{Name: "site"}}},
+			Val:  &pb.TypedValue{Value: &pb.TypedValue_StringVal{StringVal: "oops"}},
+		}},
+	}
+	_, err = s.Set(context.Background(), noTarget)
+	assert.Error(t, err)
+
+	s.Close()
+	assert.Error(t, journal.Record(update, nil))
+
+	info, err := os.Stat(fn)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	entries, err := ReadJournal(fn)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, []string{"acme"}, entries[0].Targets)
+	assert.True(t, proto.Equal(update, entries[0].Request))
+	assert.Empty(t, entries[0].Error)
+	assert.False(t, entries[0].Time.IsZero())
+	assert.Equal(t, []string{}, entries[1].Targets)
+	assert.True(t, proto.Equal(noTarget, entries[1].Request))
+	assert.Contains(t, entries[1].Error, "set request with empty target is not allowed")
+
+	require.NoError(t, os.WriteFile(fn, []byte("{\"time\": \"yesterday\"}\n"), 0600))
+	_, err = ReadJournal(fn)
+	assert.ErrorContains(t, err, "Failed to parse journal line 1")
+}
+
+func TestJournalPutAndMerge(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "journal.jsonl")
+	journal, err := OpenJournal(fn)
+	require.NoError(t, err)
+
+	s, err := NewServer(model, nil)
+	require.NoError(t, err)
+	s.SetJournal(journal)
+
+	// Changes that are not made by a Set are recorded as a Set that replaces the whole tree
+	require.NoError(t, s.PutJSON("acme", []byte(`{"site": [{"site-id": "acme-site", "description": "ACME Site"}]}`)))
+	_, err = s.MergeJSON("acme", &pb.Path{Elem: []*pb.PathElem{{Name: "site", Key: map[string]string{"site-id": "other-site"}}}},
+		[]byte(`{"site-id": "other-site", "description": "Other Site"}`), false)
+	require.NoError(t, err)
+	_, err = s.MergeJSON("acme", nil, []byte(`{}`), true)
+	require.NoError(t, err)
+	want, err := s.targetTree("acme")
+	require.NoError(t, err)
+	assert.Contains(t, string(want), "Other Site")
+	s.Close()
+
+	entries, err := ReadJournal(fn)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, []string{"acme"}, entries[1].Targets)
+
+	// Replaying the entries reproduces the tree
+	replayed, err := NewServer(model, nil)
+	require.NoError(t, err)
+	defer replayed.Close()
+	for _, entry := range entries {
+		_, err = replayed.Set(context.Background(), entry.Request)
+		require.NoError(t, err)
+	}
+	got, err := replayed.targetTree("acme")
+	require.NoError(t, err)
+	assert.JSONEq(t, string(want), string(got))
+}
@@ -36,4 +36,19 @@ var (
 	},
 		[]string{"method"},
 	)
+
+	gnmiSubscribers = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "api_gnmi_subscribers",
+		Help: "The number of connected GNMI subscribers",
+	})
+
+	gnmiSubscribeDroppedTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "api_gnmi_subscribe_dropped_total",
+		Help: "The total number of subscribe responses dropped because a subscriber fell behind",
+	})
+
+	gnmiSubscribersDisconnectedTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "api_gnmi_subscribers_disconnected_total",
+		Help: "The total number of GNMI subscribers disconnected because they fell behind",
+	})
 )
@@ -0,0 +1,273 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package gnmi implements a gnmi server to mock a device with YANG models.
+package gnmi
+
+import (
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/eapache/channels"
+	pb "github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/ygot/ygot"
+)
+
+/*
+ * ON_CHANGE subscriptions are driven by a diff of each target's tree against the tree that was
+ * last published to subscribers. The diff is taken after every commit, and after writes of
+ * operational state, which the synchronizer reports by calling ConfigForest.Changed. As such
+ * writes arrive in bursts, one per Kafka message, the targets they change are collected for
+ * InPlaceChangeInterval and then diffed once. The leaf-level updates and deletes are queued on
+ * ConfigUpdate, and a single dispatcher delivers them to every subscriber whose path is a
+ * prefix of the changed leaf.
+ *
+ * Each queued change is numbered, so that a new subscriber is only sent the changes that are
+ * not already covered by the initial values it was sent.
+ */
+
+// InPlaceChangeInterval is how long changes made in place are collected before they are
+// published together
+var InPlaceChangeInterval = 100 * time.Millisecond
+
+// configEvent is a change queued for ON_CHANGE subscribers
+type configEvent struct {
+	seq          uint64
+	notification *pb.Notification
+}
+
+// Changed reports that the trees of targets were modified in place, such as by writing
+// operational state, so that the changes are delivered to ON_CHANGE subscribers. The caller
+// must hold Mu.
+func (c *ConfigForest) Changed(targets ...string) {
+	if c.changed != nil {
+		c.changed(targets)
+	}
+}
+
+// changedInPlace records that the trees of targets were modified in place, to be published
+// after InPlaceChangeInterval along with any other such changes. The caller must hold
+// s.config.Mu.
+func (s *Server) changedInPlace(targets []string) {
+	if s.ConfigUpdate == nil {
+		// the server is closed
+		return
+	}
+	if s.inPlace == nil {
+		s.inPlace = map[string]bool{}
+	}
+	for _, target := range targets {
+		s.inPlace[target] = true
+	}
+	if s.inPlaceTimer == nil {
+		s.inPlaceTimer = time.AfterFunc(InPlaceChangeInterval, func() {
+			s.config.Mu.Lock()
+			defer s.config.Mu.Unlock()
+			s.publishInPlace()
+		})
+	}
+}
+
+// publishInPlace publishes the changes made in place that are awaiting publishing. The caller
+// must hold s.config.Mu.
+func (s *Server) publishInPlace() {
+	if s.inPlaceTimer == nil {
+		return
+	}
+	s.inPlaceTimer.Stop()
+	s.inPlaceTimer = nil
+	targets := []string{}
+	for target := range s.inPlace {
+		targets = append(targets, target)
+	}
+	s.inPlace = nil
+	sort.Strings(targets)
+	s.publishChanges(targets)
+}
+
+// publishChanges queues the changes to the trees of targets since they were last published.
+// The caller must hold s.config.Mu.
+func (s *Server) publishChanges(targets []string) {
+	if s.ConfigUpdate == nil {
+		// the server is closed
+		return
+	}
+	for _, target := range targets {
+		notification, err := s.diffPublished(target)
+		if err != nil {
+			log.Warnf("Failed to compute changes to target %s: %v", target, err)
+			continue
+		}
+		if len(notification.Update) > 0 || len(notification.Delete) > 0 {
+			s.publishSeq++
+			s.ConfigUpdate.In() <- &configEvent{seq: s.publishSeq, notification: notification}
+		}
+	}
+}
+
+// diffPublished returns the changes to the tree of target since it was last published, and
+// records the current tree as published.
+func (s *Server) diffPublished(target string) (*pb.Notification, error) {
+	empty, err := s.model.NewConfigStruct(nil)
+	if err != nil {
+		return nil, err
+	}
+	oldConfig, okay := s.published[target]
+	if !okay {
+		oldConfig = empty
+	}
+	newConfig, exists := s.config.Configs[target]
+	if !exists {
+		newConfig = empty
+	}
+
+	notification, err := ygot.Diff(oldConfig, newConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	if exists {
+		// the tree may later be modified in place, so publish a copy
+		published, err := ygot.DeepCopy(newConfig)
+		if err != nil {
+			return nil, err
+		}
+		s.published[target] = published.(ygot.ValidatedGoStruct)
+	} else {
+		delete(s.published, target)
+	}
+
+	notification.Prefix = &pb.Path{Target: target}
+	notification.Timestamp = time.Now().UnixNano()
+	return notification, nil
+}
+
+// dispatchConfigEvents delivers queued changes to ON_CHANGE subscribers, until updates, the
+// channel of the server's ConfigUpdate, is closed.
+func (s *Server) dispatchConfigEvents(updates *channels.InfiniteChannel) {
+	for item := range updates.Out() {
+		event := item.(*configEvent)
+		for _, c := range s.subscriptions.onChangeClients() {
+			since := atomic.LoadUint64(&c.onChangeSince)
+			if since == 0 || event.seq < since {
+				// the client has not been sent its initial values, which will include the change
+				continue
+			}
+			if response := filterNotification(event.notification, s.onChangeMatcher(c)); response != nil {
+				s.sendResponse(response, c)
+			}
+		}
+	}
+}
+
+// processSubStreamOnChange sends the current value of every leaf under the ON_CHANGE
+// subscriptions of c, followed by a sync response. Later changes are sent by
+// dispatchConfigEvents.
+func (s *Server) processSubStreamOnChange(c *streamClient) {
+	responses := []*pb.SubscribeResponse{}
+
+	// Changes made in place are published first, so that they are not sent again after the
+	// initial values that include them
+	s.config.Mu.Lock()
+	s.publishInPlace()
+	s.config.Mu.Unlock()
+
+	s.config.Mu.RLock()
+	match := initialMatcher(c)
+	for _, target := range sortedTargets(s.config) {
+		notification, err := leafNotification(target, s.config.Configs[target])
+		if err != nil {
+			log.Warnf("Failed to collect initial values of target %s: %v", target, err)
+			continue
+		}
+		if response := filterNotification(notification, match); response != nil {
+			responses = append(responses, response)
+		}
+	}
+	atomic.StoreUint64(&c.onChangeSince, s.publishSeq+1)
+	s.config.Mu.RUnlock()
+
+	for _, response := range responses {
+		s.sendResponse(response, c)
+	}
+	s.sendResponse(buildSyncResponse(), c)
+}
+
+// sortedTargets returns the targets of config, in order. The caller must hold config.Mu.
+func sortedTargets(config *ConfigForest) []string {
+	targets := []string{}
+	for target := range config.Configs {
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+	return targets
+}
+
+// leafNotification returns a notification that updates every leaf of config, the tree of target
+// or a subtree of it
+func leafNotification(target string, config ygot.GoStruct) (*pb.Notification, error) {
+	timestamp := time.Now().UnixNano()
+	notifications, err := ygot.TogNMINotifications(config, timestamp, ygot.GNMINotificationsConfig{UsePathElem: true})
+	if err != nil {
+		return nil, err
+	}
+	leaves := &pb.Notification{
+		Timestamp: timestamp,
+		Prefix:    &pb.Path{Target: target},
+	}
+	for _, notification := range notifications {
+		for _, update := range notification.Update {
+			elems := append(append([]*pb.PathElem{}, notification.GetPrefix().GetElem()...), update.GetPath().GetElem()...)
+			leaves.Update = append(leaves.Update, &pb.Update{Path: &pb.Path{Elem: elems}, Val: update.Val})
+		}
+	}
+	return leaves, nil
+}
+
+// filterNotification returns a subscribe response carrying the updates and deletes of
+// notification whose paths match, or nil if there are none.
+func filterNotification(notification *pb.Notification, match func(target string, path *pb.Path) bool) *pb.SubscribeResponse {
+	target := notification.GetPrefix().GetTarget()
+	filtered := &pb.Notification{
+		Timestamp: notification.Timestamp,
+		Prefix:    notification.Prefix,
+	}
+	for _, update := range notification.Update {
+		if match(target, update.GetPath()) {
+			filtered.Update = append(filtered.Update, update)
+		}
+	}
+	for _, deleted := range notification.Delete {
+		if match(target, deleted) {
+			filtered.Delete = append(filtered.Delete, deleted)
+		}
+	}
+	if len(filtered.Update) == 0 && len(filtered.Delete) == 0 {
+		return nil
+	}
+	return &pb.SubscribeResponse{
+		Response: &pb.SubscribeResponse_Update{Update: filtered},
+	}
+}
+
+// pathUnderAny returns true if the path in target is under any of prefixes
+func pathUnderAny(target string, path *pb.Path, prefixes []*pb.Path) bool {
+	for _, prefix := range prefixes {
+		if pathHasPrefix(target, path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// pathHasPrefix returns true if the path in target is prefix, or is a descendant of it. A prefix
+// without a target matches every target, and a list element of prefix matches every entry of
+// the list whose keys include the keys of the element. Wildcards in prefix are honored.
+func pathHasPrefix(target string, path *pb.Path, prefix *pb.Path) bool {
+	if (prefix.Target != "") && (prefix.Target != AllTargets) && (prefix.Target != target) {
+		return false
+	}
+	return matchPathPrefix(prefix.Elem, path.Elem) >= 0
+}
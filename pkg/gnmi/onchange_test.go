@@ -0,0 +1,177 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gnmi
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	models "github.com/onosproject/aether-models/models/aether-2.1.x/v2/api"
+	pb "github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/ygot/ygot"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+// fakeSubscribeServer collects the responses sent to a subscriber
+type fakeSubscribeServer struct {
+	grpc.ServerStream
+	responses chan *pb.SubscribeResponse
+}
+
+func (f *fakeSubscribeServer) Send(response *pb.SubscribeResponse) error {
+	f.responses <- response
+	return nil
+}
+
+func (f *fakeSubscribeServer) Recv() (*pb.SubscribeRequest, error) {
+	return nil, context.Canceled
+}
+
+func (f *fakeSubscribeServer) Context() context.Context {
+	return context.Background()
+}
+
+func (f *fakeSubscribeServer) next(t *testing.T) *pb.SubscribeResponse {
+	select {
+	case response := <-f.responses:
+		return response
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "timed out waiting for subscribe response")
+		return nil
+	}
+}
+
+func domainPath(target string, leaf ...string) *pb.Path {
+	path := &pb.Path{Target: target, Elem: []*pb.PathElem{
+		{Name: "site", Key: map[string]string{"site-id": "acme-site"}},
+		{Name: "ip-domain", Key: map[string]string{"ip-domain-id": "acme-chicago-ip"}}}}
+	for _, name := range leaf {
+		path.Elem = append(path.Elem, &pb.PathElem{Name: name})
+	}
+	return path
+}
+
+func TestPathHasPrefix(t *testing.T) {
+	leaf := domainPath("", "dns-primary")
+	assert.True(t, pathHasPrefix("ent", leaf, domainPath("ent")))
+	assert.True(t, pathHasPrefix("ent", leaf, domainPath("")))
+	assert.True(t, pathHasPrefix("ent", leaf, domainPath("ent", "dns-primary")))
+	assert.True(t, pathHasPrefix("ent", leaf, &pb.Path{Target: "ent"}))
+	assert.True(t, pathHasPrefix("ent", leaf, &pb.Path{Elem: []*pb.PathElem{{Name: "site"}}}))
+	assert.False(t, pathHasPrefix("other", leaf, domainPath("ent")))
+	assert.False(t, pathHasPrefix("ent", leaf, domainPath("ent", "dns-secondary")))
+	assert.False(t, pathHasPrefix("ent", domainPath(""), leaf))
+	assert.False(t, pathHasPrefix("ent", leaf, &pb.Path{Elem: []*pb.PathElem{
+		{Name: "site", Key: map[string]string{"site-id": "other-site"}}}}))
+	assert.True(t, pathHasPrefix("ent", leaf, wildcardDomainPath("ent", "dns-primary")))
+	assert.True(t, pathHasPrefix("ent", leaf, &pb.Path{Elem: []*pb.PathElem{{Name: wildcardLevels}, {Name: "dns-primary"}}}))
+	assert.False(t, pathHasPrefix("ent", leaf, &pb.Path{Elem: []*pb.PathElem{{Name: wildcardLevels}, {Name: "subnet"}}}))
+}
+
+func TestOnChange(t *testing.T) {
+	s, err := NewServer(model, nil)
+	require.NoError(t, err)
+	defer s.Close()
+
+	jsonConfigRoot, err := os.ReadFile("./testdata/sample-config-root.json")
+	require.NoError(t, err)
+	require.NoError(t, s.PutJSON("ent", jsonConfigRoot))
+
+	stream := &fakeSubscribeServer{responses: make(chan *pb.SubscribeResponse, 10)}
+	c := s.subscriptions.newClient(stream)
+	c.addOnChange([]*pb.Path{domainPath("ent")})
+	s.subscriptions.add(c)
+	go func() { _ = s.serveClient(c) }()
+
+	// The current values are sent, followed by a sync
+	s.processSubStreamOnChange(c)
+	initial := stream.next(t).GetUpdate()
+	require.NotNil(t, initial)
+	assert.Equal(t, "ent", initial.GetPrefix().GetTarget())
+	assert.Len(t, initial.Update, 8)
+	assert.True(t, stream.next(t).GetSyncResponse())
+
+	// A Set of one leaf is delivered as an update of that leaf
+	_, err = s.Set(context.Background(), &pb.SetRequest{
+		Prefix: domainPath("ent"),
+		Update: []*pb.Update{{
+			Path: &pb.Path{Elem: []*pb.PathElem{{Name: "dns-primary"}}},
+			Val:  &pb.TypedValue{Value: &pb.TypedValue_StringVal{StringVal: "8.8.8.8"}}}}})
+	require.NoError(t, err)
+	changed := stream.next(t).GetUpdate()
+	require.Len(t, changed.Update, 1)
+	assert.Equal(t, "dns-primary", changed.Update[0].GetPath().GetElem()[2].GetName())
+	assert.Equal(t, "8.8.8.8", changed.Update[0].GetVal().GetStringVal())
+
+	// A change in place, as the synchronizer makes to operational state, is delivered when reported
+	s.config.Mu.Lock()
+	root := s.config.Configs["ent"].(*models.Device)
+	root.Site["acme-site"].IpDomain["acme-chicago-ip"].DnsSecondary = ygot.String("8.8.4.4")
+	s.config.Changed("ent")
+	s.config.Mu.Unlock()
+	changed = stream.next(t).GetUpdate()
+	require.Len(t, changed.Update, 1)
+	assert.Equal(t, "dns-secondary", changed.Update[0].GetPath().GetElem()[2].GetName())
+
+	// A delete of the subtree is delivered as deletes of its leaves
+	_, err = s.Set(context.Background(), &pb.SetRequest{Delete: []*pb.Path{domainPath("ent")}})
+	require.NoError(t, err)
+	changed = stream.next(t).GetUpdate()
+	assert.Empty(t, changed.Update)
+	assert.Len(t, changed.Delete, 8)
+
+	// Changes outside of the subscription are not delivered
+	_, err = s.Set(context.Background(), &pb.SetRequest{
+		Prefix: &pb.Path{Target: "ent", Elem: []*pb.PathElem{{Name: "site", Key: map[string]string{"site-id": "acme-site"}}}},
+		Update: []*pb.Update{{
+			Path: &pb.Path{Elem: []*pb.PathElem{{Name: "description"}}},
+			Val:  &pb.TypedValue{Value: &pb.TypedValue_StringVal{StringVal: "changed"}}}}})
+	require.NoError(t, err)
+	select {
+	case response := <-stream.responses:
+		assert.Fail(t, "unexpected response", "%v", response)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestOnChangeInPlaceCoalesced(t *testing.T) {
+	s, err := NewServer(model, nil)
+	require.NoError(t, err)
+	defer s.Close()
+
+	jsonConfigRoot, err := os.ReadFile("./testdata/sample-config-root.json")
+	require.NoError(t, err)
+	require.NoError(t, s.PutJSON("ent", jsonConfigRoot))
+
+	stream := &fakeSubscribeServer{responses: make(chan *pb.SubscribeResponse, 10)}
+	c := s.subscriptions.newClient(stream)
+	c.addOnChange([]*pb.Path{domainPath("ent")})
+	s.subscriptions.add(c)
+	go func() { _ = s.serveClient(c) }()
+	s.processSubStreamOnChange(c)
+	stream.next(t)
+	require.True(t, stream.next(t).GetSyncResponse())
+
+	// A burst of changes in place is published as one change, with the last value
+	for _, dns := range []string{"8.8.4.1", "8.8.4.2", "8.8.4.3"} {
+		s.config.Mu.Lock()
+		root := s.config.Configs["ent"].(*models.Device)
+		root.Site["acme-site"].IpDomain["acme-chicago-ip"].DnsSecondary = ygot.String(dns)
+		s.config.Changed("ent")
+		s.config.Mu.Unlock()
+	}
+	changed := stream.next(t).GetUpdate()
+	require.Len(t, changed.Update, 1)
+	assert.Equal(t, "8.8.4.3", changed.Update[0].GetVal().GetStringVal())
+	select {
+	case response := <-stream.responses:
+		assert.Fail(t, "unexpected response", "%v", response)
+	case <-time.After(2 * InPlaceChangeInterval):
+	}
+}
@@ -0,0 +1,272 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package gnmi implements a gnmi server to mock a device with YANG models.
+package gnmi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	pb "github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/ygot/ygot"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+/*
+ * Revision History
+ *
+ * Every time the config tree of a target is committed, by a Set or by loading or pulling it
+ * through the diagnostic API, the new tree is kept as a revision of the target, along with the
+ * SetRequest that produced it and the user that sent it. Only the last few revisions of each
+ * target are kept. A target may be rolled back to one of its revisions, which is done by a Set
+ * that replaces the whole tree, so that the rollback is applied by the callback, recorded in the
+ * journal, and becomes a revision itself, like any other Set.
+ */
+
+const (
+	// DefaultRevisionLimit is the number of revisions kept for each target
+	DefaultRevisionLimit = 10
+
+	// RevisionSet is the source of a revision committed by a gNMI Set
+	RevisionSet = "set"
+
+	// RevisionPut is the source of a revision loaded through the diagnostic API
+	RevisionPut = "put"
+
+	// RevisionMerge is the source of a revision pulled through the diagnostic API
+	RevisionMerge = "merge"
+)
+
+// Revision is a committed config tree of a target
+type Revision struct {
+	Target  string
+	Number  uint64
+	Time    time.Time
+	Source  string
+	User    string
+	Request *pb.SetRequest // the request that produced the revision, if it was produced by a Set
+	Config  []byte         // the IETF JSON of the tree; omitted when revisions are listed
+}
+
+// revisionRecord is the JSON encoding of a Revision
+type revisionRecord struct {
+	Target  string          `json:"target"`
+	Number  uint64          `json:"number"`
+	Time    time.Time       `json:"time"`
+	Source  string          `json:"source"`
+	User    string          `json:"user,omitempty"`
+	Request json.RawMessage `json:"request,omitempty"`
+	Config  json.RawMessage `json:"config,omitempty"`
+}
+
+// MarshalJSON encodes the revision, with the request in the protobuf JSON encoding
+func (r *Revision) MarshalJSON() ([]byte, error) {
+	record := revisionRecord{
+		Target: r.Target,
+		Number: r.Number,
+		Time:   r.Time,
+		Source: r.Source,
+		User:   r.User,
+		Config: r.Config,
+	}
+	if r.Request != nil {
+		request, err := protojson.Marshal(r.Request)
+		if err != nil {
+			return nil, err
+		}
+		record.Request = request
+	}
+	return json.Marshal(&record)
+}
+
+// revisionHistory is the revisions of every target. It is protected by the config lock.
+type revisionHistory struct {
+	limit     int
+	last      map[string]uint64 // the number of the last revision of each target
+	revisions map[string][]*Revision
+}
+
+func newRevisionHistory() *revisionHistory {
+	return &revisionHistory{
+		limit:     DefaultRevisionLimit,
+		last:      map[string]uint64{},
+		revisions: map[string][]*Revision{},
+	}
+}
+
+// SetRevisionLimit sets the number of revisions kept for each target. A limit of zero keeps
+// no revisions.
+func (s *Server) SetRevisionLimit(limit int) {
+	s.config.Mu.Lock()
+	defer s.config.Mu.Unlock()
+	if limit < 0 {
+		limit = 0
+	}
+	s.revisions.limit = limit
+	for target, revisions := range s.revisions.revisions {
+		if len(revisions) > limit {
+			s.revisions.revisions[target] = revisions[len(revisions)-limit:]
+		}
+	}
+}
+
+// recordRevision records the current tree of target as its latest revision. The caller must
+// hold s.config.Mu.
+func (s *Server) recordRevision(target string, source string, user string, req *pb.SetRequest) {
+	if s.revisions.limit == 0 {
+		return
+	}
+	jsonTree, err := ygot.ConstructIETFJSON(s.config.Configs[target], &ygot.RFC7951JSONConfig{})
+	if err != nil {
+		log.Warnf("Failed to record revision of target %s: %v", target, err)
+		return
+	}
+	config, err := json.Marshal(jsonTree)
+	if err != nil {
+		log.Warnf("Failed to record revision of target %s: %v", target, err)
+		return
+	}
+
+	s.revisions.last[target]++
+	revisions := append(s.revisions.revisions[target], &Revision{
+		Target:  target,
+		Number:  s.revisions.last[target],
+		Time:    time.Now(),
+		Source:  source,
+		User:    user,
+		Request: req,
+		Config:  config,
+	})
+	if len(revisions) > s.revisions.limit {
+		revisions = revisions[len(revisions)-s.revisions.limit:]
+	}
+	s.revisions.revisions[target] = revisions
+}
+
+// ListRevisions returns the revisions of target, oldest first, without their config trees
+func (s *Server) ListRevisions(target string) []*Revision {
+	s.config.Mu.RLock()
+	defer s.config.Mu.RUnlock()
+
+	revisions := []*Revision{}
+	for _, revision := range s.revisions.revisions[target] {
+		listed := *revision
+		listed.Config = nil
+		revisions = append(revisions, &listed)
+	}
+	return revisions
+}
+
+// GetRevision returns a revision of target. A number of zero returns the latest revision.
+func (s *Server) GetRevision(target string, number uint64) (*Revision, error) {
+	s.config.Mu.RLock()
+	defer s.config.Mu.RUnlock()
+	return s.getRevision(target, number)
+}
+
+// getRevision returns a revision of target. The caller must hold s.config.Mu.
+func (s *Server) getRevision(target string, number uint64) (*Revision, error) {
+	revisions := s.revisions.revisions[target]
+	if len(revisions) == 0 {
+		return nil, status.Errorf(codes.NotFound, "target %s has no revisions", target)
+	}
+	if number == 0 {
+		return revisions[len(revisions)-1], nil
+	}
+	for _, revision := range revisions {
+		if revision.Number == number {
+			return revision, nil
+		}
+	}
+	return nil, status.Errorf(codes.NotFound, "revision %d of target %s not found; revisions %d to %d are kept",
+		number, target, revisions[0].Number, revisions[len(revisions)-1].Number)
+}
+
+// DiffRevisions returns the changes from one revision of target to another, as a notification
+// of updated and deleted paths. A number of zero is the latest revision.
+func (s *Server) DiffRevisions(target string, from uint64, to uint64) (*pb.Notification, error) {
+	s.config.Mu.RLock()
+	defer s.config.Mu.RUnlock()
+
+	trees := []ygot.ValidatedGoStruct{}
+	for _, number := range []uint64{from, to} {
+		revision, err := s.getRevision(target, number)
+		if err != nil {
+			return nil, err
+		}
+		tree, err := s.model.NewConfigStruct(revision.Config)
+		if err != nil {
+			return nil, fmt.Errorf("error in creating config struct of revision %d: %v", revision.Number, err)
+		}
+		trees = append(trees, tree)
+	}
+
+	changes, err := ygot.Diff(trees[0], trees[1])
+	if err != nil {
+		return nil, fmt.Errorf("error in comparing config trees: %v", err)
+	}
+	changes.Prefix = &pb.Path{Target: target}
+	return changes, nil
+}
+
+// RollbackRevision replaces the config tree of target with one of its revisions, by a Set, so
+// that the rollback is applied through the callback. As for any Set that replaces a whole tree,
+// the callback is then asked to delete the list entries that are in the current tree but not in
+// the revision.
+func (s *Server) RollbackRevision(ctx context.Context, target string, number uint64) (*pb.SetResponse, error) {
+	revision, err := s.GetRevision(target, number)
+	if err != nil {
+		return nil, err
+	}
+	log.Infof("Rolling back target %s to revision %d", target, revision.Number)
+	return s.Set(ctx, &pb.SetRequest{
+		Replace: []*pb.Update{{
+			Path: &pb.Path{Target: target},
+			Val:  &pb.TypedValue{Value: &pb.TypedValue_JsonIetfVal{JsonIetfVal: revision.Config}},
+		}},
+	})
+}
+
+type userKey struct{}
+
+// WithUser returns ctx carrying the user making a request, for requests that do not come from
+// a gNMI client
+func WithUser(ctx context.Context, user string) context.Context {
+	return context.WithValue(ctx, userKey{}, user)
+}
+
+// requestUser returns the user making a request: the user set by WithUser, the username sent by
+// the gNMI client, the common name of its TLS certificate, or its address, in that order.
+func requestUser(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	if user, okay := ctx.Value(userKey{}).(string); okay {
+		return user
+	}
+	if md, okay := metadata.FromIncomingContext(ctx); okay {
+		if usernames := md.Get("username"); len(usernames) > 0 {
+			return usernames[0]
+		}
+	}
+	p, okay := peer.FromContext(ctx)
+	if !okay {
+		return ""
+	}
+	if tlsInfo, okay := p.AuthInfo.(credentials.TLSInfo); okay && len(tlsInfo.State.PeerCertificates) > 0 {
+		return tlsInfo.State.PeerCertificates[0].Subject.CommonName
+	}
+	if p.Addr != nil {
+		return p.Addr.String()
+	}
+	return ""
+}
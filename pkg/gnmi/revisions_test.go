@@ -0,0 +1,133 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gnmi
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	models "github.com/onosproject/aether-models/models/aether-2.1.x/v2/api"
+	pb "github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// setDNSPrimary sets the dns-primary of the sample ip-domain, as user
+func setDNSPrimary(t *testing.T, s *Server, user string, dns string) {
+	_, err := s.Set(WithUser(context.Background(), user), &pb.SetRequest{
+		Prefix: domainPath("ent"),
+		Update: []*pb.Update{{
+			Path: &pb.Path{Elem: []*pb.PathElem{{Name: "dns-primary"}}},
+			Val:  &pb.TypedValue{Value: &pb.TypedValue_StringVal{StringVal: dns}}}}})
+	require.NoError(t, err)
+}
+
+func TestRevisions(t *testing.T) {
+	calls := []recordedCallback{}
+	s := newCallbackServer(t, &calls, func(ConfigCallbackType) error { return nil })
+	defer s.Close()
+
+	setDNSPrimary(t, s, "alice", "8.8.8.8")
+	setDNSPrimary(t, s, "alice", "8.8.8.9")
+
+	revisions := s.ListRevisions("ent")
+	require.Len(t, revisions, 3)
+	assert.Equal(t, uint64(1), revisions[0].Number)
+	assert.Equal(t, RevisionPut, revisions[0].Source)
+	assert.Equal(t, RevisionSet, revisions[2].Source)
+	assert.Equal(t, "alice", revisions[2].User)
+	assert.Equal(t, "8.8.8.9", revisions[2].Request.Update[0].Val.GetStringVal())
+	assert.Nil(t, revisions[2].Config)
+
+	latest, err := s.GetRevision("ent", 0)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), latest.Number)
+	assert.Contains(t, string(latest.Config), "8.8.8.9")
+
+	changes, err := s.DiffRevisions("ent", 1, 0)
+	require.NoError(t, err)
+	require.Len(t, changes.Update, 1)
+	assert.Equal(t, domainPath("", "dns-primary").String(), changes.Update[0].Path.String())
+	assert.Equal(t, "8.8.8.9", changes.Update[0].Val.GetStringVal())
+
+	// A rollback is a Set, which is applied by the callback and becomes the latest revision
+	calls = calls[:0]
+	_, err = s.RollbackRevision(WithUser(context.Background(), "bob"), "ent", 1)
+	require.NoError(t, err)
+	assert.Equal(t, "8.8.8.4", dnsPrimary(s, "ent"))
+	assert.Equal(t, []recordedCallback{{callbackType: Apply, target: "ent", targets: []string{"ent"}}}, calls)
+	latest, err = s.GetRevision("ent", 0)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(4), latest.Number)
+	assert.Equal(t, "bob", latest.User)
+	changes, err = s.DiffRevisions("ent", 1, 0)
+	require.NoError(t, err)
+	assert.Empty(t, changes.Update)
+	assert.Empty(t, changes.Delete)
+}
+
+func TestRollbackDeletesAdded(t *testing.T) {
+	calls := []recordedCallback{}
+	s := newCallbackServer(t, &calls, func(ConfigCallbackType) error { return nil })
+	defer s.Close()
+
+	_, err := s.Set(context.Background(), &pb.SetRequest{
+		Update: []*pb.Update{{
+			Path: &pb.Path{Target: "ent", Elem: []*pb.PathElem{
+				{Name: "site", Key: map[string]string{"site-id": "acme-site"}},
+				{Name: "slice", Key: map[string]string{"slice-id": "acme-slice"}}}},
+			Val: &pb.TypedValue{Value: &pb.TypedValue_JsonIetfVal{
+				JsonIetfVal: []byte(`{"slice-id": "acme-slice", "sst": "1", "sd": "111111"}`)}}}}})
+	require.NoError(t, err)
+
+	// The slice added since the revision is deleted by the rollback, once it has been applied
+	calls = calls[:0]
+	_, err = s.RollbackRevision(context.Background(), "ent", 1)
+	require.NoError(t, err)
+	assert.Equal(t, []recordedCallback{
+		{callbackType: Apply, target: "ent", targets: []string{"ent"}},
+		{callbackType: Deleted, target: "ent", targets: []string{"ent"}, path: "ent:site[site-id=acme-site]/slice[slice-id=acme-slice]"}}, calls)
+	s.config.Mu.RLock()
+	assert.Empty(t, s.config.Configs["ent"].(*models.Device).Site["acme-site"].Slice)
+	s.config.Mu.RUnlock()
+}
+
+func TestRevisionLimit(t *testing.T) {
+	s := newSampleServer(t)
+	defer s.Close()
+	s.SetRevisionLimit(2)
+
+	setDNSPrimary(t, s, "alice", "8.8.8.8")
+	setDNSPrimary(t, s, "alice", "8.8.8.9")
+	revisions := s.ListRevisions("ent")
+	require.Len(t, revisions, 2)
+	assert.Equal(t, uint64(2), revisions[0].Number)
+	assert.Equal(t, uint64(3), revisions[1].Number)
+
+	_, err := s.GetRevision("ent", 1)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	_, err = s.GetRevision("other", 0)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	s.SetRevisionLimit(0)
+	assert.Empty(t, s.ListRevisions("ent"))
+	setDNSPrimary(t, s, "alice", "8.8.8.4")
+	assert.Empty(t, s.ListRevisions("ent"))
+}
+
+func TestRequestUser(t *testing.T) {
+	assert.Equal(t, "", requestUser(context.Background()))
+	assert.Equal(t, "alice", requestUser(WithUser(context.Background(), "alice")))
+
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 5150}})
+	assert.Equal(t, "10.0.0.1:5150", requestUser(ctx))
+	ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("username", "bob"))
+	assert.Equal(t, "bob", requestUser(ctx))
+}
@@ -6,10 +6,15 @@
 package gnmi
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
+
 	"github.com/eapache/channels"
 	pb "github.com/openconfig/gnmi/proto/gnmi"
 	"github.com/openconfig/ygot/ygot"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 // NewConfigForest creates an instance of ConfigForst that is empty
@@ -21,25 +26,30 @@ func NewConfigForest() *ConfigForest {
 // NewServer creates an instance of Server with given json config.
 func NewServer(model *Model, callback ConfigCallback) (*Server, error) {
 	s := &Server{
-		model:    model,
-		config:   NewConfigForest(),
-		callback: callback,
-	}
-
-	s.subscribed = make(map[string][]*streamClient)
-
-	/* Create a RingChannel that can hold 100 items, and will discard
-	 * the oldest if it becomes full.
-	 *
-	 * TODO: This needs further testing and design work. It's unclear
-	 *    whether we have any subscribers now, and if so then simply
-	 *    deleting the channel may suffice. If we do have (or expect to
-	 *    have) subscribers then we may need a more robust solution.
-	 *    The RingChannel will prevent blocking, but may lead to loss of
-	 *    notifications.
+		model:     model,
+		config:    NewConfigForest(),
+		callback:  callback,
+		published: map[string]ygot.ValidatedGoStruct{},
+	}
+	s.config.changed = s.changedInPlace
+
+	s.leafModes = map[string]pb.SubscriptionMode{}
+	for schemaPath, mode := range DefaultLeafModeOverrides {
+		s.leafModes[schemaPath] = mode
+	}
+
+	s.subscriptions = newSubscriptionManager()
+	s.revisions = newRevisionHistory()
+
+	/* Create an unbounded channel of changes, so that committing a change never
+	 * blocks on the dispatcher. No change may be discarded, as each is a delta
+	 * against the last one published. The dispatcher itself never blocks on a
+	 * subscriber; each subscriber has its own bounded queue, and is resynchronized
+	 * if it overflows.
 	 */
 
-	s.ConfigUpdate = channels.NewRingChannel(100)
+	s.ConfigUpdate = channels.NewInfiniteChannel()
+	go s.dispatchConfigEvents(s.ConfigUpdate)
 
 	return s, nil
 }
@@ -47,23 +57,70 @@ func NewServer(model *Model, callback ConfigCallback) (*Server, error) {
 // Close - called on shutdown - shutdown gracefully
 func (s *Server) Close() {
 	log.Info("Shutting down gNMI server")
-	for sub, streamClientList := range s.subscribed {
-		log.Infof("Closing subscriptions to %s. %d", sub, len(streamClientList))
-		for _, sc := range streamClientList {
-			close(sc.UpdateChan)
-		}
-	}
+	s.subscriptions.disconnectAll(status.Error(codes.Unavailable, "gNMI server is shutting down"))
 
+	s.config.Mu.Lock()
+	// Changes made in place are not published once the channel is closed
+	if s.inPlaceTimer != nil {
+		s.inPlaceTimer.Stop()
+		s.inPlaceTimer = nil
+		s.inPlace = nil
+	}
 	if s.ConfigUpdate != nil {
-		log.Info("Closing Ring Buffer Channel")
+		log.Info("Closing change channel")
 		s.ConfigUpdate.Close()
+		s.ConfigUpdate = nil
+	}
+	// A commit awaiting confirmation stays committed
+	if s.pendingCommit != nil {
+		s.pendingCommit.timer.Stop()
+		s.pendingCommit = nil
+	}
+	if s.journal != nil {
+		if err := s.journal.Close(); err != nil {
+			log.Warnf("Failed to close journal: %v", err)
+		}
 	}
+	s.config.Mu.Unlock()
 }
 
-// ExecuteCallbacks executes the callbacks for the synchronizer
-func (s *Server) ExecuteCallbacks(reason ConfigCallbackType, target string, path *pb.Path) error {
+// journalTree records the tree of target to the journal, if there is one, as a SetRequest that
+// replaces the whole tree, for a change that was not made by a Set. The caller must hold
+// s.config.Mu.
+func (s *Server) journalTree(target string) {
+	if s.journal == nil {
+		return
+	}
+	tree, err := s.targetTree(target)
+	if err == nil {
+		err = s.journal.Record(&pb.SetRequest{Replace: []*pb.Update{{
+			Path: &pb.Path{Target: target},
+			Val:  &pb.TypedValue{Value: &pb.TypedValue_JsonIetfVal{JsonIetfVal: tree}},
+		}}}, nil)
+	}
+	if err != nil {
+		log.Warnf("Failed to record change to target %s: %v", target, err)
+	}
+}
+
+// SetJournal records every subsequent SetRequest, and every other change to the configuration,
+// to journal. The journal is closed when the server is closed.
+func (s *Server) SetJournal(journal *Journal) {
+	s.config.Mu.Lock()
+	defer s.config.Mu.Unlock()
+	s.journal = journal
+}
+
+// ExecuteCallbacks executes the callbacks for the synchronizer. scope is the paths of the
+// subtrees the callback covers, or nil for every tree.
+func (s *Server) ExecuteCallbacks(ctx context.Context, reason ConfigCallbackType, target string, path *pb.Path, scope []*pb.Path) error {
+	// Hold the lock, as Set does when it calls the callback, so that the config does not change
+	// while the callback is copying it
+	s.config.Mu.Lock()
+	defer s.config.Mu.Unlock()
+
 	if s.callback != nil {
-		if err := s.callback(s.config, reason, target, path); err != nil {
+		if err := s.callback(ctx, s.config, reason, target, path, scope); err != nil {
 			return err
 		}
 	}
@@ -96,5 +153,62 @@ func (s *Server) PutJSON(target string, b []byte) error {
 		return err
 	}
 	s.config.Configs[target] = rootStruct
+	s.recordRevision(target, RevisionPut, "", nil)
+	s.journalTree(target)
+	s.publishChanges([]string{target})
 	return nil
 }
+
+// MergeJSON replaces the subtree at path in the config tree of target with b, the IETF JSON
+// value of that subtree, keeping the rest of the tree. A nil or empty path replaces the whole
+// tree. Returns the changes as a notification of updated and deleted paths. If dryRun is set,
+// the changes are computed but the config tree is not modified.
+func (s *Server) MergeJSON(target string, path *pb.Path, b []byte, dryRun bool) (*pb.Notification, error) {
+	s.config.Mu.Lock()
+	defer s.config.Mu.Unlock()
+
+	if path == nil {
+		path = &pb.Path{}
+	}
+	path = &pb.Path{Target: target, Elem: path.Elem}
+
+	allJSONTree := map[string]map[string]interface{}{}
+	jsonTree, _, err := s.jsonTreeFromPath(allJSONTree, nil, path)
+	if err != nil {
+		return nil, err
+	}
+	_, err = s.doReplaceOrUpdate(jsonTree, pb.UpdateResult_REPLACE, nil, path, &pb.TypedValue{
+		Value: &pb.TypedValue_JsonIetfVal{JsonIetfVal: b},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	jsonDump, err := json.Marshal(jsonTree)
+	if err != nil {
+		return nil, fmt.Errorf("error in marshaling IETF JSON tree to bytes: %v", err)
+	}
+	rootStruct, err := s.model.NewConfigStruct(jsonDump)
+	if err != nil {
+		return nil, fmt.Errorf("error in creating config struct from IETF JSON data: %v", err)
+	}
+
+	oldConfig, okay := s.config.Configs[target]
+	if !okay {
+		if oldConfig, err = s.model.NewConfigStruct(nil); err != nil {
+			return nil, err
+		}
+	}
+	changes, err := ygot.Diff(oldConfig, rootStruct)
+	if err != nil {
+		return nil, fmt.Errorf("error in comparing config trees: %v", err)
+	}
+
+	if !dryRun {
+		s.config.Configs[target] = rootStruct
+		s.recordRevision(target, RevisionMerge, "", nil)
+		s.journalTree(target)
+		s.publishChanges([]string{target})
+	}
+	return changes, nil
+}
@@ -6,6 +6,7 @@ package gnmi
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"os"
@@ -283,7 +284,7 @@ func runTestSet(t *testing.T, s *Server, textPbPrefix string, textPbUpdate strin
 		Prefix: &pbPrefix,
 		Update: []*pb.Update{&pbUpdate},
 	}
-	_, err := s.Set(req)
+	_, err := s.Set(context.Background(), req)
 
 	// Check return code
 	gotRetStatus, ok := status.FromError(err)
@@ -318,3 +319,81 @@ func TestServer_PutJSON(t *testing.T) {
 	assert.True(t, okay)
 	assert.NotNil(t, acme)
 }
+
+func TestServer_MergeJSON(t *testing.T) {
+	jsonConfigRoot, err := os.ReadFile("./testdata/sample-config-root.json")
+	assert.NoError(t, err)
+	s, err := NewServer(model, nil)
+	assert.NoError(t, err)
+	err = s.PutJSON("acme", jsonConfigRoot)
+	assert.NoError(t, err)
+
+	path := &pb.Path{Elem: []*pb.PathElem{
+		{Name: "site", Key: map[string]string{"site-id": "acme-site"}},
+		{Name: "ip-domain", Key: map[string]string{"ip-domain-id": "acme-chicago-ip"}},
+	}}
+	ipDomain := []byte(`{"ip-domain-id": "acme-chicago-ip", "admin-status": "DISABLE",
+		"description": "Chicago IP Domain", "display-name": "Chicago", "dns-primary": "1.1.1.1",
+		"dns-secondary": "8.8.8.4", "mtu": 12690, "subnet": "163.25.44.0/31"}`)
+
+	// A dry run reports the change, but does not make it
+	changes, err := s.MergeJSON("acme", path, ipDomain, true)
+	assert.NoError(t, err)
+	require.Len(t, changes.Update, 1)
+	assert.Equal(t, "site[site-id=acme-site]/ip-domain[ip-domain-id=acme-chicago-ip]/dns-primary", PathToString(changes.Update[0].Path))
+	assert.Equal(t, "1.1.1.1", changes.Update[0].Val.GetStringVal())
+	assert.Empty(t, changes.Delete)
+	jsonData, err := s.GetJSON("acme")
+	assert.NoError(t, err)
+	require.JSONEq(t, string(jsonConfigRoot), string(jsonData))
+
+	// The subtree is merged, and the rest of the tree is kept
+	_, err = s.MergeJSON("acme", path, ipDomain, false)
+	assert.NoError(t, err)
+	jsonData, err = s.GetJSON("acme")
+	assert.NoError(t, err)
+	assert.Contains(t, string(jsonData), "1.1.1.1")
+	assert.Contains(t, string(jsonData), "ACME Site")
+
+	// Merging the root replaces the whole tree
+	changes, err = s.MergeJSON("acme", nil, []byte("{}"), false)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, changes.Delete)
+	jsonData, err = s.GetJSON("acme")
+	assert.NoError(t, err)
+	require.JSONEq(t, "{}", string(jsonData))
+
+	_, err = s.MergeJSON("acme", path, []byte(`{"bogus": 1}`), false)
+	assert.Error(t, err)
+}
+
+func TestExecuteCallbacksHoldsLock(t *testing.T) {
+	// The callback walks the config trees, as the synchronizer does when it copies them. Run
+	// with -race, this fails if the trees can change while the callback is walking them.
+	callback := func(ctx context.Context, config *ConfigForest, callbackType ConfigCallbackType, target string, path *pb.Path, scope []*pb.Path) error {
+		for target, config := range config.Configs {
+			if _, err := ygot.ConstructIETFJSON(config, &ygot.RFC7951JSONConfig{}); err != nil {
+				return fmt.Errorf("target %s: %v", target, err)
+			}
+		}
+		return nil
+	}
+	s, err := NewServer(model, callback)
+	require.NoError(t, err)
+	defer s.Close()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 20; i++ {
+			assert.NoError(t, s.ExecuteCallbacks(context.Background(), Forced, AllTargets, nil, nil))
+		}
+	}()
+	for i := 0; i < 20; i++ {
+		_, err := s.Set(context.Background(), &pb.SetRequest{Replace: []*pb.Update{{
+			Path: &pb.Path{Target: fmt.Sprintf("target-%d", i)},
+			Val:  &pb.TypedValue{Value: &pb.TypedValue_JsonIetfVal{JsonIetfVal: []byte("{}")}}}}})
+		require.NoError(t, err)
+	}
+	<-done
+}
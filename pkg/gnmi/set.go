@@ -6,22 +6,27 @@
 package gnmi
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"sort"
 	"time"
 
+	"github.com/onosproject/sdcore-adapter/pkg/tracing"
 	pb "github.com/openconfig/gnmi/proto/gnmi"
 	"github.com/openconfig/goyang/pkg/yang"
 	"github.com/openconfig/ygot/ygot"
 	"github.com/openconfig/ygot/ytypes"
+	"go.opentelemetry.io/otel/attribute"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
 
-// doDelete deletes the path from the json tree if the path exists. If success,
-// it calls the callback function to apply the change to the device hardware.
-func (s *Server) doDelete(jsonTree map[string]interface{}, target string, prefix, path *pb.Path) (*pb.UpdateResult, bool, error) {
+// doDelete deletes the path from the json tree if the path exists. The callback function,
+// which applies the change to the device hardware, is called by Set once every tree of the
+// request has been built.
+func (s *Server) doDelete(jsonTree map[string]interface{}, prefix, path *pb.Path) (*pb.UpdateResult, bool, error) {
 	// Update json tree of the device config
 	var curNode interface{} = jsonTree
 	pathDeleted := false
@@ -60,16 +65,6 @@ func (s *Server) doDelete(jsonTree map[string]interface{}, target string, prefix
 	}
 
 	if pathDeleted {
-		if s.callback != nil {
-			// Note that s.config has not received the changes yet, so it still contains
-			// the object being deleted, and can be used to lookup information about
-			// it inside the callback.
-			log.Debugf("Calling delete callback on: %s", PathToString(fullPath))
-			err := s.callback(s.config, Deleted, target, fullPath)
-			if err != nil {
-				return nil, false, err
-			}
-		}
 		log.Infof("Deleted: %s", PathToString(fullPath))
 	}
 
@@ -173,17 +168,54 @@ func (s *Server) doReplaceOrUpdate(jsonTree map[string]interface{}, op pb.Update
 }
 
 // Set implements the Set RPC in gNMI spec.
-func (s *Server) Set(req *pb.SetRequest) (*pb.SetResponse, error) {
+func (s *Server) Set(ctx context.Context, req *pb.SetRequest) (resp *pb.SetResponse, err error) {
 	tStart := time.Now()
 	gnmiRequestsTotal.WithLabelValues("SET").Inc()
 
+	ctx, span := tracing.StartSpan(ctx, "gnmi.Set",
+		attribute.Int("gnmi.deletes", len(req.GetDelete())),
+		attribute.Int("gnmi.replaces", len(req.GetReplace())),
+		attribute.Int("gnmi.updates", len(req.GetUpdate())))
+	defer func() { tracing.EndSpan(span, err) }()
+
 	s.config.Mu.Lock()
 	defer s.config.Mu.Unlock()
 
+	// Recorded while the lock is held, so that the journal is in the order requests are applied
+	if s.journal != nil {
+		defer func() {
+			if journalErr := s.journal.Record(req, err); journalErr != nil {
+				log.Warnf("Failed to record Set request: %v", journalErr)
+			}
+		}()
+	}
+
 	allJSONTree := map[string]map[string]interface{}{}
 
+	// Publish the changes, if the request was committed
+	defer func() {
+		targets := []string{}
+		for target := range allJSONTree {
+			targets = append(targets, target)
+		}
+		sort.Strings(targets)
+		s.publishChanges(targets)
+	}()
+
+	confirmed, confirmTimeout, err := parseConfirmedCommit(req.GetExtension())
+	if err != nil {
+		gnmiRequestsFailedTotal.WithLabelValues("SET").Inc()
+		return nil, err
+	}
+	if confirmed != nil && confirmed.Confirm && s.pendingCommit == nil {
+		gnmiRequestsFailedTotal.WithLabelValues("SET").Inc()
+		return nil, status.Error(codes.FailedPrecondition, "no commit is pending confirmation")
+	}
+
 	prefix := req.GetPrefix()
 	var results []*pb.UpdateResult
+	var deletes []pendingDelete
+	replacedTrees := map[string]bool{}
 
 	for _, path := range req.GetDelete() {
 		log.Debugf("Handling delete: %v", path)
@@ -192,21 +224,29 @@ func (s *Server) Set(req *pb.SetRequest) (*pb.SetResponse, error) {
 			gnmiRequestsFailedTotal.WithLabelValues("SET").Inc()
 			return nil, err
 		}
-		res, _, grpcStatusError := s.doDelete(jsonTree, target, prefix, path)
+		res, pathDeleted, grpcStatusError := s.doDelete(jsonTree, prefix, path)
 		if grpcStatusError != nil {
 			log.Warnf("Delete returning with error %v", grpcStatusError)
 			gnmiRequestsFailedTotal.WithLabelValues("SET").Inc()
 			return nil, grpcStatusError
 		}
+		if pathDeleted {
+			fullPath := gnmiFullPath(prefix, path)
+			fullPath.Target = target
+			deletes = append(deletes, pendingDelete{target: target, path: fullPath})
+		}
 		results = append(results, res)
 	}
 	for _, upd := range req.GetReplace() {
 		log.Debugf("Handling replace: %v", upd)
-		jsonTree, _, err := s.jsonTreeFromPath(allJSONTree, prefix, upd.GetPath())
+		jsonTree, target, err := s.jsonTreeFromPath(allJSONTree, prefix, upd.GetPath())
 		if err != nil {
 			gnmiRequestsFailedTotal.WithLabelValues("SET").Inc()
 			return nil, err
 		}
+		if len(gnmiFullPath(prefix, upd.GetPath()).GetElem()) == 0 {
+			replacedTrees[target] = true
+		}
 		res, grpcStatusError := s.doReplaceOrUpdate(jsonTree, pb.UpdateResult_REPLACE, prefix, upd.GetPath(), upd.GetVal())
 		if grpcStatusError != nil {
 			gnmiRequestsFailedTotal.WithLabelValues("SET").Inc()
@@ -231,59 +271,223 @@ func (s *Server) Set(req *pb.SetRequest) (*pb.SetResponse, error) {
 		results = append(results, res)
 	}
 
-	for target, jsonTree := range allJSONTree {
-		jsonDump, err := json.Marshal(jsonTree)
+	// Keep the trees from before the commit, to restore them if it is not confirmed in time.
+	// If the Set also confirms the pending commit, the trees that commit kept are not restored
+	// by the new one, so every tree is kept.
+	var previous map[string][]byte
+	if confirmTimeout > 0 {
+		targets := []string{}
+		for target := range allJSONTree {
+			targets = append(targets, target)
+		}
+		pending := s.pendingCommit
+		if confirmed.Confirm {
+			pending = nil
+		}
+		if previous, err = s.currentTrees(targets, pending); err != nil {
+			gnmiRequestsFailedTotal.WithLabelValues("SET").Inc()
+			return nil, err
+		}
+	}
+
+	if err := s.commit(ctx, req, allJSONTree, deletes, replacedTrees); err != nil {
+		gnmiRequestsFailedTotal.WithLabelValues("SET").Inc()
+		return nil, err
+	}
+
+	if confirmed != nil && confirmed.Confirm {
+		_ = s.confirmPendingCommit()
+	}
+	if confirmTimeout > 0 {
+		s.startPendingCommit(requestUser(ctx), previous, confirmTimeout)
+	}
+
+	setResponse := &pb.SetResponse{
+		Prefix:   req.GetPrefix(),
+		Response: results,
+	}
+
+	gnmiRequestDuration.WithLabelValues("SET").Observe(time.Since(tStart).Seconds())
+
+	return setResponse, nil
+}
+
+// pendingDelete is a path deleted by a Set, whose callback has not yet been called
+type pendingDelete struct {
+	target string
+	path   *pb.Path
+}
+
+// appendPendingDeletes returns deletes with each of more that is not already in it
+func appendPendingDeletes(deletes []pendingDelete, more ...pendingDelete) []pendingDelete {
+	for _, d := range more {
+		duplicate := false
+		for _, existing := range deletes {
+			if existing.target == d.target && pathKey(existing.path.GetElem()) == pathKey(d.path.GetElem()) {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			deletes = append(deletes, d)
+		}
+	}
+	return deletes
+}
+
+// removedEntries returns a delete of each list entry of oldConfig, the tree of target, that
+// newConfig does not have. Only the outermost removed entry is deleted, as deleting it deletes
+// the entries inside it.
+func (s *Server) removedEntries(target string, oldConfig ygot.GoStruct, newConfig ygot.GoStruct) ([]pendingDelete, error) {
+	changes, err := ygot.Diff(oldConfig, newConfig)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "error in comparing config trees of target %s: %v", target, err)
+	}
+
+	removed := []pendingDelete{}
+	for _, deleted := range changes.Delete {
+		elems := deleted.GetElem()
+		for i, elem := range elems {
+			if len(elem.GetKey()) == 0 {
+				continue
+			}
+			entryPath := &pb.Path{Target: target, Elem: elems[:i+1]}
+			nodes, err := ytypes.GetNode(s.model.schemaTreeRoot, newConfig, &pb.Path{Elem: entryPath.Elem})
+			if status.Code(err) != codes.NotFound && err != nil {
+				return nil, status.Errorf(codes.Internal, "error in looking up %s in target %s: %v", PathToString(entryPath), target, err)
+			}
+			if len(nodes) == 0 {
+				removed = appendPendingDeletes(removed, pendingDelete{target: target, path: entryPath})
+				break
+			}
+		}
+	}
+	sort.Slice(removed, func(i, j int) bool {
+		return pathKey(removed[i].path.GetElem()) < pathKey(removed[j].path.GetElem())
+	})
+	return removed, nil
+}
+
+// commit builds and validates a config tree for each target of allJSONTree, replaces the config
+// trees and calls the callback once to apply them, then calls the callback for each of the
+// deletes, and for each list entry removed from a target whose whole tree was replaced, so that
+// a rollback to an earlier tree deletes what was added since. The Set is all or nothing; if the
+// apply or a delete fails, every target gets its previous tree back and the callback is called
+// to roll back. Once committed, the trees are recorded as revisions. The caller must hold
+// s.config.Mu.
+func (s *Server) commit(ctx context.Context, req *pb.SetRequest, allJSONTree map[string]map[string]interface{}, deletes []pendingDelete, replacedTrees map[string]bool) error {
+	targets := []string{}
+	for target := range allJSONTree {
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+
+	// A Set that changes nothing, such as one that only confirms the pending commit, has no
+	// targets; there is nothing to apply, and no revision to record
+	if len(targets) == 0 {
+		return nil
+	}
+
+	candidates := map[string]ygot.ValidatedGoStruct{}
+	for _, target := range targets {
+		jsonDump, err := json.Marshal(allJSONTree[target])
 		if err != nil {
 			msg := fmt.Sprintf("error in marshaling IETF JSON tree to bytes: %v", err)
 			log.Error(msg)
-			gnmiRequestsFailedTotal.WithLabelValues("SET").Inc()
-			return nil, status.Error(codes.Internal, msg)
+			return status.Error(codes.Internal, msg)
 		}
 
 		rootStruct, err := s.model.NewConfigStruct(jsonDump)
 		if err != nil {
 			msg := fmt.Sprintf("error in creating config struct from IETF JSON data: %v", err)
 			log.Error(msg)
-			gnmiRequestsFailedTotal.WithLabelValues("SET").Inc()
-			return nil, status.Error(codes.Internal, msg)
+			return status.Error(codes.Internal, msg)
 		}
+		if err := rootStruct.Validate(); err != nil {
+			return status.Errorf(codes.InvalidArgument, "config data validation of target %s fails: %v", target, err)
+		}
+		candidates[target] = rootStruct
 
-		// Make a copy of the previous config tree, so we can restore it if the synchronizer failes.
-		oldConfig, haveOldConfig := s.config.Configs[target]
-		s.config.Configs[target] = rootStruct
-
-		// Apply the validated operation to the device.
-		// Note: We apply this after all operations have been applied to the config tree, because it is
-		// more performant to the json.Marshal and NewConfigStruct once per gnmi operation than it is to
-		// do it for each individual path set or delete.
-		if s.callback != nil {
-			if applyErr := s.callback(s.config, Apply, target, nil); applyErr != nil {
-				rollbackErr := s.callback(s.config, Rollback, target, nil)
-				if haveOldConfig {
-					// restore previous config tree before returning
-					s.config.Configs[target] = oldConfig
-				}
-				if rollbackErr != nil {
-					return nil, status.Errorf(codes.Internal, "error in rollback the failed operation (%v): %v", applyErr, rollbackErr)
-				}
-				return nil, status.Errorf(codes.Aborted, "error in applying operation to device: %v", applyErr)
+		if oldConfig, okay := s.config.Configs[target]; okay && replacedTrees[target] {
+			removed, err := s.removedEntries(target, oldConfig, rootStruct)
+			if err != nil {
+				return err
 			}
+			deletes = appendPendingDeletes(deletes, removed...)
 		}
 	}
 
-	setResponse := &pb.SetResponse{
-		Prefix:   req.GetPrefix(),
-		Response: results,
+	if s.callback == nil {
+		for _, target := range targets {
+			s.config.Configs[target] = candidates[target]
+			s.recordRevision(target, RevisionSet, requestUser(ctx), req)
+		}
+		return nil
 	}
 
-	for _, response := range setResponse.GetResponse() {
-		update := &pb.Update{
-			Path: response.GetPath(),
+	// Keep the previous config trees, so we can restore them if the synchronizer fails, and
+	// a forest of them, which still contains the objects being deleted, so that the delete
+	// callbacks can lookup information about them.
+	oldConfigs := map[string]ygot.ValidatedGoStruct{}
+	previous := NewConfigForest()
+	for target, config := range s.config.Configs {
+		previous.Configs[target] = config
+	}
+	for target, rootStruct := range candidates {
+		if oldConfig, okay := s.config.Configs[target]; okay {
+			oldConfigs[target] = oldConfig
 		}
-		s.ConfigUpdate.In() <- update
+		s.config.Configs[target] = rootStruct
 	}
 
-	gnmiRequestDuration.WithLabelValues("SET").Observe(time.Since(tStart).Seconds())
+	// Apply the validated operation to the device, once for every target of the request.
+	// Note: We apply this after all operations have been applied to the config trees, because it is
+	// more performant to the json.Marshal and NewConfigStruct once per gnmi operation than it is to
+	// do it for each individual path set or delete.
+	target := AllTargets
+	if len(targets) == 1 {
+		target = targets[0]
+	}
+	scope := TargetPaths(targets)
+	applyErr := s.callback(ctx, s.config, Apply, target, nil, scope)
+	if applyErr == nil {
+		// The deletes are only pushed once the apply has succeeded, so that nothing is deleted
+		// by a Set that fails to apply.
+		for _, d := range deletes {
+			log.Debugf("Calling delete callback on: %s", PathToString(d.path))
+			if applyErr = s.callback(ctx, previous, Deleted, d.target, d.path, scope); applyErr != nil {
+				break
+			}
+		}
+	}
+	if applyErr != nil {
+		// restore previous config trees, so that the rollback pushes them
+		for _, target := range targets {
+			if oldConfig, okay := oldConfigs[target]; okay {
+				s.config.Configs[target] = oldConfig
+			} else {
+				delete(s.config.Configs, target)
+			}
+		}
+		rollbackErr := s.callback(ctx, s.config, Rollback, target, nil, scope)
+		if rollbackErr != nil {
+			return status.Errorf(codes.Internal, "error in rollback the failed operation (%v): %v", applyErr, rollbackErr)
+		}
+		return status.Errorf(codes.Aborted, "error in applying operation to device: %v", applyErr)
+	}
 
-	return setResponse, nil
+	for _, target := range targets {
+		s.recordRevision(target, RevisionSet, requestUser(ctx), req)
+	}
+	return nil
+}
+
+// TargetPaths returns the paths of the whole trees of targets, which is the scope of a callback
+// that covers them
+func TargetPaths(targets []string) []*pb.Path {
+	paths := []*pb.Path{}
+	for _, target := range targets {
+		paths = append(paths, &pb.Path{Target: target})
+	}
+	return paths
 }
@@ -0,0 +1,156 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gnmi
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	models "github.com/onosproject/aether-models/models/aether-2.1.x/v2/api"
+	pb "github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// recordedCallback is a call of a config callback
+type recordedCallback struct {
+	callbackType ConfigCallbackType
+	target       string
+	targets      []string // the targets of the scope
+	path         string   // the target and path of a delete
+}
+
+// newCallbackServer returns a server with the sample config in target "ent", whose callback
+// records its calls and fails with the error that fail returns for the call
+func newCallbackServer(t *testing.T, calls *[]recordedCallback, fail func(ConfigCallbackType) error) *Server {
+	callback := func(ctx context.Context, config *ConfigForest, callbackType ConfigCallbackType, target string, path *pb.Path, scope []*pb.Path) error {
+		call := recordedCallback{callbackType: callbackType, target: target}
+		for _, scopePath := range scope {
+			call.targets = append(call.targets, scopePath.GetTarget())
+		}
+		if path != nil {
+			call.path = path.GetTarget() + ":" + PathToString(path)
+		}
+		*calls = append(*calls, call)
+		return fail(callbackType)
+	}
+	s, err := NewServer(model, callback)
+	require.NoError(t, err)
+	jsonConfigRoot, err := os.ReadFile("./testdata/sample-config-root.json")
+	require.NoError(t, err)
+	require.NoError(t, s.PutJSON("ent", jsonConfigRoot))
+	return s
+}
+
+// multiTargetRequest sets the dns-primary of the sample ip-domain in targets "ent" and "other"
+func multiTargetRequest() *pb.SetRequest {
+	return &pb.SetRequest{
+		Update: []*pb.Update{{
+			Path: domainPath("ent", "dns-primary"),
+			Val:  &pb.TypedValue{Value: &pb.TypedValue_StringVal{StringVal: "8.8.8.8"}}}, {
+			Path: domainPath("other", "dns-primary"),
+			Val:  &pb.TypedValue{Value: &pb.TypedValue_StringVal{StringVal: "8.8.8.8"}}}}}
+}
+
+func dnsPrimary(s *Server, target string) string {
+	s.config.Mu.RLock()
+	defer s.config.Mu.RUnlock()
+	config, okay := s.config.Configs[target]
+	if !okay {
+		return ""
+	}
+	site, okay := config.(*models.Device).Site["acme-site"]
+	if !okay {
+		return ""
+	}
+	return *site.IpDomain["acme-chicago-ip"].DnsPrimary
+}
+
+func TestSetMultiTarget(t *testing.T) {
+	calls := []recordedCallback{}
+	s := newCallbackServer(t, &calls, func(ConfigCallbackType) error { return nil })
+	defer s.Close()
+
+	_, err := s.Set(context.Background(), multiTargetRequest())
+	require.NoError(t, err)
+	assert.Equal(t, []recordedCallback{{callbackType: Apply, target: AllTargets, targets: []string{"ent", "other"}}}, calls)
+	assert.Equal(t, "8.8.8.8", dnsPrimary(s, "ent"))
+	assert.Equal(t, "8.8.8.8", dnsPrimary(s, "other"))
+}
+
+func TestSetMultiTargetApplyFails(t *testing.T) {
+	calls := []recordedCallback{}
+	s := newCallbackServer(t, &calls, func(callbackType ConfigCallbackType) error {
+		if callbackType == Apply {
+			return errors.New("apply failed")
+		}
+		return nil
+	})
+	defer s.Close()
+
+	_, err := s.Set(context.Background(), multiTargetRequest())
+	assert.Equal(t, codes.Aborted, status.Code(err))
+	assert.Equal(t, []recordedCallback{
+		{callbackType: Apply, target: AllTargets, targets: []string{"ent", "other"}},
+		{callbackType: Rollback, target: AllTargets, targets: []string{"ent", "other"}}}, calls)
+
+	// Neither target was changed
+	assert.Equal(t, "8.8.8.4", dnsPrimary(s, "ent"))
+	s.config.Mu.RLock()
+	_, okay := s.config.Configs["other"]
+	s.config.Mu.RUnlock()
+	assert.False(t, okay)
+}
+
+func TestSetDeleteFails(t *testing.T) {
+	calls := []recordedCallback{}
+	s := newCallbackServer(t, &calls, func(callbackType ConfigCallbackType) error {
+		if callbackType == Deleted {
+			return errors.New("delete failed")
+		}
+		return nil
+	})
+	defer s.Close()
+
+	req := multiTargetRequest()
+	req.Delete = []*pb.Path{domainPath("ent", "dns-secondary")}
+	_, err := s.Set(context.Background(), req)
+	assert.Equal(t, codes.Aborted, status.Code(err))
+
+	// The deletes are called back once the apply has succeeded, and everything is rolled back if
+	// one fails
+	assert.Equal(t, []recordedCallback{
+		{callbackType: Apply, target: AllTargets, targets: []string{"ent", "other"}},
+		{callbackType: Deleted, target: "ent", targets: []string{"ent", "other"},
+			path: "ent:site[site-id=acme-site]/ip-domain[ip-domain-id=acme-chicago-ip]/dns-secondary"},
+		{callbackType: Rollback, target: AllTargets, targets: []string{"ent", "other"}}}, calls)
+	assert.Equal(t, "8.8.8.4", dnsPrimary(s, "ent"))
+	assert.Equal(t, "", dnsPrimary(s, "other"))
+}
+
+func TestSetApplyFailsBeforeDelete(t *testing.T) {
+	calls := []recordedCallback{}
+	s := newCallbackServer(t, &calls, func(callbackType ConfigCallbackType) error {
+		if callbackType == Apply {
+			return errors.New("apply failed")
+		}
+		return nil
+	})
+	defer s.Close()
+
+	req := multiTargetRequest()
+	req.Delete = []*pb.Path{domainPath("ent", "dns-secondary")}
+	_, err := s.Set(context.Background(), req)
+	assert.Equal(t, codes.Aborted, status.Code(err))
+
+	// Nothing is deleted by a Set that fails to apply
+	assert.Equal(t, []recordedCallback{
+		{callbackType: Apply, target: AllTargets, targets: []string{"ent", "other"}},
+		{callbackType: Rollback, target: AllTargets, targets: []string{"ent", "other"}}}, calls)
+}
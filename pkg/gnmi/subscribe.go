@@ -18,42 +18,45 @@ import (
 
 // processSubscribeOnce processes subscribe once requests
 func (s *Server) processSubscribeOnce(c *streamClient, request *pb.SubscriptionList) {
-	go s.collector(c, request)
-	s.listenForUpdates(c)
-
+	s.collector(c, request)
 }
 
 // processSubscribePoll processes subcribe poll requests
 func (s *Server) processSubscribePoll(c *streamClient, request *pb.SubscriptionList) {
-	go s.collector(c, request)
-	s.listenForUpdates(c)
-}
-
-// processSubStreamOnChange processes subscribe stream requests for on_change subscription mode.
-func (s *Server) processSubStreamOnChange(c *streamClient, request *pb.SubscriptionList) {
-	go s.listenToConfigEvents(request)
-
+	s.collector(c, request)
 }
 
 // processSubStreamSample processes subscribe stream requests for sample subscription mode.
 func (s *Server) processSubStreamSample(c *streamClient, request *pb.SubscriptionList) {
 	ticker := time.NewTicker(time.Duration(c.sampleInterval) * time.Nanosecond)
-	go func() {
-		for range ticker.C {
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
 			s.collector(c, request)
+		case <-c.done:
+			return
 		}
-	}()
-	s.listenForUpdates(c)
-
+	}
 }
 
 // Subscribe handle subscribe requests including POLL, STREAM, ONCE subscribe requests
 func (s *Server) Subscribe(stream pb.GNMI_SubscribeServer) error {
+	sc := s.subscriptions.newClient(stream)
+	s.subscriptions.add(sc)
+	defer s.subscriptions.remove(sc)
 
-	sc := streamClient{stream: stream}
-	var err error
-	sc.UpdateChan = make(chan *pb.Update)
+	go s.receiveSubscribeRequests(sc)
+
+	return s.serveClient(sc)
+}
 
+// receiveSubscribeRequests handles the requests received on the client's stream, until the
+// stream is closed.
+func (s *Server) receiveSubscribeRequests(sc *streamClient) {
+	stream := sc.stream
+
+	var err error
 	var subscribe *pb.SubscriptionList
 	var mode gnmi.SubscriptionList_Mode
 
@@ -63,26 +66,13 @@ func (s *Server) Subscribe(stream pb.GNMI_SubscribeServer) error {
 		switch {
 		case err == io.EOF, err != nil && err.Error() == "rpc error: code = Canceled desc = context canceled":
 			log.Warnf("Subscribe stream closed %v", stream)
-			close(sc.UpdateChan)
-			for k, subClientList := range s.subscribed {
-				for i, subClient := range subClientList {
-					if subClient.stream == stream {
-						log.Infof("Removing subscriber %v from %s", stream, k)
-						newLen := len(subClientList) - 1
-						if newLen == 0 {
-							delete(s.subscribed, k)
-						} else {
-							subClientList[i] = subClientList[newLen]
-							s.subscribed[k] = subClientList[:newLen]
-						}
-					}
-				}
-			}
-			return nil
+			sc.disconnect(nil)
+			return
 
 		case err != nil:
 			log.Warnf("Subscribe stream error %s. %v", err.Error(), stream)
-			return err
+			sc.disconnect(err)
+			return
 		}
 
 		if sc.sr.GetPoll() != nil {
@@ -94,28 +84,39 @@ func (s *Server) Subscribe(stream pb.GNMI_SubscribeServer) error {
 
 		switch mode {
 		case pb.SubscriptionList_ONCE:
-			go s.processSubscribeOnce(&sc, subscribe)
+			go s.processSubscribeOnce(sc, subscribe)
 		case pb.SubscriptionList_POLL:
-			go s.processSubscribePoll(&sc, subscribe)
+			go s.processSubscribePoll(sc, subscribe)
 		case pb.SubscriptionList_STREAM:
-			// Adds streamClient to the list of subscribed
+			onChange := []*pb.Path{}
+			targetDefined := []*pb.Path{}
 			for _, sub := range subscribe.Subscription {
-				streamClientList, ok := s.subscribed[sub.GetPath().String()]
-				if !ok {
-					streamClientList = []*streamClient{
-						&sc,
+				path := gnmiFullPath(subscribe.GetPrefix(), sub.GetPath())
+				path.Target = requestTarget(subscribe.GetPrefix(), sub.GetPath())
+				switch sub.GetMode() {
+				case pb.SubscriptionMode_ON_CHANGE:
+					onChange = append(onChange, path)
+				case pb.SubscriptionMode_TARGET_DEFINED:
+					if err := checkInterval("sample", sub.GetSampleInterval()); err != nil {
+						sc.disconnect(err)
+						return
+					}
+					if err := checkInterval("heartbeat", sub.GetHeartbeatInterval()); err != nil {
+						sc.disconnect(err)
+						return
 					}
-				} else {
-					streamClientList = append(streamClientList, &sc)
+					targetDefined = append(targetDefined, path)
+					// The mode of each leaf is chosen by leafMode. The ON_CHANGE leaves are
+					// sent by dispatchConfigEvents, and the SAMPLE leaves by this.
+					go s.processSubStreamTargetDefined(sc, path, sub)
 				}
 				log.Infof("Added subscription %v to %s", stream, sub.GetPath().String())
-				s.subscribed[sub.GetPath().String()] = streamClientList
 			}
+			sc.addOnChange(onChange)
+			sc.addTargetDefined(targetDefined)
 
 			for _, sub := range subscribe.Subscription {
 				switch sub.GetMode() {
-				case pb.SubscriptionMode_ON_CHANGE:
-					go s.processSubStreamOnChange(&sc, subscribe)
 				case pb.SubscriptionMode_SAMPLE:
 					subSampleInterval := sub.GetSampleInterval()
 					//If the sample_interval is set to 0,
@@ -128,28 +129,28 @@ func (s *Server) Subscribe(stream pb.GNMI_SubscribeServer) error {
 						// the sample interval less than the lowest
 						// sample interval which is defined in the target
 						if subSampleInterval < lowestSampleInterval {
-							return status.Error(codes.InvalidArgument, fmt.Sprintf("%s%d", "The sample interval must be higher than ", lowestSampleInterval))
+							sc.disconnect(status.Error(codes.InvalidArgument, fmt.Sprintf("%s%d", "The sample interval must be higher than ", lowestSampleInterval)))
+							return
 						}
 						sc.sampleInterval = subSampleInterval
 					}
-					go s.processSubStreamSample(&sc, subscribe)
-				case pb.SubscriptionMode_TARGET_DEFINED:
-					// TODO: when a client creates a
-					//  subscription specifying the target defined mode,
-					//  the target MUST determine the best type of subscription to
-					//  be created on a per-leaf basis.
-					//  That is to say,
-					//  if the path specified within the message refers
-					//  to some leaves which are event driven
-					//  (e.g., the changing of state of an entity based on an external trigger)
-					//  then an ON_CHANGE subscription may be created,
-					//  whereas if other data represents counter values,
-					//  a SAMPLE subscription may be created.
-					go s.processSubStreamOnChange(&sc, subscribe)
+					go s.processSubStreamSample(sc, subscribe)
 				}
 			}
+			if len(onChange) > 0 || len(targetDefined) > 0 {
+				go s.processSubStreamOnChange(sc)
+			}
 		default:
 		}
 	}
 
 }
+
+// checkInterval returns an error if the named interval, in nanoseconds, is set but is lower than
+// the target supports
+func checkInterval(name string, interval uint64) error {
+	if interval != 0 && interval < lowestSampleInterval {
+		return status.Error(codes.InvalidArgument, fmt.Sprintf("The %s interval must be higher than %d", name, lowestSampleInterval))
+	}
+	return nil
+}
@@ -0,0 +1,241 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package gnmi implements a gnmi server to mock a device with YANG models.
+package gnmi
+
+import (
+	"fmt"
+	"sync"
+
+	pb "github.com/openconfig/gnmi/proto/gnmi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+/*
+ * Every subscriber has a bounded queue of responses, and a single goroutine, the one serving
+ * its Subscribe RPC, that sends them. Producers, such as the ON_CHANGE dispatcher and the
+ * SAMPLE tickers, never block on a subscriber. If a subscriber's queue is full, the overflow
+ * policy either drops the oldest queued response and, once the subscriber has caught up,
+ * resends the current values of its ON_CHANGE subscriptions, or disconnects the subscriber.
+ */
+
+// OverflowPolicy is what is done when a subscriber falls behind and its queue is full
+type OverflowPolicy string
+
+const (
+	// OverflowDropOldest drops the oldest queued response, and resynchronizes the subscriber
+	OverflowDropOldest OverflowPolicy = "drop-oldest"
+
+	// OverflowDisconnect disconnects the subscriber
+	OverflowDisconnect OverflowPolicy = "disconnect"
+
+	// DefaultSubscriberQueueSize is the number of responses that may be queued for a subscriber
+	DefaultSubscriberQueueSize = 100
+)
+
+// ParseOverflowPolicy returns the overflow policy named by policy
+func ParseOverflowPolicy(policy string) (OverflowPolicy, error) {
+	switch OverflowPolicy(policy) {
+	case OverflowDropOldest, OverflowDisconnect:
+		return OverflowPolicy(policy), nil
+	}
+	return "", fmt.Errorf("Unknown subscriber overflow policy %s", policy)
+}
+
+// subscriptionManager keeps track of the subscribers
+type subscriptionManager struct {
+	mu        sync.Mutex
+	clients   map[*streamClient]bool
+	queueSize int
+	overflow  OverflowPolicy
+}
+
+func newSubscriptionManager() *subscriptionManager {
+	return &subscriptionManager{
+		clients:   map[*streamClient]bool{},
+		queueSize: DefaultSubscriberQueueSize,
+		overflow:  OverflowDropOldest,
+	}
+}
+
+// SetSubscriberPolicy sets the queue size and overflow policy of subsequent subscribers
+func (s *Server) SetSubscriberPolicy(queueSize int, overflow OverflowPolicy) {
+	s.subscriptions.mu.Lock()
+	defer s.subscriptions.mu.Unlock()
+	if queueSize <= 0 {
+		queueSize = DefaultSubscriberQueueSize
+	}
+	s.subscriptions.queueSize = queueSize
+	s.subscriptions.overflow = overflow
+}
+
+// newClient returns a client for stream, with the current queue size and overflow policy
+func (m *subscriptionManager) newClient(stream pb.GNMI_SubscribeServer) *streamClient {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return &streamClient{
+		stream:   stream,
+		queue:    make(chan *pb.SubscribeResponse, m.queueSize),
+		overflow: m.overflow,
+		done:     make(chan struct{}),
+	}
+}
+
+func (m *subscriptionManager) add(c *streamClient) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.clients[c] {
+		m.clients[c] = true
+		gnmiSubscribers.Inc()
+	}
+}
+
+func (m *subscriptionManager) remove(c *streamClient) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.clients[c] {
+		delete(m.clients, c)
+		gnmiSubscribers.Dec()
+	}
+}
+
+// onChangeClients returns the subscribers that have ON_CHANGE or TARGET_DEFINED subscriptions
+func (m *subscriptionManager) onChangeClients() []*streamClient {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	clients := []*streamClient{}
+	for c := range m.clients {
+		if c.hasOnChange() {
+			clients = append(clients, c)
+		}
+	}
+	return clients
+}
+
+// disconnectAll disconnects every subscriber with err
+func (m *subscriptionManager) disconnectAll(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for c := range m.clients {
+		c.disconnect(err)
+	}
+}
+
+// addOnChange adds ON_CHANGE subscriptions to the client
+func (c *streamClient) addOnChange(paths []*pb.Path) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onChange = append(c.onChange, paths...)
+}
+
+// getOnChange returns the ON_CHANGE subscriptions of the client
+func (c *streamClient) getOnChange() []*pb.Path {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.onChange
+}
+
+// addTargetDefined adds TARGET_DEFINED subscriptions to the client
+func (c *streamClient) addTargetDefined(paths []*pb.Path) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.targetDefined = append(c.targetDefined, paths...)
+}
+
+// getTargetDefined returns the TARGET_DEFINED subscriptions of the client
+func (c *streamClient) getTargetDefined() []*pb.Path {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.targetDefined
+}
+
+// hasOnChange returns true if the client has subscriptions that are streamed as changes occur
+func (c *streamClient) hasOnChange() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.onChange) > 0 || len(c.targetDefined) > 0
+}
+
+// send queues a response to the client, applying the overflow policy if the queue is full
+func (c *streamClient) send(response *pb.SubscribeResponse) {
+	for {
+		select {
+		case <-c.done:
+			return
+		case c.queue <- response:
+			return
+		default:
+		}
+
+		if c.overflow == OverflowDisconnect {
+			gnmiSubscribeDroppedTotal.Inc()
+			gnmiSubscribersDisconnectedTotal.Inc()
+			log.Warnf("Disconnecting subscriber %v, which has fallen behind", c.stream)
+			c.disconnect(status.Error(codes.ResourceExhausted, "subscriber is not keeping up with updates"))
+			return
+		}
+
+		select {
+		case <-c.queue:
+			gnmiSubscribeDroppedTotal.Inc()
+			c.mu.Lock()
+			c.resync = true
+			c.mu.Unlock()
+		default:
+		}
+	}
+}
+
+// takeResync returns true if responses were dropped since it was last called
+func (c *streamClient) takeResync() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	resync := c.resync
+	c.resync = false
+	return resync
+}
+
+// disconnect ends the client's Subscribe RPC, returning err. Only the first call has effect.
+func (c *streamClient) disconnect(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	select {
+	case <-c.done:
+		return
+	default:
+	}
+	c.err = err
+	close(c.done)
+}
+
+// disconnectErr returns the error that the client was disconnected with
+func (c *streamClient) disconnectErr() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// serveClient sends the client's queued responses until it is disconnected
+func (s *Server) serveClient(c *streamClient) error {
+	for {
+		select {
+		case <-c.done:
+			return c.disconnectErr()
+		case response := <-c.queue:
+			log.Debugf("Sending SubscribeResponse out to gNMI client: %s", response)
+			if err := c.stream.Send(response); err != nil {
+				log.Errorf("Error in sending response to client %v", err)
+				c.disconnect(err)
+				return err
+			}
+		}
+
+		if len(c.queue) == 0 && c.takeResync() && c.hasOnChange() {
+			log.Infof("Resending current values to subscriber %v, which fell behind", c.stream)
+			s.processSubStreamOnChange(c)
+		}
+	}
+}
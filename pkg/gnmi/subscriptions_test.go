@@ -0,0 +1,142 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gnmi
+
+import (
+	"context"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	pb "github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fakeSubscribeClient is a stream that receives the given requests, then waits until it is closed
+type fakeSubscribeClient struct {
+	fakeSubscribeServer
+	requests chan *pb.SubscribeRequest
+}
+
+func (f *fakeSubscribeClient) Recv() (*pb.SubscribeRequest, error) {
+	request, ok := <-f.requests
+	if !ok {
+		return nil, io.EOF
+	}
+	return request, nil
+}
+
+func deleteResponse(name string) *pb.SubscribeResponse {
+	return buildDeleteResponse(&pb.Path{Elem: []*pb.PathElem{{Name: name}}})
+}
+
+func newSampleServer(t *testing.T) *Server {
+	s, err := NewServer(model, nil)
+	require.NoError(t, err)
+	jsonConfigRoot, err := os.ReadFile("./testdata/sample-config-root.json")
+	require.NoError(t, err)
+	require.NoError(t, s.PutJSON("ent", jsonConfigRoot))
+	return s
+}
+
+func TestParseOverflowPolicy(t *testing.T) {
+	policy, err := ParseOverflowPolicy("disconnect")
+	assert.NoError(t, err)
+	assert.Equal(t, OverflowDisconnect, policy)
+	_, err = ParseOverflowPolicy("block")
+	assert.EqualError(t, err, "Unknown subscriber overflow policy block")
+}
+
+func TestSubscriberDropOldest(t *testing.T) {
+	s := newSampleServer(t)
+	defer s.Close()
+	s.SetSubscriberPolicy(2, OverflowDropOldest)
+
+	stream := &fakeSubscribeServer{responses: make(chan *pb.SubscribeResponse, 10)}
+	c := s.subscriptions.newClient(stream)
+	c.addOnChange([]*pb.Path{domainPath("ent")})
+	s.subscriptions.add(c)
+
+	dropped := testutil.ToFloat64(gnmiSubscribeDroppedTotal)
+	c.send(deleteResponse("a"))
+	c.send(deleteResponse("b"))
+	c.send(deleteResponse("c"))
+	assert.Equal(t, dropped+1, testutil.ToFloat64(gnmiSubscribeDroppedTotal))
+
+	// The oldest response was dropped, and once the rest are sent the current values are resent
+	go func() { _ = s.serveClient(c) }()
+	assert.Equal(t, "b", stream.next(t).GetUpdate().GetDelete()[0].GetElem()[0].GetName())
+	assert.Equal(t, "c", stream.next(t).GetUpdate().GetDelete()[0].GetElem()[0].GetName())
+	assert.Len(t, stream.next(t).GetUpdate().GetUpdate(), 8)
+	assert.True(t, stream.next(t).GetSyncResponse())
+}
+
+func TestSubscriberDisconnect(t *testing.T) {
+	s := newSampleServer(t)
+	defer s.Close()
+	s.SetSubscriberPolicy(1, OverflowDisconnect)
+
+	stream := &fakeSubscribeServer{responses: make(chan *pb.SubscribeResponse, 10)}
+	c := s.subscriptions.newClient(stream)
+	s.subscriptions.add(c)
+
+	disconnected := testutil.ToFloat64(gnmiSubscribersDisconnectedTotal)
+	c.send(deleteResponse("a"))
+	c.send(deleteResponse("b"))
+	assert.Equal(t, disconnected+1, testutil.ToFloat64(gnmiSubscribersDisconnectedTotal))
+
+	err := s.serveClient(c)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
+
+func TestSubscribeStream(t *testing.T) {
+	s := newSampleServer(t)
+	defer s.Close()
+
+	stream := &fakeSubscribeClient{
+		fakeSubscribeServer: fakeSubscribeServer{responses: make(chan *pb.SubscribeResponse, 10)},
+		requests:            make(chan *pb.SubscribeRequest, 1)}
+	stream.requests <- &pb.SubscribeRequest{Request: &pb.SubscribeRequest_Subscribe{Subscribe: &pb.SubscriptionList{
+		Prefix: &pb.Path{Target: "ent"},
+		Mode:   pb.SubscriptionList_STREAM,
+		Subscription: []*pb.Subscription{{
+			Path: domainPath("", "dns-primary"),
+			Mode: pb.SubscriptionMode_ON_CHANGE}}}}}
+
+	subscribers := testutil.ToFloat64(gnmiSubscribers)
+	result := make(chan error)
+	go func() { result <- s.Subscribe(stream) }()
+
+	initial := stream.next(t).GetUpdate()
+	require.Len(t, initial.Update, 1)
+	assert.Equal(t, "8.8.8.4", initial.Update[0].GetVal().GetStringVal())
+	assert.True(t, stream.next(t).GetSyncResponse())
+	assert.Equal(t, subscribers+1, testutil.ToFloat64(gnmiSubscribers))
+
+	_, err := s.Set(context.Background(), &pb.SetRequest{
+		Prefix: domainPath("ent"),
+		Update: []*pb.Update{{
+			Path: &pb.Path{Elem: []*pb.PathElem{{Name: "dns-primary"}}},
+			Val:  &pb.TypedValue{Value: &pb.TypedValue_StringVal{StringVal: "8.8.8.8"}}}}})
+	require.NoError(t, err)
+	changed := stream.next(t).GetUpdate()
+	require.Len(t, changed.Update, 1)
+	assert.Equal(t, "8.8.8.8", changed.Update[0].GetVal().GetStringVal())
+
+	// Closing the stream ends the subscription
+	close(stream.requests)
+	select {
+	case err := <-result:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "timed out waiting for Subscribe to return")
+	}
+	assert.Equal(t, subscribers, testutil.ToFloat64(gnmiSubscribers))
+}
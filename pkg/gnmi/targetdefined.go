@@ -0,0 +1,318 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package gnmi implements a gnmi server to mock a device with YANG models.
+package gnmi
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	pb "github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/goyang/pkg/yang"
+	"github.com/openconfig/ygot/util"
+	"github.com/openconfig/ygot/ygot"
+	"github.com/openconfig/ygot/ytypes"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+/*
+ * For a TARGET_DEFINED subscription, the mode of each leaf is chosen by the server. Config
+ * leaves only change when they are Set, so they are streamed ON_CHANGE. State leaves are
+ * assumed to be counter-like, and are SAMPLEd, unless the override table says otherwise; the
+ * state that the synchronizer populates from events, such as a device's IP address, is
+ * streamed ON_CHANGE.
+ */
+
+// DefaultLeafModeOverrides are the modes of the leaves that are not classified by whether they
+// are config or state. Leaves are identified by their schema path, without keys.
+var DefaultLeafModeOverrides = map[string]pb.SubscriptionMode{
+	"/site/device/state/ip-address":     pb.SubscriptionMode_ON_CHANGE,
+	"/site/device/state/connected":      pb.SubscriptionMode_ON_CHANGE,
+	"/site/device/state/last-connected": pb.SubscriptionMode_ON_CHANGE,
+}
+
+// SetLeafModeOverride sets the mode of the leaf at schemaPath, for TARGET_DEFINED
+// subscriptions. It must be called before the server is serving.
+func (s *Server) SetLeafModeOverride(schemaPath string, mode pb.SubscriptionMode) error {
+	if mode != pb.SubscriptionMode_ON_CHANGE && mode != pb.SubscriptionMode_SAMPLE {
+		return fmt.Errorf("Leaf mode must be ON_CHANGE or SAMPLE, not %s", mode)
+	}
+	s.leafModes[schemaPath] = mode
+	return nil
+}
+
+// schemaPathString returns the schema path of path, which is its element names without keys
+func schemaPathString(path *pb.Path) string {
+	names := []string{}
+	for _, elem := range path.GetElem() {
+		names = append(names, elem.Name)
+	}
+	return "/" + strings.Join(names, "/")
+}
+
+// schemaEntry returns the schema of the node at path, or nil if it is not in the schema
+func schemaEntry(root *yang.Entry, path *pb.Path) *yang.Entry {
+	entry := root
+	for _, elem := range path.GetElem() {
+		if entry == nil {
+			return nil
+		}
+		entry = entry.Dir[elem.Name]
+	}
+	return entry
+}
+
+// leafMode returns the mode, ON_CHANGE or SAMPLE, of the leaf at path for TARGET_DEFINED
+// subscriptions
+func (s *Server) leafMode(path *pb.Path) pb.SubscriptionMode {
+	if mode, okay := s.leafModes[schemaPathString(path)]; okay {
+		return mode
+	}
+	if entry := schemaEntry(s.model.schemaTreeRoot, path); entry != nil && entry.ReadOnly() {
+		return pb.SubscriptionMode_SAMPLE
+	}
+	return pb.SubscriptionMode_ON_CHANGE
+}
+
+// onChangeMatcher returns a function that returns true if a changed leaf should be sent to c;
+// that is, if it is under an ON_CHANGE subscription, or is an ON_CHANGE leaf under a
+// TARGET_DEFINED subscription.
+func (s *Server) onChangeMatcher(c *streamClient) func(string, *pb.Path) bool {
+	onChange := c.getOnChange()
+	targetDefined := c.getTargetDefined()
+	return func(target string, path *pb.Path) bool {
+		if pathUnderAny(target, path, onChange) {
+			return true
+		}
+		return pathUnderAny(target, path, targetDefined) && (s.leafMode(path) == pb.SubscriptionMode_ON_CHANGE)
+	}
+}
+
+// initialMatcher returns a function that returns true if a leaf is under any of the
+// ON_CHANGE or TARGET_DEFINED subscriptions of c, whose initial values are sent on subscribing.
+func initialMatcher(c *streamClient) func(string, *pb.Path) bool {
+	paths := append(append([]*pb.Path{}, c.getOnChange()...), c.getTargetDefined()...)
+	return func(target string, path *pb.Path) bool {
+		return pathUnderAny(target, path, paths)
+	}
+}
+
+// processSubStreamTargetDefined samples the SAMPLE leaves under the TARGET_DEFINED subscription
+// path, which is a full path with target. If sub asks to suppress redundant samples, a leaf is
+// only sent when its value has changed. Every heartbeat interval, if there is one, the current
+// value of every leaf under path is sent, whatever its mode.
+func (s *Server) processSubStreamTargetDefined(c *streamClient, path *pb.Path, sub *pb.Subscription) {
+	sampleInterval := sub.GetSampleInterval()
+	if sampleInterval == 0 {
+		sampleInterval = lowestSampleInterval
+	}
+	sampleTicker := time.NewTicker(time.Duration(sampleInterval) * time.Nanosecond)
+	defer sampleTicker.Stop()
+
+	var heartbeat <-chan time.Time
+	if sub.GetHeartbeatInterval() != 0 {
+		heartbeatTicker := time.NewTicker(time.Duration(sub.GetHeartbeatInterval()) * time.Nanosecond)
+		defer heartbeatTicker.Stop()
+		heartbeat = heartbeatTicker.C
+	}
+
+	isSample := func(target string, leaf *pb.Path) bool {
+		return pathHasPrefix(target, leaf, path) && (s.leafMode(leaf) == pb.SubscriptionMode_SAMPLE)
+	}
+	isAny := func(target string, leaf *pb.Path) bool {
+		return pathHasPrefix(target, leaf, path)
+	}
+
+	// the values last sent of the SAMPLE leaves, which the initial values have already covered
+	sent := sentLeaves{}
+	sent.remember(s.collectLeaves(path, isSample), nil)
+
+	for {
+		select {
+		case <-sampleTicker.C:
+			notifications := s.collectLeaves(path, isSample)
+			for _, notification := range notifications {
+				if sub.GetSuppressRedundant() {
+					s.sendNotification(sent.redundantRemoved(notification), c)
+				} else {
+					s.sendNotification(notification, c)
+				}
+			}
+			// SAMPLE leaves that have been deleted since they were sent are reported as deleted
+			for _, notification := range sent.deleted(notifications) {
+				s.sendNotification(notification, c)
+			}
+			sent = sentLeaves{}
+			sent.remember(notifications, nil)
+		case <-heartbeat:
+			notifications := s.collectLeaves(path, isAny)
+			for _, notification := range notifications {
+				s.sendNotification(notification, c)
+			}
+			sent.remember(notifications, isSample)
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// collectLeaves returns a notification, for each target that path's target matches, of the
+// current values of the leaves that match. Only the subtree of each tree at path is walked.
+func (s *Server) collectLeaves(path *pb.Path, match func(string, *pb.Path) bool) []*pb.Notification {
+	s.config.Mu.RLock()
+	defer s.config.Mu.RUnlock()
+
+	notifications := []*pb.Notification{}
+	for _, configTarget := range sortedTargets(s.config) {
+		if (path.Target != "") && (path.Target != AllTargets) && (path.Target != configTarget) {
+			continue
+		}
+		notification, err := s.subtreeLeafNotification(configTarget, s.config.Configs[configTarget], path)
+		if err != nil {
+			log.Warnf("Failed to sample target %s: %v", configTarget, err)
+			continue
+		}
+		if response := filterNotification(notification, match); response != nil {
+			notifications = append(notifications, response.GetUpdate())
+		}
+	}
+	return notifications
+}
+
+// subtreeLeafNotification returns a notification that updates every leaf of the tree of target
+// under the containers or list entries that path names. If path names a leaf, the leaves of its
+// container are included, and a multi-level wildcard includes everything above it; the caller
+// filters the leaves by path.
+func (s *Server) subtreeLeafNotification(target string, config ygot.ValidatedGoStruct, path *pb.Path) (*pb.Notification, error) {
+	elems := []*pb.PathElem{}
+	for _, elem := range path.GetElem() {
+		if (elem.Name == "*") || (elem.Name == "...") {
+			break
+		}
+		elems = append(elems, elem)
+	}
+	if entry := schemaEntry(s.model.schemaTreeRoot, &pb.Path{Elem: elems}); (len(elems) > 0) && (entry != nil) && entry.IsLeaf() {
+		elems = elems[:len(elems)-1]
+	}
+
+	leaves := &pb.Notification{
+		Timestamp: time.Now().UnixNano(),
+		Prefix:    &pb.Path{Target: target},
+	}
+	nodes, err := ytypes.GetNode(s.model.schemaTreeRoot, config, &pb.Path{Elem: elems},
+		&ytypes.GetPartialKeyMatch{}, &ytypes.GetHandleWildcards{})
+	if status.Code(err) == codes.NotFound {
+		// nothing is there, so there are no leaves
+		return leaves, nil
+	} else if err != nil {
+		return nil, err
+	}
+	for _, node := range nodes {
+		nodeStruct, okay := node.Data.(ygot.GoStruct)
+		if !okay || util.IsValueNil(nodeStruct) {
+			continue
+		}
+		subtree, err := leafNotification(target, nodeStruct)
+		if err != nil {
+			return nil, err
+		}
+		for _, update := range subtree.Update {
+			elems := append(append([]*pb.PathElem{}, node.Path.GetElem()...), update.GetPath().GetElem()...)
+			leaves.Update = append(leaves.Update, &pb.Update{Path: &pb.Path{Elem: elems}, Val: update.Val})
+		}
+	}
+	return leaves, nil
+}
+
+// sendNotification sends notification to c, unless it is empty
+func (s *Server) sendNotification(notification *pb.Notification, c *streamClient) {
+	if len(notification.Update) == 0 && len(notification.Delete) == 0 {
+		return
+	}
+	s.sendResponse(&pb.SubscribeResponse{Response: &pb.SubscribeResponse_Update{Update: notification}}, c)
+}
+
+// sentLeaf is the value last sent of a leaf
+type sentLeaf struct {
+	target string
+	path   *pb.Path
+	val    *pb.TypedValue
+}
+
+// sentLeaves are the values last sent of the SAMPLE leaves of a subscription, by target and
+// leaf path
+type sentLeaves map[string]*sentLeaf
+
+func sentLeafKey(target string, path *pb.Path) string {
+	return target + ":" + pathKey(path.GetElem())
+}
+
+// redundantRemoved returns notification without the updates whose values were last sent
+func (sent sentLeaves) redundantRemoved(notification *pb.Notification) *pb.Notification {
+	target := notification.GetPrefix().GetTarget()
+	changed := &pb.Notification{
+		Timestamp: notification.Timestamp,
+		Prefix:    notification.Prefix,
+	}
+	for _, update := range notification.Update {
+		if last, okay := sent[sentLeafKey(target, update.GetPath())]; !okay || !proto.Equal(last.val, update.Val) {
+			changed.Update = append(changed.Update, update)
+		}
+	}
+	return changed
+}
+
+// deleted returns a notification, for each target, that deletes the leaves that were sent but
+// are not in notifications, the current values of the leaves
+func (sent sentLeaves) deleted(notifications []*pb.Notification) []*pb.Notification {
+	current := sentLeaves{}
+	current.remember(notifications, nil)
+
+	byTarget := map[string]*pb.Notification{}
+	targets := []string{}
+	for key, leaf := range sent {
+		if _, okay := current[key]; okay {
+			continue
+		}
+		notification, okay := byTarget[leaf.target]
+		if !okay {
+			notification = &pb.Notification{
+				Timestamp: time.Now().UnixNano(),
+				Prefix:    &pb.Path{Target: leaf.target},
+			}
+			byTarget[leaf.target] = notification
+			targets = append(targets, leaf.target)
+		}
+		notification.Delete = append(notification.Delete, leaf.path)
+	}
+	sort.Strings(targets)
+
+	deletes := []*pb.Notification{}
+	for _, target := range targets {
+		notification := byTarget[target]
+		sort.Slice(notification.Delete, func(i, j int) bool {
+			return pathKey(notification.Delete[i].GetElem()) < pathKey(notification.Delete[j].GetElem())
+		})
+		deletes = append(deletes, notification)
+	}
+	return deletes
+}
+
+// remember records the values of the updates of notifications that match, or of every update if
+// match is nil, as having been sent
+func (sent sentLeaves) remember(notifications []*pb.Notification, match func(string, *pb.Path) bool) {
+	for _, notification := range notifications {
+		target := notification.GetPrefix().GetTarget()
+		for _, update := range notification.Update {
+			if (match == nil) || match(target, update.GetPath()) {
+				sent[sentLeafKey(target, update.GetPath())] = &sentLeaf{target: target, path: update.GetPath(), val: update.Val}
+			}
+		}
+	}
+}
@@ -0,0 +1,209 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gnmi
+
+import (
+	"testing"
+	"time"
+
+	models "github.com/onosproject/aether-models/models/aether-2.1.x/v2/api"
+	pb "github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/ygot/ygot"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func devicePath(target string, leaf ...string) *pb.Path {
+	path := &pb.Path{Target: target, Elem: []*pb.PathElem{
+		{Name: "site", Key: map[string]string{"site-id": "acme-site"}},
+		{Name: "device", Key: map[string]string{"device-id": "dev1"}}}}
+	for _, name := range leaf {
+		path.Elem = append(path.Elem, &pb.PathElem{Name: name})
+	}
+	return path
+}
+
+// newDeviceServer returns a server whose sample config has a device with operational state.
+// The connected leaf of the device is SAMPLEd, as it is not in the override table.
+func newDeviceServer(t *testing.T) *Server {
+	s := newSampleServer(t)
+	delete(s.leafModes, "/site/device/state/connected")
+
+	s.config.Mu.Lock()
+	defer s.config.Mu.Unlock()
+	device, err := s.config.Configs["ent"].(*models.Device).Site["acme-site"].NewDevice("dev1")
+	require.NoError(t, err)
+	device.State = &models.OnfSite_Site_Device_State{
+		IpAddress: ygot.String("10.0.0.1"),
+		Connected: ygot.String("Yes"),
+	}
+	s.config.Changed("ent")
+	return s
+}
+
+// setDeviceState changes the state of the device in place, as the synchronizer does
+func setDeviceState(s *Server, ipAddress string, connected string) {
+	s.config.Mu.Lock()
+	defer s.config.Mu.Unlock()
+	state := s.config.Configs["ent"].(*models.Device).Site["acme-site"].Device["dev1"].State
+	state.IpAddress = ygot.String(ipAddress)
+	state.Connected = ygot.String(connected)
+	s.config.Changed("ent")
+}
+
+func updatedLeaves(response *pb.SubscribeResponse) map[string]string {
+	leaves := map[string]string{}
+	for _, update := range response.GetUpdate().GetUpdate() {
+		elems := update.GetPath().GetElem()
+		leaves[elems[len(elems)-1].GetName()] = update.GetVal().GetStringVal()
+	}
+	return leaves
+}
+
+func withSampleInterval(t *testing.T, interval time.Duration) {
+	lowest := lowestSampleInterval
+	lowestSampleInterval = uint64(interval.Nanoseconds())
+	t.Cleanup(func() { lowestSampleInterval = lowest })
+}
+
+func TestLeafMode(t *testing.T) {
+	s := newDeviceServer(t)
+	defer s.Close()
+
+	assert.Equal(t, pb.SubscriptionMode_ON_CHANGE, s.leafMode(domainPath("", "dns-primary")))
+	assert.Equal(t, pb.SubscriptionMode_ON_CHANGE, s.leafMode(devicePath("", "state", "ip-address")))
+	assert.Equal(t, pb.SubscriptionMode_SAMPLE, s.leafMode(devicePath("", "state", "connected")))
+
+	require.NoError(t, s.SetLeafModeOverride("/site/ip-domain/dns-primary", pb.SubscriptionMode_SAMPLE))
+	assert.Equal(t, pb.SubscriptionMode_SAMPLE, s.leafMode(domainPath("", "dns-primary")))
+	assert.EqualError(t, s.SetLeafModeOverride("/site/ip-domain/mtu", pb.SubscriptionMode_TARGET_DEFINED),
+		"Leaf mode must be ON_CHANGE or SAMPLE, not TARGET_DEFINED")
+}
+
+func TestTargetDefinedSuppressRedundant(t *testing.T) {
+	withSampleInterval(t, 20*time.Millisecond)
+	s := newDeviceServer(t)
+	defer s.Close()
+
+	stream := &fakeSubscribeServer{responses: make(chan *pb.SubscribeResponse, 10)}
+	c := s.subscriptions.newClient(stream)
+	c.addTargetDefined([]*pb.Path{devicePath("ent", "state")})
+	s.subscriptions.add(c)
+	go func() { _ = s.serveClient(c) }()
+
+	// The current values of every leaf are sent, followed by a sync
+	s.processSubStreamOnChange(c)
+	assert.Equal(t, map[string]string{"ip-address": "10.0.0.1", "connected": "Yes"}, updatedLeaves(stream.next(t)))
+	assert.True(t, stream.next(t).GetSyncResponse())
+	go s.processSubStreamTargetDefined(c, devicePath("ent", "state"), &pb.Subscription{SuppressRedundant: true})
+
+	// A change of an ON_CHANGE leaf is delivered when reported
+	setDeviceState(s, "10.0.0.2", "Yes")
+	assert.Equal(t, map[string]string{"ip-address": "10.0.0.2"}, updatedLeaves(stream.next(t)))
+
+	// A change of a SAMPLE leaf is delivered by the next sample, which suppresses the unchanged
+	setDeviceState(s, "10.0.0.2", "No")
+	assert.Equal(t, map[string]string{"connected": "No"}, updatedLeaves(stream.next(t)))
+	select {
+	case response := <-stream.responses:
+		assert.Fail(t, "unexpected response", "%v", response)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestTargetDefinedSampleDeleted(t *testing.T) {
+	withSampleInterval(t, 20*time.Millisecond)
+	s := newDeviceServer(t)
+	defer s.Close()
+
+	stream := &fakeSubscribeServer{responses: make(chan *pb.SubscribeResponse, 10)}
+	c := s.subscriptions.newClient(stream)
+	c.addTargetDefined([]*pb.Path{devicePath("ent", "state")})
+	s.subscriptions.add(c)
+	go func() { _ = s.serveClient(c) }()
+	s.processSubStreamOnChange(c)
+	stream.next(t)
+	assert.True(t, stream.next(t).GetSyncResponse())
+	go s.processSubStreamTargetDefined(c, devicePath("ent", "state"), &pb.Subscription{SuppressRedundant: true})
+
+	// Once the sampling has started, a SAMPLE leaf that is removed is reported once as deleted
+	setDeviceState(s, "10.0.0.2", "Yes")
+	assert.Equal(t, map[string]string{"ip-address": "10.0.0.2"}, updatedLeaves(stream.next(t)))
+	setDeviceState(s, "10.0.0.2", "No")
+	assert.Equal(t, map[string]string{"connected": "No"}, updatedLeaves(stream.next(t)))
+	s.config.Mu.Lock()
+	s.config.Configs["ent"].(*models.Device).Site["acme-site"].Device["dev1"].State.Connected = nil
+	s.config.Mu.Unlock()
+	deleted := stream.next(t).GetUpdate()
+	assert.Empty(t, deleted.Update)
+	require.Len(t, deleted.Delete, 1)
+	assert.Equal(t, "ent", deleted.GetPrefix().GetTarget())
+	assert.Equal(t, "connected", deleted.Delete[0].GetElem()[3].GetName())
+	select {
+	case response := <-stream.responses:
+		assert.Fail(t, "unexpected response", "%v", response)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestSubtreeLeafNotification(t *testing.T) {
+	s := newDeviceServer(t)
+	defer s.Close()
+	config := s.config.Configs["ent"]
+
+	leafNames := func(notification *pb.Notification) []string {
+		names := []string{}
+		for _, update := range notification.Update {
+			names = append(names, schemaPathString(update.GetPath()))
+		}
+		return names
+	}
+
+	// Only the subtree is walked; a leaf includes the leaves of its container
+	for _, path := range []*pb.Path{devicePath("ent", "state"), devicePath("ent", "state", "connected")} {
+		notification, err := s.subtreeLeafNotification("ent", config, path)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"/site/device/state/ip-address", "/site/device/state/connected"}, leafNames(notification))
+	}
+
+	// Wildcards are honored
+	path := &pb.Path{Elem: []*pb.PathElem{{Name: "site", Key: map[string]string{"site-id": "*"}}, {Name: "device"}, {Name: "state"}}}
+	notification, err := s.subtreeLeafNotification("ent", config, path)
+	require.NoError(t, err)
+	assert.Len(t, notification.Update, 2)
+	assert.Equal(t, "acme-site", notification.Update[0].GetPath().GetElem()[0].GetKey()["site-id"])
+
+	// A subtree that does not exist has no leaves
+	notification, err = s.subtreeLeafNotification("ent", config, &pb.Path{Elem: []*pb.PathElem{{Name: "site", Key: map[string]string{"site-id": "nowhere"}}}})
+	require.NoError(t, err)
+	assert.Empty(t, notification.Update)
+}
+
+func TestTargetDefinedHeartbeat(t *testing.T) {
+	withSampleInterval(t, 20*time.Millisecond)
+	s := newDeviceServer(t)
+	defer s.Close()
+
+	stream := &fakeSubscribeServer{responses: make(chan *pb.SubscribeResponse, 10)}
+	c := s.subscriptions.newClient(stream)
+	c.addTargetDefined([]*pb.Path{devicePath("ent", "state")})
+	s.subscriptions.add(c)
+	go func() { _ = s.serveClient(c) }()
+	go s.processSubStreamTargetDefined(c, devicePath("ent", "state"), &pb.Subscription{
+		SuppressRedundant: true,
+		SampleInterval:    uint64(time.Hour.Nanoseconds()),
+		HeartbeatInterval: uint64((50 * time.Millisecond).Nanoseconds()),
+	})
+
+	// Nothing has changed, but every leaf is sent on each heartbeat
+	assert.Equal(t, map[string]string{"ip-address": "10.0.0.1", "connected": "Yes"}, updatedLeaves(stream.next(t)))
+	assert.Equal(t, map[string]string{"ip-address": "10.0.0.1", "connected": "Yes"}, updatedLeaves(stream.next(t)))
+}
+
+func TestCheckInterval(t *testing.T) {
+	assert.NoError(t, checkInterval("heartbeat", 0))
+	assert.NoError(t, checkInterval("heartbeat", lowestSampleInterval))
+	assert.Error(t, checkInterval("heartbeat", lowestSampleInterval-1))
+}
@@ -8,7 +8,6 @@ package gnmi
 import (
 	"bytes"
 	"compress/gzip"
-	"encoding/json"
 	"flag"
 	"fmt"
 	"io"
@@ -22,7 +21,6 @@ import (
 	dpb "github.com/golang/protobuf/protoc-gen-go/descriptor"
 	"github.com/openconfig/goyang/pkg/yang"
 	"github.com/openconfig/ygot/ygot"
-	"github.com/openconfig/ygot/ytypes"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
@@ -370,192 +368,66 @@ func setPathWithoutAttribute(op pb.UpdateResult_Operation, curNode map[string]in
 	return nil
 }
 
-// sendResponse sends an SubscribeResponse to a gNMI client.
-func (s *Server) sendResponse(response *pb.SubscribeResponse, stream pb.GNMI_SubscribeServer) {
-	log.Debugf("Sending SubscribeResponse out to gNMI client: %s", response)
-	err := stream.Send(response)
-	if err != nil {
-		//TODO remove channel registrations
-		log.Errorf("Error in sending response to client %v", err)
-	}
+// sendResponse queues a SubscribeResponse to be sent to a gNMI client.
+func (s *Server) sendResponse(response *pb.SubscribeResponse, c *streamClient) {
+	c.send(response)
 }
 
-// getUpdate finds the node in the tree, build the update message and return it back to the collector
-func (s *Server) getUpdate(c *streamClient, subList *pb.SubscriptionList, path *pb.Path) (*pb.Update, error) {
+// getUpdates returns the updates for a subscription path, one for each concrete path if it
+// contains wildcards
+func (s *Server) getUpdates(subList *pb.SubscriptionList, path *pb.Path) ([]*pb.Update, error) {
+	s.config.Mu.RLock()
+	defer s.config.Mu.RUnlock()
 
-	fullPath := path
 	prefix := subList.GetPrefix()
+	config, _, err := s.configFromPath(prefix, path)
+	if err != nil {
+		return nil, err
+	}
+
+	fullPath := path
 	if prefix != nil {
 		fullPath = gnmiFullPath(prefix, path)
 	}
 	if fullPath.GetElem() == nil && fullPath.GetElement() != nil { // nolint:staticcheck
 		return nil, status.Error(codes.Unimplemented, "deprecated path element type is unsupported")
 	}
-	node, err := ytypes.GetNode(s.model.schemaTreeRoot, s.config, fullPath)
-	if isNil(node) || err != nil {
-		return nil, status.Errorf(codes.NotFound, "path %v not found", fullPath)
-
-	}
-
-	nodeStruct, ok := node[0].Data.(ygot.GoStruct)
-	// Return leaf node.
-	if !ok {
-		var val *pb.TypedValue
-		switch kind := reflect.ValueOf(node).Kind(); kind {
-		case reflect.Ptr, reflect.Interface:
-			var err error
-			val, err = value.FromScalar(reflect.ValueOf(node).Elem().Interface())
-			if err != nil {
-				msg := fmt.Sprintf("leaf node %v does not contain a scalar type value: %v", path, err)
-				log.Error(msg)
-				return nil, status.Error(codes.Internal, msg)
-			}
-		case reflect.Int64:
-			enumMap, ok := s.model.enumData[reflect.TypeOf(node).Name()]
-			if !ok {
-				return nil, status.Error(codes.Internal, "not a GoStruct enumeration type")
 
-			}
-			val = &pb.TypedValue{
-				Value: &pb.TypedValue_StringVal{
-					StringVal: enumMap[reflect.ValueOf(node).Int()].Name,
-				},
-			}
-		case reflect.Slice:
-			var err error
-			switch kind := reflect.ValueOf(node[0].Data).Kind(); kind {
-			case reflect.Int64:
-				enumMap, ok := s.model.enumData[reflect.TypeOf(node[0].Data).Name()]
-				if !ok {
-					return nil, status.Error(codes.Internal, "not a GoStruct enumeration type")
-				}
-				val = &pb.TypedValue{
-					Value: &pb.TypedValue_StringVal{
-						StringVal: enumMap[reflect.ValueOf(node[0].Data).Int()].Name,
-					},
-				}
-			default:
-				val, err = value.FromScalar(reflect.ValueOf(node[0].Data).Elem().Interface())
-				if err != nil {
-					msg := fmt.Sprintf("leaf node %v does not contain a scalar type value: %v", fullPath, err)
-					log.Error(msg)
-					return nil, status.Error(codes.Internal, msg)
-				}
-			}
-		default:
-			return nil, status.Errorf(codes.Internal, "unexpected kind of leaf node type: %v %v", node, kind)
+	if hasWildcards(fullPath) {
+		notification, err := s.getWildcardNotification(config, prefix, fullPath, pb.Encoding_JSON_IETF, pb.GetRequest_ALL, nil)
+		if err != nil {
+			return nil, err
 		}
-
-		update := &pb.Update{Path: path, Val: val}
-		return update, nil
-
+		return notification.Update, nil
 	}
 
-	// Return IETF JSON for the sub-tree.
-	jsonTree, err := ygot.ConstructIETFJSON(nodeStruct, &ygot.RFC7951JSONConfig{AppendModuleName: true})
-	if err != nil {
-		msg := fmt.Sprintf("error in constructing IETF JSON tree from requested node: %v", err)
-		log.Error(msg)
-		return nil, status.Error(codes.Internal, msg)
-	}
-	jsonDump, err := json.Marshal(jsonTree)
+	update, err := s.getNodeUpdate(config, path, fullPath, pb.Encoding_JSON_IETF, pb.GetRequest_ALL, nil)
 	if err != nil {
-		msg := fmt.Sprintf("error in marshaling IETF JSON tree to bytes: %v", err)
-		log.Error(msg)
-		return nil, status.Error(codes.Internal, msg)
+		return nil, err
 	}
-	update := &pb.Update{
-		Path: path,
-		Val: &pb.TypedValue{
-			Value: &pb.TypedValue_JsonIetfVal{
-				JsonIetfVal: jsonDump,
-			},
-		},
-	}
-
-	return update, nil
-
+	return []*pb.Update{update}, nil
 }
 
-// collector collects the latest update from the config.
 func (s *Server) collector(c *streamClient, request *pb.SubscriptionList) {
 	for _, sub := range request.Subscription {
 		path := sub.GetPath()
-		update, err := s.getUpdate(c, request, path)
+		updates, err := s.getUpdates(request, path)
 
 		if err != nil {
 			log.Warnf("Error while collecting data for subscribe once or poll: %s", err)
-			update = &pb.Update{
-				Path: path,
-			}
-			c.UpdateChan <- update
-		}
-
-		if err == nil {
-			c.UpdateChan <- update
+			s.sendResponse(buildDeleteResponse(path), c)
+		} else if len(updates) > 0 {
+			response, _ := buildSubResponse(updates...)
+			s.sendResponse(response, c)
 		}
 	}
+	s.sendResponse(buildSyncResponse(), c)
 }
 
-// listenForUpdates reads update messages from the update channel, creates a
-// subscribe response and send it to the gnmi client.
-func (s *Server) listenForUpdates(c *streamClient) {
-	for update := range c.UpdateChan {
-		if update.Val == nil {
-			deleteResponse := buildDeleteResponse(update.GetPath())
-			s.sendResponse(deleteResponse, c.stream)
-			syncResponse := buildSyncResponse()
-			s.sendResponse(syncResponse, c.stream)
-
-		} else {
-			response, _ := buildSubResponse(update)
-			s.sendResponse(response, c.stream)
-			syncResponse := buildSyncResponse()
-			s.sendResponse(syncResponse, c.stream)
-		}
-	}
-}
-
-// configEventProducer produces update events for stream subscribed.
-func (s *Server) listenToConfigEvents(request *pb.SubscriptionList) {
-	for updateInterface := range s.ConfigUpdate.Out() {
-		update := updateInterface.(*pb.Update)
-		for key, clientList := range s.subscribed {
-			if key == update.GetPath().String() {
-				for _, c := range clientList {
-					newUpdateValue, err := s.getUpdate(c, request, update.GetPath())
-
-					if err != nil {
-						deleteResponse := buildDeleteResponse(update.GetPath())
-						s.sendResponse(deleteResponse, c.stream)
-						syncResponse := buildSyncResponse()
-						s.sendResponse(syncResponse, c.stream)
-
-					} else {
-						update.Val = newUpdateValue.Val
-
-						// builds subscription response
-						response, _ := buildSubResponse(update)
-
-						s.sendResponse(response, c.stream)
-						// builds Sync response
-						syncResponse := buildSyncResponse()
-						s.sendResponse(syncResponse, c.stream)
-					}
-				}
-			}
-		}
-	}
-
-}
-
-// buildSubResponse builds a subscribeResponse based on the given Update message.
-func buildSubResponse(update *pb.Update) (*pb.SubscribeResponse, error) {
-	updateArray := make([]*pb.Update, 0)
-	updateArray = append(updateArray, update)
+func buildSubResponse(updates ...*pb.Update) (*pb.SubscribeResponse, error) {
 	notification := &pb.Notification{
 		Timestamp: time.Now().Unix(),
-		Update:    updateArray,
+		Update:    updates,
 	}
 	responseUpdate := &pb.SubscribeResponse_Update{
 		Update: notification,
@@ -720,9 +592,9 @@ func convertTypedValueToJSONValue(val *pb.TypedValue, intAsString bool) (interfa
 	return nodeVal, nil
 }
 
-// configFromPath given a prefix and a path, find the right configuration in `config` for that
-// target. If it doesn't exist, create blank one.
-func (s *Server) configFromPath(prefix *pb.Path, path *pb.Path) (ygot.ValidatedGoStruct, string, error) {
+// requestTarget returns the target of a path in a request: the path's target, else the
+// prefix's target, else the default target.
+func requestTarget(prefix *pb.Path, path *pb.Path) string {
 	target := ""
 	if defaultTarget != nil {
 		target = *defaultTarget
@@ -733,6 +605,13 @@ func (s *Server) configFromPath(prefix *pb.Path, path *pb.Path) (ygot.ValidatedG
 	if (path != nil) && (path.Target != "") {
 		target = path.Target
 	}
+	return target
+}
+
+// configFromPath given a prefix and a path, find the right configuration in `config` for that
+// target. If it doesn't exist, create blank one.
+func (s *Server) configFromPath(prefix *pb.Path, path *pb.Path) (ygot.ValidatedGoStruct, string, error) {
+	target := requestTarget(prefix, path)
 
 	if target == "" {
 		msg := fmt.Sprintf("get request with empty target is not allowed for %s", PrefixAndPathToString(prefix, path))
@@ -761,16 +640,7 @@ func (s *Server) configFromPath(prefix *pb.Path, path *pb.Path) (ygot.ValidatedG
 // from s.config, the appropriate JSON Tree is created, and added to allJSONTree. If the
 // target does not already exist, then it is added.
 func (s *Server) jsonTreeFromPath(allJSONTree map[string]map[string]interface{}, prefix *pb.Path, path *pb.Path) (map[string]interface{}, string, error) {
-	target := ""
-	if defaultTarget != nil {
-		target = *defaultTarget
-	}
-	if (prefix != nil) && (prefix.Target != "") {
-		target = prefix.Target
-	}
-	if (path != nil) && (path.Target != "") {
-		target = path.Target
-	}
+	target := requestTarget(prefix, path)
 
 	if target == "" {
 		return nil, "", status.Errorf(codes.InvalidArgument, "set request with empty target is not allowed for %s", PrefixAndPathToString(prefix, path))
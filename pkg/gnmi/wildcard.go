@@ -0,0 +1,132 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package gnmi implements a gnmi server to mock a device with YANG models.
+package gnmi
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	pb "github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/ygot/ygot"
+)
+
+/*
+ * Paths in Get and Subscribe may contain wildcards, as described by the gNMI path conventions:
+ * a key value of "*" matches every entry of a list, and an element named "..." matches any
+ * number of levels of the tree, including none. A wildcard path is expanded into the concrete
+ * paths of the nodes that it matches; where "..." could match at several depths, the shallowest
+ * match is used, as it includes the deeper ones.
+ */
+
+const (
+	// wildcardKey is a key value that matches every entry of a list
+	wildcardKey = "*"
+
+	// wildcardLevels is an element name that matches any number of levels
+	wildcardLevels = "..."
+)
+
+// hasWildcards returns true if path contains any wildcards
+func hasWildcards(path *pb.Path) bool {
+	for _, elem := range path.GetElem() {
+		if elem.Name == wildcardLevels {
+			return true
+		}
+		for _, v := range elem.Key {
+			if v == wildcardKey {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// elemMatches returns true if elem matches pattern. Keys that are absent from pattern match
+// any value.
+func elemMatches(pattern *pb.PathElem, elem *pb.PathElem) bool {
+	if pattern.Name != elem.Name {
+		return false
+	}
+	for k, v := range pattern.Key {
+		if (v != wildcardKey) && (elem.Key[k] != v) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchPathPrefix returns the length of the shortest prefix of path that matches pattern, or -1
+// if no prefix of path matches.
+func matchPathPrefix(pattern []*pb.PathElem, path []*pb.PathElem) int {
+	if len(pattern) == 0 {
+		return 0
+	}
+	if pattern[0].Name == wildcardLevels {
+		for skip := 0; skip <= len(path); skip++ {
+			if n := matchPathPrefix(pattern[1:], path[skip:]); n >= 0 {
+				return skip + n
+			}
+		}
+		return -1
+	}
+	if (len(path) == 0) || !elemMatches(pattern[0], path[0]) {
+		return -1
+	}
+	n := matchPathPrefix(pattern[1:], path[1:])
+	if n < 0 {
+		return -1
+	}
+	return n + 1
+}
+
+// pathKey returns a string that identifies the elements of a concrete path, with the keys of
+// each element in order
+func pathKey(elems []*pb.PathElem) string {
+	parts := []string{}
+	for _, elem := range elems {
+		keys := []string{}
+		for k, v := range elem.Key {
+			keys = append(keys, fmt.Sprintf("[%s=%s]", k, v))
+		}
+		sort.Strings(keys)
+		parts = append(parts, elem.Name+strings.Join(keys, ""))
+	}
+	return strings.Join(parts, "/")
+}
+
+// expandPath returns the concrete paths, in order, of the nodes of config that are matched by
+// the wildcard path
+func expandPath(config ygot.ValidatedGoStruct, path *pb.Path) ([]*pb.Path, error) {
+	leaves, err := leafNotification("", config)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := map[string]*pb.Path{}
+	for _, leaf := range leaves.Update {
+		n := matchPathPrefix(path.Elem, leaf.Path.Elem)
+		if n < 0 {
+			continue
+		}
+		elems := leaf.Path.Elem[:n]
+		key := pathKey(elems)
+		if _, okay := matches[key]; !okay {
+			matches[key] = &pb.Path{Origin: path.Origin, Target: path.Target, Elem: elems}
+		}
+	}
+
+	keys := []string{}
+	for key := range matches {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	paths := []*pb.Path{}
+	for _, key := range keys {
+		paths = append(paths, matches[key])
+	}
+	return paths, nil
+}
@@ -0,0 +1,122 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gnmi
+
+import (
+	"context"
+	"testing"
+
+	pb "github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func wildcardDomainPath(target string, leaf ...string) *pb.Path {
+	path := &pb.Path{Target: target, Elem: []*pb.PathElem{
+		{Name: "site", Key: map[string]string{"site-id": wildcardKey}},
+		{Name: "ip-domain", Key: map[string]string{"ip-domain-id": wildcardKey}}}}
+	for _, name := range leaf {
+		path.Elem = append(path.Elem, &pb.PathElem{Name: name})
+	}
+	return path
+}
+
+// newWildcardServer returns a server whose sample config has a second ip-domain
+func newWildcardServer(t *testing.T) *Server {
+	s := newSampleServer(t)
+	second := domainPath("ent")
+	second.Elem[1].Key["ip-domain-id"] = "acme-denver-ip"
+	_, err := s.Set(context.Background(), &pb.SetRequest{
+		Prefix: second,
+		Update: []*pb.Update{{
+			Path: &pb.Path{Elem: []*pb.PathElem{{Name: "subnet"}}},
+			Val:  &pb.TypedValue{Value: &pb.TypedValue_StringVal{StringVal: "163.25.45.0/31"}}}}})
+	require.NoError(t, err)
+	return s
+}
+
+func TestMatchPathPrefix(t *testing.T) {
+	leaf := domainPath("", "dns-primary").Elem
+	assert.Equal(t, 3, matchPathPrefix(wildcardDomainPath("", "dns-primary").Elem, leaf))
+	assert.Equal(t, 2, matchPathPrefix(wildcardDomainPath("").Elem, leaf))
+	assert.Equal(t, 3, matchPathPrefix([]*pb.PathElem{{Name: wildcardLevels}, {Name: "dns-primary"}}, leaf))
+	assert.Equal(t, 1, matchPathPrefix([]*pb.PathElem{{Name: wildcardLevels}, {Name: "site"}}, leaf))
+	assert.Equal(t, 0, matchPathPrefix([]*pb.PathElem{{Name: wildcardLevels}}, leaf))
+	assert.Equal(t, -1, matchPathPrefix([]*pb.PathElem{{Name: wildcardLevels}, {Name: "subnet"}}, leaf))
+	assert.Equal(t, -1, matchPathPrefix(wildcardDomainPath("", "dns-primary", "extra").Elem, leaf))
+	assert.Equal(t, -1, matchPathPrefix([]*pb.PathElem{
+		{Name: "site", Key: map[string]string{"site-id": "other-site"}}}, leaf))
+}
+
+func TestExpandPath(t *testing.T) {
+	s := newWildcardServer(t)
+	defer s.Close()
+
+	paths, err := expandPath(s.config.Configs["ent"], wildcardDomainPath("ent", "subnet"))
+	require.NoError(t, err)
+	require.Len(t, paths, 2)
+	assert.Equal(t, "acme-chicago-ip", paths[0].Elem[1].Key["ip-domain-id"])
+	assert.Equal(t, "acme-denver-ip", paths[1].Elem[1].Key["ip-domain-id"])
+	assert.Equal(t, "ent", paths[0].Target)
+
+	paths, err = expandPath(s.config.Configs["ent"], &pb.Path{Elem: []*pb.PathElem{{Name: wildcardLevels}, {Name: "dns-primary"}}})
+	require.NoError(t, err)
+	require.Len(t, paths, 1)
+	assert.Equal(t, domainPath("", "dns-primary").String(), paths[0].String())
+
+	paths, err = expandPath(s.config.Configs["ent"], wildcardDomainPath("ent", "no-such-leaf"))
+	require.NoError(t, err)
+	assert.Empty(t, paths)
+}
+
+func TestGetWildcard(t *testing.T) {
+	s := newWildcardServer(t)
+	defer s.Close()
+
+	resp, err := s.Get(&pb.GetRequest{
+		Prefix:   &pb.Path{Target: "ent"},
+		Path:     []*pb.Path{wildcardDomainPath("", "subnet")},
+		Encoding: pb.Encoding_JSON_IETF,
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.Notification, 1)
+	updates := resp.Notification[0].Update
+	require.Len(t, updates, 2)
+	assert.Equal(t, "acme-chicago-ip", updates[0].Path.Elem[1].Key["ip-domain-id"])
+	assert.Equal(t, "163.25.44.0/31", updates[0].Val.GetStringVal())
+	assert.Equal(t, "acme-denver-ip", updates[1].Path.Elem[1].Key["ip-domain-id"])
+	assert.Equal(t, "163.25.45.0/31", updates[1].Val.GetStringVal())
+
+	// A wildcard that matches nothing gives an empty notification
+	resp, err = s.Get(&pb.GetRequest{
+		Prefix:   &pb.Path{Target: "ent"},
+		Path:     []*pb.Path{{Elem: []*pb.PathElem{{Name: wildcardLevels}, {Name: "no-such-leaf"}}}},
+		Encoding: pb.Encoding_JSON_IETF,
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.Notification, 1)
+	assert.Empty(t, resp.Notification[0].Update)
+}
+
+func TestSubscribeOnceWildcard(t *testing.T) {
+	s := newWildcardServer(t)
+	defer s.Close()
+
+	stream := &fakeSubscribeServer{responses: make(chan *pb.SubscribeResponse, 10)}
+	c := s.subscriptions.newClient(stream)
+	go func() { _ = s.serveClient(c) }()
+	s.collector(c, &pb.SubscriptionList{
+		Prefix: &pb.Path{Target: "ent"},
+		Mode:   pb.SubscriptionList_ONCE,
+		Subscription: []*pb.Subscription{
+			{Path: wildcardDomainPath("", "subnet")},
+			{Path: domainPath("", "dns-primary")}}})
+
+	assert.Len(t, stream.next(t).GetUpdate().GetUpdate(), 2)
+	dns := stream.next(t).GetUpdate().GetUpdate()
+	require.Len(t, dns, 1)
+	assert.Equal(t, "8.8.8.4", dns[0].GetVal().GetStringVal())
+	assert.True(t, stream.next(t).GetSyncResponse())
+}
@@ -11,6 +11,7 @@ package gnmiclient
 import (
 	"context"
 	"crypto/tls"
+	"encoding/json"
 	"fmt"
 	"github.com/openconfig/gnmi/client"
 	gclient "github.com/openconfig/gnmi/client/gnmi"
@@ -71,3 +72,32 @@ func GetPath(ctx context.Context, path string, target string, addr string) (*gpb
 
 	return resp.Notification[0].Update[0].Val, nil
 }
+
+// GetTargets returns the targets known to the server named by addr, by getting the root
+// of the wildcard target "*", which onos-config answers with the list of its targets.
+func GetTargets(ctx context.Context, addr string) ([]string, error) {
+	val, err := GetPath(ctx, "", "*", addr)
+	if err != nil {
+		return nil, err
+	}
+	if val == nil {
+		return []string{}, nil
+	}
+
+	targets := []string{}
+	if leafList := val.GetLeaflistVal(); leafList != nil {
+		for _, elem := range leafList.Element {
+			targets = append(targets, elem.GetStringVal())
+		}
+		return targets, nil
+	}
+
+	data := val.GetJsonVal()
+	if data == nil {
+		data = val.GetJsonIetfVal()
+	}
+	if err := json.Unmarshal(data, &targets); err != nil {
+		return nil, fmt.Errorf("unexpected list of targets %v: %v", val, err)
+	}
+	return targets, nil
+}
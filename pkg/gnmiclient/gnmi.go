@@ -18,8 +18,6 @@ import (
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/metadata"
-	"os"
-	"strings"
 	"time"
 )
 
@@ -93,8 +91,8 @@ func NewGnmiWithInterceptor(addr string, timeout time.Duration) (GnmiInterface,
 
 	ctx := context.Background()
 	token := ""
-	openIDIssuer := os.Getenv("OIDC_SERVER_URL")
-	if len(strings.TrimSpace(openIDIssuer)) > 0 {
+	openIDIssuer := OpenIDIssuer()
+	if len(openIDIssuer) > 0 {
 		token, err = GetAccessToken(openIDIssuer, secretname)
 		if err != nil {
 			return nil, token, err
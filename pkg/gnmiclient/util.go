@@ -237,6 +237,12 @@ func fetchATokenViaKeyCloak(openIDIssuer string, user string, passwd string) (st
 
 }
 
+// OpenIDIssuer returns the OIDC server that issues and validates bearer tokens, named by
+// the OIDC_SERVER_URL environment variable, or "" if there is none
+func OpenIDIssuer() string {
+	return strings.TrimSpace(os.Getenv("OIDC_SERVER_URL"))
+}
+
 // getNamespace Get the current namespace
 func getNamespace() string {
 	if ns, ok := os.LookupEnv("POD_NAMESPACE"); ok {
@@ -0,0 +1,182 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package replay replays a journal of gNMI Set requests through the synchronizer, recording
+// the southbound pushes that result
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/onosproject/onos-lib-go/pkg/logging"
+	"github.com/onosproject/sdcore-adapter/pkg/gnmi"
+	"github.com/onosproject/sdcore-adapter/pkg/synchronizer"
+	pb "github.com/openconfig/gnmi/proto/gnmi"
+	"google.golang.org/protobuf/proto"
+)
+
+/*
+ * The journal is replayed into an in-process gNMI server, with a fresh synchronizer whose
+ * pusher records each push rather than sending it. Each Set is synchronized to completion
+ * before the next is applied, so unlike the adapter, where a newer update may obsolete one
+ * that has not yet been pushed, every Set produces its pushes. The push cache behaves as it
+ * does in an adapter that started with the first entry of the journal.
+ */
+
+var log = logging.GetLogger("replay")
+
+// Push operations
+const (
+	OperationUpdate = "POST"
+	OperationDelete = "DELETE"
+)
+
+// Push is a push that the synchronizer made
+type Push struct {
+	Operation string          `json:"operation"`
+	Endpoint  string          `json:"endpoint"`
+	Data      json.RawMessage `json:"data,omitempty"`
+}
+
+// RecordingPusher is a pusher that records pushes instead of sending them
+type RecordingPusher struct {
+	mu     sync.Mutex
+	pushes []Push
+}
+
+// PushUpdate records an update
+func (p *RecordingPusher) PushUpdate(endpoint string, data []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	push := Push{Operation: OperationUpdate, Endpoint: endpoint}
+	if json.Valid(data) {
+		push.Data = append(json.RawMessage{}, data...)
+	} else {
+		// not expected, but keep the data in a form that can be reported
+		push.Data, _ = json.Marshal(string(data))
+	}
+	p.pushes = append(p.pushes, push)
+	return nil
+}
+
+// PushDelete records a delete
+func (p *RecordingPusher) PushDelete(endpoint string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.pushes = append(p.pushes, Push{Operation: OperationDelete, Endpoint: endpoint})
+	return nil
+}
+
+// Take returns the pushes recorded since the last call
+func (p *RecordingPusher) Take() []Push {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	pushes := p.pushes
+	p.pushes = nil
+	if pushes == nil {
+		pushes = []Push{}
+	}
+	return pushes
+}
+
+// Step is the outcome of replaying one journal entry
+type Step struct {
+	Entry *gnmi.JournalEntry `json:"entry"`
+	// Error is the error of the replayed Set, which is expected to match the entry's
+	Error  string `json:"error,omitempty"`
+	Pushes []Push `json:"pushes"`
+	// SyncErrors are errors from synchronizing, which the adapter logs but does not return
+	SyncErrors []string `json:"sync-errors,omitempty"`
+}
+
+// Replayer replays journal entries
+type Replayer struct {
+	server     *gnmi.Server
+	sync       *synchronizer.Synchronizer
+	pusher     *RecordingPusher
+	syncErrors []string
+}
+
+// NewReplayer creates a replayer. opts configure the synchronizer as the adapter that recorded
+// the journal was configured; pushing is always enabled, and the pusher is always recording.
+func NewReplayer(opts ...synchronizer.SynchronizerOption) (*Replayer, error) {
+	r := &Replayer{pusher: &RecordingPusher{}}
+	opts = append(opts,
+		synchronizer.WithPostEnable(true),
+		synchronizer.WithPusher(r.pusher))
+	r.sync = synchronizer.NewSynchronizer(opts...)
+
+	server, err := gnmi.NewServer(r.sync.GetModels(), r.callback)
+	if err != nil {
+		return nil, err
+	}
+	r.server = server
+	return r, nil
+}
+
+// callback synchronizes each change as it is made. As in the adapter, only the targets the
+// change is scoped to are synchronized, and synchronization errors are not returned to the
+// gNMI server.
+func (r *Replayer) callback(ctx context.Context, config *gnmi.ConfigForest, callbackType gnmi.ConfigCallbackType, target string, path *pb.Path, scope []*pb.Path) error {
+	var err error
+	switch callbackType {
+	case gnmi.Deleted:
+		err = r.sync.HandleDelete(ctx, config, path)
+	default:
+		var filters []synchronizer.ResyncFilter
+		if filters, err = synchronizer.ResyncFiltersFromScope(scope); err == nil {
+			_, err = r.sync.SynchronizeDevice(ctx, config, filters...)
+		}
+	}
+	if err != nil {
+		log.Warnf("Error during synchronize: %v", err)
+		r.syncErrors = append(r.syncErrors, err.Error())
+	}
+	return nil
+}
+
+// Apply replays a single journal entry
+func (r *Replayer) Apply(ctx context.Context, entry *gnmi.JournalEntry) *Step {
+	req := entry.Request
+	if (len(entry.Targets) == 1) && (req.GetPrefix().GetTarget() == "") {
+		// the request may have relied on the adapter's default target
+		req = proto.Clone(req).(*pb.SetRequest)
+		if req.Prefix == nil {
+			req.Prefix = &pb.Path{}
+		}
+		req.Prefix.Target = entry.Targets[0]
+	}
+	if len(req.GetExtension()) > 0 {
+		// a commit that was not confirmed in time was rolled back by a Set of its own, which is
+		// in the journal, so confirmed commits are replayed as plain Sets
+		if req == entry.Request {
+			req = proto.Clone(req).(*pb.SetRequest)
+		}
+		req.Extension = nil
+	}
+
+	step := &Step{Entry: entry}
+	r.syncErrors = nil
+	if _, err := r.server.Set(ctx, req); err != nil {
+		step.Error = err.Error()
+	}
+	step.Pushes = r.pusher.Take()
+	step.SyncErrors = r.syncErrors
+	return step
+}
+
+// Replay replays a journal, returning the outcome of each entry
+func Replay(ctx context.Context, entries []*gnmi.JournalEntry, opts ...synchronizer.SynchronizerOption) ([]*Step, error) {
+	r, err := NewReplayer(opts...)
+	if err != nil {
+		return nil, err
+	}
+	steps := []*Step{}
+	for _, entry := range entries {
+		steps = append(steps, r.Apply(ctx, entry))
+	}
+	return steps, nil
+}
@@ -0,0 +1,165 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package replay
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/onosproject/sdcore-adapter/pkg/gnmi"
+	"github.com/onosproject/sdcore-adapter/pkg/synchronizer"
+	pb "github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func dgPath(leaf ...string) *pb.Path {
+	path := &pb.Path{Elem: []*pb.PathElem{
+		{Name: "site", Key: map[string]string{"site-id": "sample-site"}},
+		{Name: "device-group", Key: map[string]string{"device-group-id": "sample-dg"}}}}
+	for _, name := range leaf {
+		path.Elem = append(path.Elem, &pb.PathElem{Name: name})
+	}
+	return path
+}
+
+// recordJournal records a journal of Sets made to a server that does not synchronize
+func recordJournal(t *testing.T, fn string) {
+	sampleEnt, err := os.ReadFile("testdata/sample-ent.json")
+	require.NoError(t, err)
+
+	journal, err := gnmi.OpenJournal(fn)
+	require.NoError(t, err)
+	s, err := gnmi.NewServer(synchronizer.NewSynchronizer().GetModels(), nil)
+	require.NoError(t, err)
+	s.SetJournal(journal)
+	defer s.Close()
+
+	requests := []*pb.SetRequest{
+		{Replace: []*pb.Update{{
+			Path: &pb.Path{Target: "sample-ent"},
+			Val:  &pb.TypedValue{Value: &pb.TypedValue_JsonIetfVal{JsonIetfVal: sampleEnt}},
+		}}},
+		{Prefix: &pb.Path{Target: "sample-ent"}, Update: []*pb.Update{{
+			Path: dgPath("mbr", "uplink"),
+			Val:  &pb.TypedValue{Value: &pb.TypedValue_UintVal{UintVal: 9999}},
+		}}},
+		{Prefix: &pb.Path{Target: "sample-ent"}, Update: []*pb.Update{{
+			Path: dgPath("display-name"),
+			Val:  &pb.TypedValue{Value: &pb.TypedValue_StringVal{StringVal: "renamed"}},
+		}}},
+	}
+	for _, req := range requests {
+		_, err := s.Set(context.Background(), req)
+		require.NoError(t, err)
+	}
+}
+
+func TestReplay(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "journal.jsonl")
+	recordJournal(t, fn)
+
+	entries, err := gnmi.ReadJournal(fn)
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+
+	steps, err := Replay(context.Background(), entries)
+	require.NoError(t, err)
+	require.Len(t, steps, 3)
+	for _, step := range steps {
+		assert.Empty(t, step.Error)
+		assert.Empty(t, step.SyncErrors)
+	}
+
+	// The initial configuration pushes the device-group, the slice, and the slice to the UPF
+	endpoints := []string{}
+	for _, push := range steps[0].Pushes {
+		assert.Equal(t, OperationUpdate, push.Operation)
+		endpoints = append(endpoints, push.Endpoint)
+	}
+	assert.ElementsMatch(t, []string{
+		"http://5gcore/v1/device-group/sample-dg",
+		"http://5gcore/v1/network-slice/sample-slice",
+		"http://upf/v1/config/network-slices"}, endpoints)
+
+	// Changing the bitrate only re-pushes the device-group
+	require.Len(t, steps[1].Pushes, 1)
+	assert.Equal(t, "http://5gcore/v1/device-group/sample-dg", steps[1].Pushes[0].Endpoint)
+	assert.Contains(t, string(steps[1].Pushes[0].Data), "9999")
+
+	// The display name is not pushed, so nothing changes southbound
+	assert.Empty(t, steps[2].Pushes)
+
+	// Replaying again produces the same pushes
+	again, err := Replay(context.Background(), entries)
+	require.NoError(t, err)
+	for i := range steps {
+		assert.Equal(t, steps[i].Pushes, again[i].Pushes)
+	}
+}
+
+func TestReplayChangedTargets(t *testing.T) {
+	sampleEnt, err := os.ReadFile("testdata/sample-ent.json")
+	require.NoError(t, err)
+	replace := func(target string) *pb.Update {
+		return &pb.Update{
+			Path: &pb.Path{Target: target},
+			Val:  &pb.TypedValue{Value: &pb.TypedValue_JsonIetfVal{JsonIetfVal: sampleEnt}},
+		}
+	}
+	entries := []*gnmi.JournalEntry{
+		{Targets: []string{"ent", "other"}, Request: &pb.SetRequest{
+			Replace: []*pb.Update{replace("ent"), replace("other")}}},
+		{Targets: []string{"other"}, Request: &pb.SetRequest{
+			Prefix: &pb.Path{Target: "other"},
+			Update: []*pb.Update{{
+				Path: dgPath("mbr", "uplink"),
+				Val:  &pb.TypedValue{Value: &pb.TypedValue_UintVal{UintVal: 9999}},
+			}}}},
+	}
+
+	r, err := NewReplayer()
+	require.NoError(t, err)
+	synchronized := func() []string {
+		enterprises := []string{}
+		for entID := range r.sync.GetStatus().LastTargetedSync.Enterprises {
+			enterprises = append(enterprises, entID)
+		}
+		return enterprises
+	}
+
+	step := r.Apply(context.Background(), entries[0])
+	assert.Empty(t, step.Error)
+	assert.Empty(t, step.SyncErrors)
+	assert.ElementsMatch(t, []string{"ent", "other"}, synchronized())
+
+	// A Set of one target only synchronizes that target
+	step = r.Apply(context.Background(), entries[1])
+	assert.Empty(t, step.Error)
+	assert.Empty(t, step.SyncErrors)
+	assert.Equal(t, []string{"other"}, synchronized())
+	require.Len(t, step.Pushes, 1)
+	assert.Equal(t, "http://5gcore/v1/device-group/sample-dg", step.Pushes[0].Endpoint)
+}
+
+func TestReplayDefaultTarget(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "journal.jsonl")
+	recordJournal(t, fn)
+	entries, err := gnmi.ReadJournal(fn)
+	require.NoError(t, err)
+
+	// A request that relied on the adapter's default target is replayed against the target
+	// recorded in the journal
+	entries[1].Request.Prefix.Target = ""
+	r, err := NewReplayer()
+	require.NoError(t, err)
+	r.Apply(context.Background(), entries[0])
+	step := r.Apply(context.Background(), entries[1])
+	assert.Empty(t, step.Error)
+	assert.Len(t, step.Pushes, 1)
+	assert.Equal(t, "", entries[1].Request.Prefix.Target)
+}
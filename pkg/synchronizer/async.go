@@ -6,7 +6,10 @@
 package synchronizer
 
 import (
+	"context"
+
 	"github.com/onosproject/sdcore-adapter/pkg/gnmi"
+	"github.com/onosproject/sdcore-adapter/pkg/tracing"
 	"github.com/openconfig/ygot/ygot"
 	"sync/atomic"
 )
@@ -20,49 +23,75 @@ import (
  * fully obsoleted by newer updates)
  */
 
-// Drain the synchronizer of any queued updates
-func (s *Synchronizer) drain() {
+// Drain the synchronizer of any queued updates, returning the filters that cover the
+// resources of the drained updates. Returns an empty set if nothing was drained.
+func (s *Synchronizer) drain() resyncFilters {
+	drained := resyncFilters{}
 L:
 	for {
 		select {
-		case <-s.updateChannel:
+		case update := <-s.updateChannel:
 			log.Infof("Drained a pending synchronization request")
+			drained = mergeResyncFilters(drained, update.filters)
 			atomic.AddInt32(&s.busy, -1)
 		default:
 			break L
 		}
 	}
+	return drained
 }
 
-// Queue an update request for future processing
-func (s *Synchronizer) enqueue(config *gnmi.ConfigForest, callbackType gnmi.ConfigCallbackType, target string) error {
+// copyConfig returns a deep copy of config
+func copyConfig(config *gnmi.ConfigForest) (*gnmi.ConfigForest, error) {
 	configCopy := gnmi.NewConfigForest()
 
 	for target, targetConfig := range config.Configs {
-		// Make a copy of the gostruct; we don't want it to change out from under us
-		// if the gnmi server is updating it.
 		targetConfigCopy, err := ygot.DeepCopy(targetConfig)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
+		// This conversion is safe as DeepCopy will use the same underlying type as
+		// `config`, which is a ValidatedGoStruct.
 		configCopy.Configs[target] = targetConfigCopy.(ygot.ValidatedGoStruct)
 	}
+	return configCopy, nil
+}
+
+// Queue an update request for future processing
+func (s *Synchronizer) enqueue(ctx context.Context, config *gnmi.ConfigForest, callbackType gnmi.ConfigCallbackType, target string, filters resyncFilters) (err error) {
+	_, span := tracing.StartSpan(ctx, "synchronizer.enqueue")
+	defer func() { tracing.EndSpan(span, err) }()
+
+	// Make a copy; we don't want it to change out from under us if the gnmi server is
+	// updating it.
+	configCopy, err := copyConfig(config)
+	if err != nil {
+		return err
+	}
+	if s.electionEnable {
+		s.stopMu.Lock()
+		s.latestConfig = configCopy
+		s.stopMu.Unlock()
+	}
 
-	// This conversion is safe as DeepCopy will use the same underlying type as
-	// `config`, which is a ValidatedGoStruct.
 	update := ConfigUpdate{
 		config:       configCopy,
 		callbackType: callbackType,
 		target:       target,
+		// The update is serviced after the request has returned, so keep the trace but
+		// not the request's cancellation.
+		ctx:     tracing.Detach(ctx),
+		filters: filters,
 	}
 
 	// Increment our busy count
 	atomic.AddInt32(&s.busy, 1)
 
 	// We don't care about any pending synchronizations; throw away any old ones
-	// and queue the latest one.
-	s.drain()
+	// and queue the latest one. The latest one must still cover the resources of any
+	// targeted resync that was thrown away.
+	update.filters = mergeResyncFilters(update.filters, s.drain())
 	s.updateChannel <- &update
 
 	return nil
@@ -7,8 +7,10 @@
 package synchronizer
 
 import (
+	"encoding/json"
 	"fmt"
 	"reflect"
+	"sort"
 )
 
 const (
@@ -22,10 +24,40 @@ const (
 	CacheModelDeviceGroup = "devicegroup"
 )
 
+// CacheModels lists the model names that may be used in the cache
+var CacheModels = []string{CacheModelSliceUpf, CacheModelSlice, CacheModelDeviceGroup}
+
+// CacheEntry is an entry in the cache, with the contents as they were pushed
+type CacheEntry struct {
+	Model    string          `json:"model"`
+	ID       string          `json:"id"`
+	Contents json.RawMessage `json:"contents"`
+}
+
+// IsCacheModel returns true if modelName is one of the CacheModels
+func IsCacheModel(modelName string) bool {
+	for _, name := range CacheModels {
+		if name == modelName {
+			return true
+		}
+	}
+	return false
+}
+
+// cacheKey is the key of an entry in the cache. The model and id are kept apart, as an id may
+// contain anything, including a dash followed by the name of another model.
+type cacheKey struct {
+	model string
+	id    string
+}
+
 // CacheCheck returns true if (modelName, modelId) exists in the cache and the contents have not
 // changed.
 func (s *Synchronizer) CacheCheck(modelName string, modelID string, contents interface{}) bool {
-	key := fmt.Sprintf("%s-%s", modelName, modelID)
+	s.cacheMu.Lock()
+	defer s.cacheMu.Unlock()
+
+	key := cacheKey{model: modelName, id: modelID}
 	entry, okay := s.cache[key]
 	if !okay {
 		return false
@@ -36,19 +68,74 @@ func (s *Synchronizer) CacheCheck(modelName string, modelID string, contents int
 
 // CacheUpdate updates the contents of (modelName, modelID) in the cache with new contents
 func (s *Synchronizer) CacheUpdate(modelName string, modelID string, contents interface{}) {
-	key := fmt.Sprintf("%s-%s", modelName, modelID)
+	s.cacheMu.Lock()
+	defer s.cacheMu.Unlock()
+
+	key := cacheKey{model: modelName, id: modelID}
 	s.cache[key] = contents
 }
 
 // CacheInvalidate removes all entries in the cache
 func (s *Synchronizer) CacheInvalidate() {
-	s.cache = map[string]interface{}{}
+	s.cacheMu.Lock()
+	defer s.cacheMu.Unlock()
+
+	s.cache = map[cacheKey]interface{}{}
 }
 
-// CacheDelete removes a single entry from the cache
-func (s *Synchronizer) CacheDelete(modelName string, modelID string) {
-	key := fmt.Sprintf("%s-%s", modelName, modelID)
+// CacheDelete removes a single entry from the cache. Returns true if the entry existed.
+func (s *Synchronizer) CacheDelete(modelName string, modelID string) bool {
+	s.cacheMu.Lock()
+	defer s.cacheMu.Unlock()
+
+	key := cacheKey{model: modelName, id: modelID}
+	_, okay := s.cache[key]
 
 	// delete does not crash if the key does not exist
 	delete(s.cache, key)
+	return okay
+}
+
+// CacheDeleteModel removes all entries for modelName from the cache, and returns the number
+// of entries removed.
+func (s *Synchronizer) CacheDeleteModel(modelName string) int {
+	s.cacheMu.Lock()
+	defer s.cacheMu.Unlock()
+
+	count := 0
+	for key := range s.cache {
+		if key.model == modelName {
+			delete(s.cache, key)
+			count++
+		}
+	}
+	return count
+}
+
+// CacheList returns the entries in the cache, sorted by model and id. If modelName is not
+// empty, only entries for that model are returned.
+func (s *Synchronizer) CacheList(modelName string) ([]CacheEntry, error) {
+	s.cacheMu.Lock()
+	defer s.cacheMu.Unlock()
+
+	entries := []CacheEntry{}
+	for key, contents := range s.cache {
+		if (modelName != "") && (key.model != modelName) {
+			continue
+		}
+		data, err := json.Marshal(contents)
+		if err != nil {
+			return nil, fmt.Errorf("Cache entry %s %s failed to Marshal Json: %s", key.model, key.id, err)
+		}
+		entries = append(entries, CacheEntry{Model: key.model, ID: key.id, Contents: data})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Model != entries[j].Model {
+			return entries[i].Model < entries[j].Model
+		}
+		return entries[i].ID < entries[j].ID
+	})
+
+	return entries, nil
 }
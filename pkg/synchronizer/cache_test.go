@@ -0,0 +1,66 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package synchronizer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCacheAmbiguousID(t *testing.T) {
+	// An id that starts with another model's name is not confused with that model
+	s := NewSynchronizer()
+	s.CacheUpdate(CacheModelSlice, "upf-x", map[string]string{"name": "upf-x"})
+	s.CacheUpdate(CacheModelSliceUpf, "x", map[string]string{"name": "x"})
+
+	entries, err := s.CacheList(CacheModelSlice)
+	assert.Nil(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "upf-x", entries[0].ID)
+
+	assert.Equal(t, 1, s.CacheDeleteModel(CacheModelSliceUpf))
+	assert.True(t, s.CacheCheck(CacheModelSlice, "upf-x", map[string]string{"name": "upf-x"}))
+	assert.True(t, s.CacheDelete(CacheModelSlice, "upf-x"))
+	assert.False(t, s.CacheDelete(CacheModelSliceUpf, "x"))
+}
+
+func TestCacheListAndDelete(t *testing.T) {
+	s := NewSynchronizer()
+	s.CacheUpdate(CacheModelSlice, "slice-b", &coreSlice{SiteInfo: siteInfo{SiteName: "slice-b"}})
+	s.CacheUpdate(CacheModelSlice, "slice-a", &coreSlice{SiteInfo: siteInfo{SiteName: "slice-a"}})
+	s.CacheUpdate(CacheModelSliceUpf, "slice-a", map[string]string{"name": "slice-a"})
+	s.CacheUpdate(CacheModelDeviceGroup, "dg-a", &deviceGroup{SiteInfo: "sample-site"})
+
+	entries, err := s.CacheList("")
+	assert.Nil(t, err)
+	require.Len(t, entries, 4)
+	assert.Equal(t, CacheModelDeviceGroup, entries[0].Model)
+	assert.Equal(t, "dg-a", entries[0].ID)
+	assert.Equal(t, CacheModelSlice, entries[1].Model)
+	assert.Equal(t, "slice-a", entries[1].ID)
+	assert.Equal(t, "slice-b", entries[2].ID)
+	assert.Equal(t, CacheModelSliceUpf, entries[3].Model)
+	assert.JSONEq(t, `{"name": "slice-a"}`, string(entries[3].Contents))
+
+	entries, err = s.CacheList(CacheModelSlice)
+	assert.Nil(t, err)
+	assert.Len(t, entries, 2)
+
+	assert.True(t, s.CacheDelete(CacheModelSlice, "slice-a"))
+	assert.False(t, s.CacheDelete(CacheModelSlice, "slice-a"))
+	assert.False(t, s.CacheCheck(CacheModelSlice, "slice-a", &coreSlice{SiteInfo: siteInfo{SiteName: "slice-a"}}))
+	assert.True(t, s.CacheCheck(CacheModelSliceUpf, "slice-a", map[string]string{"name": "slice-a"}))
+
+	assert.Equal(t, 1, s.CacheDeleteModel(CacheModelSlice))
+	assert.Equal(t, 0, s.CacheDeleteModel(CacheModelSlice))
+	entries, err = s.CacheList("")
+	assert.Nil(t, err)
+	assert.Len(t, entries, 2)
+
+	assert.True(t, IsCacheModel(CacheModelDeviceGroup))
+	assert.False(t, IsCacheModel("bogus"))
+}
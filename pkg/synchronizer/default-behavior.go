@@ -0,0 +1,228 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package synchronizer implements a synchronizer for converting sdcore gnmi to json
+package synchronizer
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"os"
+	"sort"
+
+	"gopkg.in/yaml.v2"
+)
+
+// The default behavior policy is based on yaml configuration. The yaml field names are
+// implied and do not need to be specified if they are merely the lowercased versions of
+// the Golang field names. A sample policy file:
+//
+//	behaviors:
+//	  ALLOW-PUBLIC:
+//	    - name: DENY-CLASS-A
+//	      cidr: 10.0.0.0/8
+//	      action: deny
+//	      priority: 250
+//	      traffic-class: {qci: 9, arp: 6}
+//	    - name: ALLOW-ALL
+//	      cidr: 0.0.0.0/0
+//	      action: permit
+//	      priority: 253
+//	      traffic-class: {qci: 9, arp: 6}
+
+// DefaultBehaviorTrafficClass is the traffic class attached to a default behavior rule
+type DefaultBehaviorTrafficClass struct {
+	Name string
+	QCI  uint8
+	ARP  uint8
+	PDB  uint16
+	PELR uint8
+}
+
+// DefaultBehaviorRule is a single filter rule within a default behavior
+type DefaultBehaviorRule struct {
+	Name         string
+	CIDR         string
+	Action       string
+	Priority     uint8
+	TrafficClass DefaultBehaviorTrafficClass `yaml:"traffic-class"`
+}
+
+// DefaultBehaviorPolicy maps the name of a slice default behavior to the ordered list of
+// rules that implement it.
+type DefaultBehaviorPolicy struct {
+	Behaviors map[string][]DefaultBehaviorRule
+}
+
+// maxApplicationPriority is the largest priority of an application filter rule in the model.
+// Default behavior rules follow the application rules, so their priorities must be larger.
+const maxApplicationPriority = 200
+
+// builtinTrafficClass is the traffic class used by the built-in default behaviors
+var builtinTrafficClass = DefaultBehaviorTrafficClass{ARP: 6, QCI: 9}
+
+// NewDefaultBehaviorPolicy returns a policy containing the built-in ALLOW-ALL, DENY-ALL,
+// and ALLOW-PUBLIC behaviors.
+func NewDefaultBehaviorPolicy() *DefaultBehaviorPolicy {
+	return &DefaultBehaviorPolicy{
+		Behaviors: map[string][]DefaultBehaviorRule{
+			"ALLOW-ALL": {
+				{Name: "ALLOW-ALL", CIDR: "0.0.0.0/0", Action: "permit", Priority: 250, TrafficClass: builtinTrafficClass},
+				{Name: "ALLOW-ALL-V6", CIDR: "::/0", Action: "permit", Priority: 250, TrafficClass: builtinTrafficClass},
+			},
+			"DENY-ALL": {
+				{Name: "DENY-ALL", CIDR: "0.0.0.0/0", Action: "deny", Priority: 250, TrafficClass: builtinTrafficClass},
+				{Name: "DENY-ALL-V6", CIDR: "::/0", Action: "deny", Priority: 250, TrafficClass: builtinTrafficClass},
+			},
+			"ALLOW-PUBLIC": {
+				{Name: "DENY-CLASS-A", CIDR: "10.0.0.0/8", Action: "deny", Priority: 250, TrafficClass: builtinTrafficClass},
+				{Name: "DENY-CLASS-B", CIDR: "172.16.0.0/12", Action: "deny", Priority: 251, TrafficClass: builtinTrafficClass},
+				{Name: "DENY-CLASS-C", CIDR: "192.168.0.0/16", Action: "deny", Priority: 252, TrafficClass: builtinTrafficClass},
+				{Name: "ALLOW-ALL", CIDR: "0.0.0.0/0", Action: "permit", Priority: 253, TrafficClass: builtinTrafficClass},
+				// Unique local (RFC 4193) and link-local addresses are the IPv6 equivalent
+				// of the private ranges above.
+				{Name: "DENY-ULA", CIDR: "fc00::/7", Action: "deny", Priority: 250, TrafficClass: builtinTrafficClass},
+				{Name: "DENY-LINK-LOCAL", CIDR: "fe80::/10", Action: "deny", Priority: 251, TrafficClass: builtinTrafficClass},
+				{Name: "ALLOW-ALL-V6", CIDR: "::/0", Action: "permit", Priority: 253, TrafficClass: builtinTrafficClass},
+			},
+		},
+	}
+}
+
+// LoadFromYamlFile loads behaviors from a YAML file. A behavior in the file replaces the
+// built-in behavior of the same name; other built-in behaviors remain available.
+func (p *DefaultBehaviorPolicy) LoadFromYamlFile(fn string) error {
+	yamlFile, err := os.ReadFile(fn)
+	if err != nil {
+		return fmt.Errorf("Failed to read yaml file: %v", err)
+	}
+
+	loaded := DefaultBehaviorPolicy{}
+	err = yaml.UnmarshalStrict(yamlFile, &loaded)
+	if err != nil {
+		return fmt.Errorf("Failed to unmarshal yaml: %v", err)
+	}
+
+	err = loaded.Validate()
+	if err != nil {
+		return fmt.Errorf("Invalid default behavior policy %s: %v", fn, err)
+	}
+
+	if p.Behaviors == nil {
+		p.Behaviors = map[string][]DefaultBehaviorRule{}
+	}
+	for name, rules := range loaded.Behaviors {
+		p.Behaviors[name] = rules
+	}
+
+	return nil
+}
+
+// Validate returns an error if any behavior in the policy cannot be used to build filter rules
+func (p *DefaultBehaviorPolicy) Validate() error {
+	// be deterministic...
+	names := []string{}
+	for name := range p.Behaviors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if name == "" {
+			return errors.New("behavior has an empty name")
+		}
+		rules := p.Behaviors[name]
+		if len(rules) == 0 {
+			return fmt.Errorf("behavior %s has no rules", name)
+		}
+		ruleNames := map[string]bool{}
+		for _, rule := range rules {
+			if rule.Name == "" {
+				return fmt.Errorf("behavior %s has a rule with an empty name", name)
+			}
+			if ruleNames[rule.Name] {
+				return fmt.Errorf("behavior %s has duplicate rule %s", name, rule.Name)
+			}
+			ruleNames[rule.Name] = true
+			if (rule.Action != "permit") && (rule.Action != "deny") {
+				return fmt.Errorf("behavior %s rule %s has invalid action %s", name, rule.Name, rule.Action)
+			}
+			if _, err := netip.ParsePrefix(rule.CIDR); err != nil {
+				return fmt.Errorf("behavior %s rule %s has invalid cidr: %v", name, rule.Name, err)
+			}
+		}
+		for i := range rules {
+			if err := validateDefaultBehaviorRule(&rules[i]); err != nil {
+				return fmt.Errorf("behavior %s rule %s %v", name, rules[i].Name, err)
+			}
+			for j := i + 1; j < len(rules); j++ {
+				a, _ := netip.ParsePrefix(rules[i].CIDR)
+				b, _ := netip.ParsePrefix(rules[j].CIDR)
+				if (rules[i].Priority == rules[j].Priority) && a.Overlaps(b) {
+					return fmt.Errorf("behavior %s rules %s and %s overlap and have the same priority %d",
+						name, rules[i].Name, rules[j].Name, rules[i].Priority)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateDefaultBehaviorRule returns an error if the priority or traffic class of a rule is
+// outside the ranges the model allows
+func validateDefaultBehaviorRule(rule *DefaultBehaviorRule) error {
+	if rule.Priority <= maxApplicationPriority {
+		return fmt.Errorf("has priority %d; default behavior rules must have priorities above %d, after the application rules",
+			rule.Priority, maxApplicationPriority)
+	}
+	tc := &rule.TrafficClass
+	if (tc.QCI < 1) || (tc.QCI > 32) {
+		return fmt.Errorf("has traffic-class qci %d; expected 1 to 32", tc.QCI)
+	}
+	if (tc.ARP < 1) || (tc.ARP > 15) {
+		return fmt.Errorf("has traffic-class arp %d; expected 1 to 15", tc.ARP)
+	}
+	if tc.PELR > 10 {
+		return fmt.Errorf("has traffic-class pelr %d; expected 0 to 10", tc.PELR)
+	}
+	if tc.PDB > 1000 {
+		return fmt.Errorf("has traffic-class pdb %d; expected 0 to 1000", tc.PDB)
+	}
+	return nil
+}
+
+// defaultBehaviorRules returns the filter rules that implement a slice's default behavior.
+// Rules for IPv6 prefixes are only included if ipv6 is true.
+func (s *Synchronizer) defaultBehaviorRules(scope *AetherScope, behavior string, ipv6 bool) ([]appFilterRule, error) {
+	policyRules, okay := s.scopeOptions(scope).defaultBehaviors.Behaviors[behavior]
+	if !okay {
+		return nil, fmt.Errorf("has invalid defauilt-behavior %s", behavior)
+	}
+
+	rules := []appFilterRule{}
+	for _, policyRule := range policyRules {
+		// The policy was validated when it was loaded
+		prefix, _ := netip.ParsePrefix(policyRule.CIDR)
+		if prefix.Addr().Is6() && !ipv6 {
+			continue
+		}
+		rules = append(rules, appFilterRule{
+			Name:     policyRule.Name,
+			Action:   policyRule.Action,
+			Priority: s.mapPriority(policyRule.Priority),
+			Endpoint: prefix.String(),
+			TrafficClass: &trafficClass{
+				Name: policyRule.TrafficClass.Name,
+				QCI:  policyRule.TrafficClass.QCI,
+				ARP:  policyRule.TrafficClass.ARP,
+				PDB:  policyRule.TrafficClass.PDB,
+				PELR: policyRule.TrafficClass.PELR,
+			},
+		})
+	}
+
+	return rules, nil
+}
@@ -0,0 +1,145 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package synchronizer
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/onosproject/sdcore-adapter/pkg/test/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultBehaviorPolicyBuiltins(t *testing.T) {
+	p := NewDefaultBehaviorPolicy()
+	assert.Nil(t, p.Validate())
+	assert.Len(t, p.Behaviors, 3)
+	assert.Contains(t, p.Behaviors, "ALLOW-ALL")
+	assert.Contains(t, p.Behaviors, "DENY-ALL")
+	assert.Contains(t, p.Behaviors, "ALLOW-PUBLIC")
+
+	s := NewSynchronizer()
+	rules, err := s.defaultBehaviorRules(nil, "ALLOW-PUBLIC", false)
+	assert.Nil(t, err)
+	assert.Len(t, rules, 4)
+	assert.Equal(t, "DENY-CLASS-A", rules[0].Name)
+	assert.Equal(t, "ALLOW-ALL", rules[3].Name)
+	assert.Equal(t, uint8(253), rules[3].Priority)
+
+	rules, err = s.defaultBehaviorRules(nil, "ALLOW-PUBLIC", true)
+	assert.Nil(t, err)
+	assert.Len(t, rules, 7)
+	assert.Equal(t, "ALLOW-ALL-V6", rules[6].Name)
+
+	_, err = s.defaultBehaviorRules(nil, "ALLOW-SOME", false)
+	assert.EqualError(t, err, "has invalid defauilt-behavior ALLOW-SOME")
+}
+
+func TestDefaultBehaviorPolicyLoad(t *testing.T) {
+	p := NewDefaultBehaviorPolicy()
+	err := p.LoadFromYamlFile("./testdata/default-behaviors.yaml")
+	assert.Nil(t, err)
+
+	// ALLOW-PUBLIC was replaced, ALLOW-INTERNET-ONLY was added, the others are untouched
+	assert.Len(t, p.Behaviors, 4)
+	assert.Len(t, p.Behaviors["ALLOW-PUBLIC"], 5)
+	assert.Equal(t, "DENY-CGNAT", p.Behaviors["ALLOW-PUBLIC"][3].Name)
+	assert.Len(t, p.Behaviors["ALLOW-INTERNET-ONLY"], 2)
+	assert.Equal(t, uint8(8), p.Behaviors["ALLOW-INTERNET-ONLY"][0].TrafficClass.QCI)
+	assert.Equal(t, uint16(300), p.Behaviors["ALLOW-INTERNET-ONLY"][0].TrafficClass.PDB)
+	assert.Len(t, p.Behaviors["DENY-ALL"], 2)
+
+	p = NewDefaultBehaviorPolicy()
+	err = p.LoadFromYamlFile("./testdata/default-behaviors-invalid.yaml")
+	assert.EqualError(t, err, `Invalid default behavior policy ./testdata/default-behaviors-invalid.yaml: behavior ALLOW-PUBLIC rule DENY-CLASS-A has invalid cidr: netip.ParsePrefix("10.0.0.0/33"): prefix length out of range`)
+	// a failed load leaves the policy unchanged
+	assert.Len(t, p.Behaviors["ALLOW-PUBLIC"], 7)
+
+	err = p.LoadFromYamlFile("./testdata/does-not-exist.yaml")
+	assert.Error(t, err)
+}
+
+func TestDefaultBehaviorPolicyValidate(t *testing.T) {
+	p := &DefaultBehaviorPolicy{Behaviors: map[string][]DefaultBehaviorRule{"EMPTY": {}}}
+	assert.EqualError(t, p.Validate(), "behavior EMPTY has no rules")
+
+	p = &DefaultBehaviorPolicy{Behaviors: map[string][]DefaultBehaviorRule{"X": {{Name: "", CIDR: "0.0.0.0/0", Action: "deny"}}}}
+	assert.EqualError(t, p.Validate(), "behavior X has a rule with an empty name")
+
+	p = &DefaultBehaviorPolicy{Behaviors: map[string][]DefaultBehaviorRule{"X": {
+		{Name: "A", CIDR: "0.0.0.0/0", Action: "deny"},
+		{Name: "A", CIDR: "::/0", Action: "deny"}}}}
+	assert.EqualError(t, p.Validate(), "behavior X has duplicate rule A")
+
+	p = &DefaultBehaviorPolicy{Behaviors: map[string][]DefaultBehaviorRule{"X": {{Name: "A", CIDR: "0.0.0.0/0", Action: "drop"}}}}
+	assert.EqualError(t, p.Validate(), "behavior X rule A has invalid action drop")
+
+	tc := DefaultBehaviorTrafficClass{QCI: 9, ARP: 6}
+	p = &DefaultBehaviorPolicy{Behaviors: map[string][]DefaultBehaviorRule{"X": {{Name: "A", CIDR: "0.0.0.0/0", Action: "deny", Priority: 100, TrafficClass: tc}}}}
+	assert.EqualError(t, p.Validate(), "behavior X rule A has priority 100; default behavior rules must have priorities above 200, after the application rules")
+
+	p = &DefaultBehaviorPolicy{Behaviors: map[string][]DefaultBehaviorRule{"X": {{Name: "A", CIDR: "0.0.0.0/0", Action: "deny", Priority: 250}}}}
+	assert.EqualError(t, p.Validate(), "behavior X rule A has traffic-class qci 0; expected 1 to 32")
+
+	p = &DefaultBehaviorPolicy{Behaviors: map[string][]DefaultBehaviorRule{"X": {
+		{Name: "A", CIDR: "0.0.0.0/0", Action: "deny", Priority: 250, TrafficClass: DefaultBehaviorTrafficClass{QCI: 9, ARP: 16}}}}}
+	assert.EqualError(t, p.Validate(), "behavior X rule A has traffic-class arp 16; expected 1 to 15")
+
+	p = &DefaultBehaviorPolicy{Behaviors: map[string][]DefaultBehaviorRule{"X": {
+		{Name: "A", CIDR: "10.0.0.0/8", Action: "deny", Priority: 250, TrafficClass: tc},
+		{Name: "B", CIDR: "0.0.0.0/0", Action: "permit", Priority: 250, TrafficClass: tc}}}}
+	assert.EqualError(t, p.Validate(), "behavior X rules A and B overlap and have the same priority 250")
+
+	// IPv4 and IPv6 rules do not overlap
+	p = &DefaultBehaviorPolicy{Behaviors: map[string][]DefaultBehaviorRule{"X": {
+		{Name: "A", CIDR: "0.0.0.0/0", Action: "deny", Priority: 250, TrafficClass: tc},
+		{Name: "B", CIDR: "::/0", Action: "deny", Priority: 250, TrafficClass: tc}}}}
+	assert.NoError(t, p.Validate())
+}
+
+func TestWithDefaultBehaviorPolicyNil(t *testing.T) {
+	s := NewSynchronizer(WithDefaultBehaviorPolicy(nil))
+	rules, err := s.defaultBehaviorRules(nil, "ALLOW-ALL", false)
+	assert.NoError(t, err)
+	assert.Len(t, rules, 1)
+}
+
+func TestSynchronizeVCSCustomDefaultBehavior(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockPusher := mocks.NewMockPusherInterface(ctrl)
+	pushes := make(map[string]string)
+
+	policy := NewDefaultBehaviorPolicy()
+	err := policy.LoadFromYamlFile("./testdata/default-behaviors.yaml")
+	require.NoError(t, err)
+	s := NewSynchronizer(WithPusher(mockPusher), WithDefaultBehaviorPolicy(policy))
+
+	config, device := BuildSampleConfig()
+	device.Site["sample-site"].Slice["sample-slice"].DefaultBehavior = aStr("ALLOW-INTERNET-ONLY")
+
+	mockPusher.EXPECT().PushUpdate(gomock.Any(), gomock.Any()).DoAndReturn(func(endpoint string, data []byte) error {
+		pushes[endpoint] = string(data)
+		return nil
+	}).AnyTimes()
+	pushErrors, err := s.SynchronizeDevice(context.Background(), config)
+	assert.Equal(t, 0, pushErrors)
+	assert.Nil(t, err)
+
+	data, okay := pushes["http://5gcore/v1/network-slice/sample-slice"]
+	require.True(t, okay)
+
+	var pushed coreSlice
+	require.NoError(t, json.Unmarshal([]byte(data), &pushed))
+	rules := pushed.ApplicationFilteringRules
+	require.Len(t, rules, 4)
+	assert.Equal(t, "DENY-CLASS-A", rules[2].Name)
+	assert.Equal(t, "10.0.0.0/8", rules[2].Endpoint)
+	assert.Equal(t, "ALLOW-ALL", rules[3].Name)
+	assert.Equal(t, uint8(251), rules[3].Priority)
+	assert.Equal(t, &trafficClass{QCI: 8, ARP: 5, PDB: 300, PELR: 6}, rules[3].TrafficClass)
+}
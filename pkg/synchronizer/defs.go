@@ -6,6 +6,8 @@
 package synchronizer
 
 import (
+	"context"
+	"sync"
 	"time"
 
 	"github.com/onosproject/sdcore-adapter/pkg/gnmi"
@@ -19,19 +21,62 @@ const (
 	// DefaultPostTimeout is the default timeout for post operations
 	DefaultPostTimeout = time.Second * 10
 
+	// DefaultRetryInterval is the default interval between attempts when a push fails
+	DefaultRetryInterval = time.Second * 5
+
+	// DefaultKafkaTopic is the default Kafka topic that operational state is read from
+	DefaultKafkaTopic = "sdcore"
+
 	// DefaultPartialUpdateEnable is the default partial update setting
 	DefaultPartialUpdateEnable = true
+
+	// DefaultPriorityAutoAssignEnable is the default setting for automatic assignment of filter rule priorities
+	DefaultPriorityAutoAssignEnable = false
+
+	// DefaultPortRangeSplitEnable is the default setting for splitting port ranges into maskable ranges
+	DefaultPortRangeSplitEnable = false
+
+	// DefaultForeignImsiEnable is the default setting for permitting IMSIs from a foreign PLMN
+	DefaultForeignImsiEnable = true
 )
 
-// Synchronizer is a Version 3 synchronizer.
-type Synchronizer struct {
+// synchronizerOptions are the options that Reconfigure may change. Each synchronization
+// attempt works from a copy, so that Reconfigure does not wait for the attempt to complete.
+type synchronizerOptions struct {
 	postEnable          bool
 	postTimeout         time.Duration
-	pusher              PusherInterface
-	updateChannel       chan *ConfigUpdate
 	retryInterval       time.Duration
 	partialUpdateEnable bool
 
+	// If false, IMSIs whose MCC/MNC do not match the site will be dropped from device-groups
+	foreignImsiEnable bool
+
+	// If true, application filter rules are given distinct priorities automatically
+	priorityAutoAssignEnable bool
+
+	// If true, application filter rules are split so that each covers a maskable port range
+	portRangeSplitEnable bool
+
+	// Rules that implement each of the slice default behaviors
+	defaultBehaviors *DefaultBehaviorPolicy
+}
+
+// Synchronizer is a Version 3 synchronizer.
+type Synchronizer struct {
+	pusher        PusherInterface
+	updateChannel chan *ConfigUpdate
+
+	// Options, protected by optionsMu; see currentOptions
+	options   synchronizerOptions
+	optionsMu sync.RWMutex
+
+	// If true, configured bitrates are not reported to prometheus. Used when previewing.
+	metricsDisable bool
+
+	// Kafka bus that operational state is read from; not read if kafkaURI is empty
+	kafkaURI   string
+	kafkaTopic string
+
 	// True if the opstate processor has started
 	opstateStarted bool
 
@@ -41,16 +86,50 @@ type Synchronizer struct {
 	busy int32
 
 	// used for ease of mocking
-	synchronizeDeviceFunc func(config *gnmi.ConfigForest) (int, error)
+	synchronizeDeviceFunc func(ctx context.Context, config *gnmi.ConfigForest, filters ...ResyncFilter) (int, error)
 
-	// cache of previously synchronized updates
-	cache map[string]interface{}
+	// cache of previously synchronized updates, protected by cacheMu
+	cache   map[cacheKey]interface{}
+	cacheMu sync.Mutex
 
 	// Promehtues fetchers for each endpoint
 	prometheus map[string]*metrics.Fetcher
 
 	kafkaMsgChannel   chan string
 	kafkaErrorChannel chan error
+
+	// Number of updates pushed, used to tell whether a resource was pushed or unchanged
+	pushCount uint64
+
+	// Status reported by GetStatus, protected by statusMu
+	statusMu         sync.Mutex
+	inProgressSince  *time.Time
+	lastSync         *SyncResult
+	lastTargetedSync *SyncResult
+	retry            RetryStatus
+	loopRunning      bool
+	configReceived   bool
+	kafkaStarted     bool
+	kafkaError       error
+	kafkaReceived    bool
+	standby          bool
+
+	// Shutdown state, see shutdown.go. stopCtx is cancelled when Stop is called, and loopDone
+	// is closed when the loop exits. cancelUpdate is protected by stopMu.
+	stopping     int32
+	stopCtx      context.Context
+	stopCancel   context.CancelFunc
+	loopDone     chan struct{}
+	stopMu       sync.Mutex
+	cancelUpdate context.CancelFunc
+
+	// Leader election state, see leader.go. The loop runs until loopCtx is cancelled, and
+	// latestConfig is the most recent configuration received. Protected by stopMu.
+	electionEnable bool
+	leader         bool
+	loopCtx        context.Context
+	loopCancel     context.CancelFunc
+	latestConfig   *gnmi.ConfigForest
 }
 
 // ConfigUpdate holds the configuration for a particular synchronization request
@@ -58,6 +137,9 @@ type ConfigUpdate struct {
 	config       *gnmi.ConfigForest
 	callbackType gnmi.ConfigCallbackType
 	target       string
+	ctx          context.Context // trace context of the request that caused the update
+	filters      resyncFilters   // resources to synchronize, or nil for every resource
+	obsoleted    bool            // set if the update was abandoned for a newer one
 }
 
 // SynchronizerOption is for options passed when creating a new synchronizer
@@ -73,4 +155,12 @@ type AetherScope struct {
 	CoreEndpoint *string
 	Site         *Site
 	Slice        *Slice
+
+	// Context carries the trace of the current synchronization, so that pushes can be
+	// correlated with the request that caused them. It is cancelled when the synchronizer is
+	// stopped or becomes standby, which aborts the pushes in flight.
+	Context context.Context
+
+	// options are those of the current synchronization attempt; see scopeOptions
+	options *synchronizerOptions
 }
@@ -14,6 +14,7 @@ package synchronizer
  */
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/onosproject/sdcore-adapter/pkg/gnmi"
@@ -85,7 +86,7 @@ func (s *Synchronizer) deleteSliceByID(scope *AetherScope, id *string) error {
 	}
 
 	url := fmt.Sprintf("%s/v1/network-slice/%s", *scope.CoreEndpoint, *id)
-	err = s.pusher.PushDelete(url)
+	err = s.pushDelete(scope, url)
 	if err != nil {
 		pushError, ok := err.(*PushError)
 		if ok && pushError.StatusCode == 404 {
@@ -133,7 +134,7 @@ func (s *Synchronizer) deleteDeviceGroupByID(scope *AetherScope, id *string) err
 	}
 
 	url := fmt.Sprintf("%s/v1/device-group/%s", *scope.CoreEndpoint, *id)
-	err = s.pusher.PushDelete(url)
+	err = s.pushDelete(scope, url)
 	if err != nil {
 		pushError, ok := err.(*PushError)
 		if ok && pushError.StatusCode == 404 {
@@ -195,7 +196,7 @@ func (s *Synchronizer) deleteSiteByPath(scope *AetherScope, path *pb.Path) error
 }
 
 // HandleDelete synchronously performs a delete
-func (s *Synchronizer) HandleDelete(config *gnmi.ConfigForest, path *pb.Path) error {
+func (s *Synchronizer) HandleDelete(ctx context.Context, config *gnmi.ConfigForest, path *pb.Path) error {
 	if path == nil || len(path.Elem) == 0 {
 		return errors.New("Delete of whole enterprise is not currently supported")
 	}
@@ -213,7 +214,9 @@ func (s *Synchronizer) HandleDelete(config *gnmi.ConfigForest, path *pb.Path) er
 
 	rootDevice := rootDeviceInterface.(*RootDevice)
 
-	scope := &AetherScope{Enterprise: rootDevice}
+	scope := &AetherScope{
+		Enterprise: rootDevice,
+		Context:    ctx}
 
 	log.Infof("HandleDelete: %s", gnmi.PathToString(path))
 
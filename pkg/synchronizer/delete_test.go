@@ -5,6 +5,7 @@
 package synchronizer
 
 import (
+	"context"
 	"github.com/golang/mock/gomock"
 	"github.com/onosproject/sdcore-adapter/pkg/test/mocks"
 	pb "github.com/openconfig/gnmi/proto/gnmi"
@@ -29,49 +30,49 @@ func TestHandleDeleteNotApplicable(t *testing.T) {
 	config, _ := BuildSampleConfig()
 
 	// Path is nil
-	err := s.HandleDelete(config, nil)
+	err := s.HandleDelete(context.Background(), config, nil)
 	assert.EqualError(t, err, "Delete of whole enterprise is not currently supported")
 
 	// Path has no elements
 	path := &pb.Path{}
-	err = s.HandleDelete(config, path)
+	err = s.HandleDelete(context.Background(), config, path)
 	assert.EqualError(t, err, "Delete of whole enterprise is not currently supported")
 
 	// Path has only one element
 	path = &pb.Path{Target: "sample-ent", Elem: []*pb.PathElem{{Name: "anything"}}}
-	err = s.HandleDelete(config, path)
+	err = s.HandleDelete(context.Background(), config, path)
 	assert.Nil(t, err)
 
 	// Path is not for a slice or device-group
 	path = &pb.Path{Target: "sample-ent", Elem: []*pb.PathElem{{Name: "anything"}, {Name: "else"}, {Name: "at"}, {Name: "all"}}}
-	err = s.HandleDelete(config, path)
+	err = s.HandleDelete(context.Background(), config, path)
 	assert.Nil(t, err)
 
 	// Path is for a slice but lacks a site key
 	path = &pb.Path{Target: "sample-ent", Elem: []*pb.PathElem{{Name: "site"}, {Name: "slice"}}}
-	err = s.HandleDelete(config, path)
+	err = s.HandleDelete(context.Background(), config, path)
 	assert.EqualError(t, err, "Delete of slice does not have a site-id key")
 
 	// Path is for a slice but lacks a key
 	path = &pb.Path{Target: "sample-ent", Elem: []*pb.PathElem{{Name: "site", Key: siteKey}, {Name: "slice"}}}
-	err = s.HandleDelete(config, path)
+	err = s.HandleDelete(context.Background(), config, path)
 	assert.EqualError(t, err, "Delete of slice does not have an id key")
 
 	// Path is for a device-group but lacks a key
 	path = &pb.Path{Target: "sample-ent", Elem: []*pb.PathElem{{Name: "site", Key: siteKey}, {Name: "device-group"}}}
-	err = s.HandleDelete(config, path)
+	err = s.HandleDelete(context.Background(), config, path)
 	assert.EqualError(t, err, "Delete of device-group does not have an id key")
 
 	// Path is for a leaf within a slice
 	path = &pb.Path{Target: "sample-ent", Elem: []*pb.PathElem{{Name: "site", Key: siteKey}, {Name: "slice"}, {Name: "inside"}}}
 	path.Elem = append(path.Elem, &pb.PathElem{Name: "leaf"})
-	err = s.HandleDelete(config, path)
+	err = s.HandleDelete(context.Background(), config, path)
 	assert.Nil(t, err)
 
 	// Path is for a leaf within a device-group
 	path = &pb.Path{Target: "sample-ent", Elem: []*pb.PathElem{{Name: "site", Key: siteKey}, {Name: "device-group"}, {Name: "inside"}}}
 	path.Elem = append(path.Elem, &pb.PathElem{Name: "sample-dg"})
-	err = s.HandleDelete(config, path)
+	err = s.HandleDelete(context.Background(), config, path)
 	assert.Nil(t, err)
 }
 
@@ -85,7 +86,7 @@ func TestHandleDeleteSlice(t *testing.T) {
 	mockPusher.EXPECT().PushDelete("http://5gcore/v1/network-slice/sample-slice").DoAndReturn(func(endpoint string) error {
 		return nil
 	}).AnyTimes()
-	err := s.HandleDelete(config, path)
+	err := s.HandleDelete(context.Background(), config, path)
 	assert.Nil(t, err)
 }
 
@@ -100,7 +101,7 @@ func TestHandleDeleteSlicePushError(t *testing.T) {
 	mockPusher.EXPECT().PushDelete("http://5gcore/v1/network-slice/sample-slice").DoAndReturn(func(endpoint string) error {
 		return &PushError{Operation: "DELETE", Endpoint: endpoint, StatusCode: 404, Status: "Not Found"}
 	}).AnyTimes()
-	err := s.HandleDelete(config, path)
+	err := s.HandleDelete(context.Background(), config, path)
 	assert.Nil(t, err)
 
 	// reset the mockpusher and synchronizer between tests
@@ -113,7 +114,7 @@ func TestHandleDeleteSlicePushError(t *testing.T) {
 	mockPusher.EXPECT().PushDelete("http://5gcore/v1/network-slice/sample-slice").DoAndReturn(func(endpoint string) error {
 		return &PushError{Operation: "DELETE", Endpoint: endpoint, StatusCode: 403, Status: "Forbidden"}
 	}).AnyTimes()
-	err = s.HandleDelete(config, path)
+	err = s.HandleDelete(context.Background(), config, path)
 	assert.EqualError(t, err, "Slice sample-slice failed to push delete: Push Error op=DELETE endpoint=http://5gcore/v1/network-slice/sample-slice code=403 status=Forbidden")
 }
 
@@ -126,28 +127,28 @@ func TestHandleDeleteVCSMissingDeps(t *testing.T) {
 	config, device := BuildSampleConfig()
 	device.Site["sample-site"].Slice = nil
 	path := BuildRootPath("sample-ent", "sample-site", "slice-id", "slice", "sample-slice")
-	err := s.HandleDelete(config, path)
+	err := s.HandleDelete(context.Background(), config, path)
 	assert.EqualError(t, err, "Slice sample-slice not found")
 
 	// Slice is empty
 	config, device = BuildSampleConfig()
 	device.Site["sample-site"].Slice = map[string]*Slice{}
 	path = BuildRootPath("sample-ent", "sample-site", "slice-id", "slice", "sample-slice")
-	err = s.HandleDelete(config, path)
+	err = s.HandleDelete(context.Background(), config, path)
 	assert.EqualError(t, err, "Slice sample-slice not found")
 
 	// Site is nil
 	config, device = BuildSampleConfig()
 	device.Site = nil
 	path = BuildRootPath("sample-ent", "sample-site", "slice-id", "slice", "sample-slice")
-	err = s.HandleDelete(config, path)
+	err = s.HandleDelete(context.Background(), config, path)
 	assert.EqualError(t, err, "Delete of slice failed to find site sample-site")
 
 	// Site is empty list
 	config, device = BuildSampleConfig()
 	device.Site = map[string]*Site{}
 	path = BuildRootPath("sample-ent", "sample-site", "slice-id", "slice", "sample-slice")
-	err = s.HandleDelete(config, path)
+	err = s.HandleDelete(context.Background(), config, path)
 	assert.EqualError(t, err, "Delete of slice failed to find site sample-site")
 }
 
@@ -161,7 +162,7 @@ func TestHandleDeleteDeviceGroup(t *testing.T) {
 	mockPusher.EXPECT().PushDelete("http://5gcore/v1/device-group/sample-dg").DoAndReturn(func(endpoint string) error {
 		return nil
 	}).AnyTimes()
-	err := s.HandleDelete(config, path)
+	err := s.HandleDelete(context.Background(), config, path)
 	assert.Nil(t, err)
 }
 
@@ -176,7 +177,7 @@ func TestHandleDeleteDeviceGroupPushError(t *testing.T) {
 	mockPusher.EXPECT().PushDelete("http://5gcore/v1/device-group/sample-dg").DoAndReturn(func(endpoint string) error {
 		return &PushError{Operation: "DELETE", Endpoint: endpoint, StatusCode: 404, Status: "Not Found"}
 	}).AnyTimes()
-	err := s.HandleDelete(config, path)
+	err := s.HandleDelete(context.Background(), config, path)
 	assert.Nil(t, err)
 
 	// reset the mockpusher and synchronizer between tests
@@ -189,7 +190,7 @@ func TestHandleDeleteDeviceGroupPushError(t *testing.T) {
 	mockPusher.EXPECT().PushDelete("http://5gcore/v1/device-group/sample-dg").DoAndReturn(func(endpoint string) error {
 		return &PushError{Operation: "DELETE", Endpoint: endpoint, StatusCode: 403, Status: "Forbidden"}
 	}).AnyTimes()
-	err = s.HandleDelete(config, path)
+	err = s.HandleDelete(context.Background(), config, path)
 	assert.EqualError(t, err, "Device-Group sample-dg failed to push delete: Push Error op=DELETE endpoint=http://5gcore/v1/device-group/sample-dg code=403 status=Forbidden")
 }
 
@@ -202,28 +203,28 @@ func TestHandleDeleteDeviceGroupMissingDeps(t *testing.T) {
 	config, device := BuildSampleConfig()
 	device.Site["sample-site"].DeviceGroup = nil
 	path := BuildRootPath("sample-ent", "sample-site", "dg-id", "device-group", "sample-dg")
-	err := s.HandleDelete(config, path)
+	err := s.HandleDelete(context.Background(), config, path)
 	assert.EqualError(t, err, "DeviceGroup sample-dg not found")
 
 	// Slice is empty
 	config, device = BuildSampleConfig()
 	device.Site["sample-site"].DeviceGroup = map[string]*DeviceGroup{}
 	path = BuildRootPath("sample-ent", "sample-site", "dg-id", "device-group", "sample-dg")
-	err = s.HandleDelete(config, path)
+	err = s.HandleDelete(context.Background(), config, path)
 	assert.EqualError(t, err, "DeviceGroup sample-dg not found")
 
 	// Site is nil
 	config, device = BuildSampleConfig()
 	device.Site = nil
 	path = BuildRootPath("sample-ent", "sample-site", "dg-id", "device-group", "sample-dg")
-	err = s.HandleDelete(config, path)
+	err = s.HandleDelete(context.Background(), config, path)
 	assert.EqualError(t, err, "Delete of device-group failed to find site sample-site")
 
 	// Site is empty list
 	config, device = BuildSampleConfig()
 	device.Site = map[string]*Site{}
 	path = BuildRootPath("sample-ent", "sample-site", "dg-id", "device-group", "sample-dg")
-	err = s.HandleDelete(config, path)
+	err = s.HandleDelete(context.Background(), config, path)
 	assert.EqualError(t, err, "Delete of device-group failed to find site sample-site")
 }
 
@@ -242,7 +243,7 @@ func TestHandleDeleteSlite(t *testing.T) {
 	mockPusher.EXPECT().PushDelete("http://5gcore/v1/network-slice/sample-slice").DoAndReturn(func(endpoint string) error {
 		return nil
 	}).AnyTimes()
-	err := s.HandleDelete(config, path)
+	err := s.HandleDelete(context.Background(), config, path)
 	assert.Nil(t, err)
 }
 
@@ -264,6 +265,6 @@ func TestHandleDeleteEnterprise(t *testing.T) {
 	}).AnyTimes()
 	*/
 
-	err := s.HandleDelete(config, path)
+	err := s.HandleDelete(context.Background(), config, path)
 	assert.EqualError(t, err, "Delete of whole enterprise is not currently supported")
 }
@@ -0,0 +1,119 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package synchronizer implements a synchronizer for converting sdcore gnmi to json
+package synchronizer
+
+import (
+	"fmt"
+	"net/netip"
+	"sort"
+)
+
+// The core evaluates application filter rules in priority order, lowest value first. When two
+// rules could match the same packet and have the same priority, the order is undefined. The
+// default behavior rules occupy a block at the end of the priority space (250 and up for the
+// built-in behaviors), and user rules should come before that block. With auto-assignment,
+// user rules are given distinct priorities below the block; without it, conflicts are logged,
+// and the rules are pushed as configured.
+
+// maxFilterPriority is one larger than the largest priority that may be sent to the core
+const maxFilterPriority = 256
+
+// defaultBehaviorFloor returns the lowest priority used by the default behavior rules. User
+// rules must have a priority below this value.
+func defaultBehaviorFloor(defaultRules []appFilterRule) int {
+	floor := maxFilterPriority
+	for _, rule := range defaultRules {
+		if int(rule.Priority) < floor {
+			floor = int(rule.Priority)
+		}
+	}
+	return floor
+}
+
+// filterRulePortRange returns the destination port range of a rule. A rule without a
+// port range matches any port.
+func filterRulePortRange(rule *appFilterRule) (uint16, uint16) {
+	if rule.DestPortStart == nil {
+		return 0, 65535
+	}
+	if rule.DestPortEnd == nil {
+		return *rule.DestPortStart, *rule.DestPortStart
+	}
+	return *rule.DestPortStart, *rule.DestPortEnd
+}
+
+// filterRulesOverlap returns true if some packet could be matched by both rules
+func filterRulesOverlap(a *appFilterRule, b *appFilterRule) bool {
+	prefixA, errA := netip.ParsePrefix(a.Endpoint)
+	prefixB, errB := netip.ParsePrefix(b.Endpoint)
+	if (errA != nil) || (errB != nil) {
+		// Endpoints are validated before the rule is built, so this should not happen
+		return a.Endpoint == b.Endpoint
+	}
+	if !prefixA.Overlaps(prefixB) {
+		return false
+	}
+
+	if (a.Protocol != nil) && (b.Protocol != nil) && (*a.Protocol != *b.Protocol) {
+		return false
+	}
+
+	startA, endA := filterRulePortRange(a)
+	startB, endB := filterRulePortRange(b)
+	if (endA < startB) || (endB < startA) {
+		return false
+	}
+
+	return true
+}
+
+// checkFilterPriorities returns an error if a user rule's priority is within the default
+// behavior block, or if two overlapping user rules have the same priority.
+func checkFilterPriorities(rules []appFilterRule, floor int) error {
+	for i := range rules {
+		if int(rules[i].Priority) >= floor {
+			return fmt.Errorf("rule %s priority %d collides with default behavior rules at priority %d and above",
+				rules[i].Name, rules[i].Priority, floor)
+		}
+		for j := i + 1; j < len(rules); j++ {
+			if (rules[i].Priority == rules[j].Priority) && filterRulesOverlap(&rules[i], &rules[j]) {
+				return fmt.Errorf("rules %s and %s overlap and have the same priority %d",
+					rules[i].Name, rules[j].Name, rules[i].Priority)
+			}
+		}
+	}
+	return nil
+}
+
+// assignFilterPriorities gives every user rule a distinct priority. Rules are ordered by their
+// configured priority, then by the order they were generated in (application, then endpoint).
+// Each rule keeps its configured priority unless an earlier rule already holds that priority
+// or a later one, in which case it takes the next free value. Assignment is deterministic, so
+// an unchanged configuration always produces the same priorities.
+func assignFilterPriorities(rules []appFilterRule, floor int) error {
+	order := make([]int, len(rules))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return rules[order[i]].Priority < rules[order[j]].Priority
+	})
+
+	next := 0
+	for _, i := range order {
+		priority := int(rules[i].Priority)
+		if priority < next {
+			priority = next
+		}
+		if priority >= floor {
+			return fmt.Errorf("unable to assign rule %s a priority below the default behavior rules at priority %d",
+				rules[i].Name, floor)
+		}
+		rules[i].Priority = uint8(priority)
+		next = priority + 1
+	}
+	return nil
+}
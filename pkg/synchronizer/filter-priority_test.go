@@ -0,0 +1,175 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package synchronizer
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/onosproject/sdcore-adapter/pkg/test/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilterRulesOverlap(t *testing.T) {
+	a := &appFilterRule{Name: "a", Endpoint: "10.0.0.0/8"}
+	b := &appFilterRule{Name: "b", Endpoint: "10.1.2.3/32"}
+	assert.True(t, filterRulesOverlap(a, b))
+
+	// disjoint prefixes
+	b.Endpoint = "11.0.0.0/8"
+	assert.False(t, filterRulesOverlap(a, b))
+
+	// IPv4 and IPv6 never overlap
+	b.Endpoint = "::/0"
+	assert.False(t, filterRulesOverlap(a, b))
+
+	// different protocols
+	b.Endpoint = "10.1.2.3/32"
+	a.Protocol = aUint8(6)
+	b.Protocol = aUint8(17)
+	assert.False(t, filterRulesOverlap(a, b))
+
+	// a rule with no protocol matches any protocol
+	b.Protocol = nil
+	assert.True(t, filterRulesOverlap(a, b))
+
+	// disjoint port ranges
+	a.DestPortStart, a.DestPortEnd = aUint16(100), aUint16(200)
+	b.DestPortStart, b.DestPortEnd = aUint16(201), aUint16(300)
+	assert.False(t, filterRulesOverlap(a, b))
+
+	// touching port ranges
+	b.DestPortStart = aUint16(200)
+	assert.True(t, filterRulesOverlap(a, b))
+
+	// a rule with no ports matches any port
+	b.DestPortStart, b.DestPortEnd = nil, nil
+	assert.True(t, filterRulesOverlap(a, b))
+}
+
+func TestCheckFilterPriorities(t *testing.T) {
+	rules := []appFilterRule{
+		{Name: "app1-ep1", Endpoint: "1.2.3.4/32", Priority: 5, DestPortStart: aUint16(80), DestPortEnd: aUint16(80)},
+		{Name: "app1-ep2", Endpoint: "1.2.3.4/32", Priority: 5, DestPortStart: aUint16(443), DestPortEnd: aUint16(443)},
+	}
+	assert.Nil(t, checkFilterPriorities(rules, 250))
+
+	rules[1].DestPortStart = aUint16(80)
+	assert.EqualError(t, checkFilterPriorities(rules, 250), "rules app1-ep1 and app1-ep2 overlap and have the same priority 5")
+
+	rules[1].Priority = 250
+	assert.EqualError(t, checkFilterPriorities(rules, 250), "rule app1-ep2 priority 250 collides with default behavior rules at priority 250 and above")
+}
+
+func TestAssignFilterPriorities(t *testing.T) {
+	rules := []appFilterRule{
+		{Name: "app1-ep1", Priority: 0},
+		{Name: "app1-ep2", Priority: 0},
+		{Name: "app2-ep1", Priority: 10},
+		{Name: "app3-ep1", Priority: 1},
+		{Name: "app4-ep1", Priority: 10},
+	}
+	assert.Nil(t, assignFilterPriorities(rules, 250))
+	assert.Equal(t, uint8(0), rules[0].Priority)
+	assert.Equal(t, uint8(1), rules[1].Priority)
+	assert.Equal(t, uint8(10), rules[2].Priority)
+	assert.Equal(t, uint8(2), rules[3].Priority)
+	assert.Equal(t, uint8(11), rules[4].Priority)
+
+	// Assignment is stable
+	assert.Nil(t, assignFilterPriorities(rules, 250))
+	assert.Equal(t, []uint8{0, 1, 10, 2, 11}, []uint8{rules[0].Priority, rules[1].Priority, rules[2].Priority, rules[3].Priority, rules[4].Priority})
+
+	// Running out of room below the default behavior block
+	rules = []appFilterRule{
+		{Name: "app1-ep1", Priority: 249},
+		{Name: "app1-ep2", Priority: 249},
+	}
+	assert.EqualError(t, assignFilterPriorities(rules, 250), "unable to assign rule app1-ep2 a priority below the default behavior rules at priority 250")
+}
+
+func TestSynchronizeVCSPriorityConflict(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockPusher := mocks.NewMockPusherInterface(ctrl)
+	pushes := make(map[string]string)
+	mockPusher.EXPECT().PushUpdate(gomock.Any(), gomock.Any()).DoAndReturn(func(endpoint string, data []byte) error {
+		pushes[endpoint] = string(data)
+		return nil
+	}).AnyTimes()
+
+	config, device := BuildSampleConfig()
+	// A second endpoint on the same application, overlapping the first
+	device.Application["sample-app"].Endpoint["sample-app-ep2"] = &ApplicationEndpoint{
+		EndpointId: aStr("sample-app-ep2"),
+		PortStart:  aUint16(124),
+		PortEnd:    aUint16(130),
+		Protocol:   aStr("UDP"),
+	}
+
+	// Without auto-assignment, the slice is pushed with the conflicting priorities
+	s := NewSynchronizer(WithPusher(mockPusher))
+	pushErrors, err := s.SynchronizeDevice(context.Background(), config)
+	assert.Equal(t, 0, pushErrors)
+	assert.Nil(t, err)
+	data, okay := pushes["http://5gcore/v1/network-slice/sample-slice"]
+	require.True(t, okay)
+	var conflicting coreSlice
+	require.NoError(t, json.Unmarshal([]byte(data), &conflicting))
+	require.Len(t, conflicting.ApplicationFilteringRules, 4)
+	assert.Equal(t, conflicting.ApplicationFilteringRules[0].Priority, conflicting.ApplicationFilteringRules[1].Priority)
+
+	// With auto-assignment, the second endpoint gets the next priority
+	s = NewSynchronizer(WithPusher(mockPusher), WithPriorityAutoAssignEnable(true))
+	pushErrors, err = s.SynchronizeDevice(context.Background(), config)
+	assert.Equal(t, 0, pushErrors)
+	assert.Nil(t, err)
+	data, okay = pushes["http://5gcore/v1/network-slice/sample-slice"]
+	require.True(t, okay)
+
+	var pushed coreSlice
+	require.NoError(t, json.Unmarshal([]byte(data), &pushed))
+	rules := pushed.ApplicationFilteringRules
+	require.Len(t, rules, 4)
+	assert.Equal(t, "sample-app-sample-app-ep", rules[0].Name)
+	assert.Equal(t, uint8(7), rules[0].Priority)
+	assert.Equal(t, "sample-app-sample-app-ep2", rules[1].Name)
+	assert.Equal(t, uint8(8), rules[1].Priority)
+	assert.Equal(t, "sample-app2-sample-app2-ep", rules[2].Name)
+	assert.Equal(t, uint8(9), rules[2].Priority)
+	assert.Equal(t, "DENY-ALL", rules[3].Name)
+	assert.Equal(t, uint8(250), rules[3].Priority)
+}
+
+func TestSynchronizeVCSPriorityInDefaultBlock(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockPusher := mocks.NewMockPusherInterface(ctrl)
+	pushes := make(map[string]string)
+	mockPusher.EXPECT().PushUpdate(gomock.Any(), gomock.Any()).DoAndReturn(func(endpoint string, data []byte) error {
+		pushes[endpoint] = string(data)
+		return nil
+	}).AnyTimes()
+
+	config, device := BuildSampleConfig()
+	device.Site["sample-site"].Slice["sample-slice"].Filter["sample-app"].Priority = aUint8(251)
+
+	// The slice is still pushed, as it was before conflicts were detected
+	s := NewSynchronizer(WithPusher(mockPusher))
+	pushErrors, err := s.SynchronizeDevice(context.Background(), config)
+	assert.Equal(t, 0, pushErrors)
+	assert.Nil(t, err)
+	_, okay := pushes["http://5gcore/v1/network-slice/sample-slice"]
+	assert.True(t, okay)
+
+	// With auto-assignment, a priority that cannot be moved below the block fails the slice
+	delete(pushes, "http://5gcore/v1/network-slice/sample-slice")
+	s = NewSynchronizer(WithPusher(mockPusher), WithPriorityAutoAssignEnable(true))
+	_, err = s.SynchronizeDevice(context.Background(), config)
+	assert.Nil(t, err)
+	_, okay = pushes["http://5gcore/v1/network-slice/sample-slice"]
+	assert.False(t, okay)
+}
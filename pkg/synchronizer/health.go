@@ -0,0 +1,145 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package synchronizer implements a synchronizer for converting sdcore gnmi to json
+package synchronizer
+
+import (
+	"fmt"
+)
+
+/*
+ * Synchronizer Health
+ *
+ * Liveness only reflects whether the synchronizer loop is running; if it is not, the process
+ * needs to be restarted. A standby replica does not run the loop until it is elected, so its
+ * loop check passes. Readiness also requires that an initial configuration has been
+ * received, that the Kafka reader (if one is configured) has been started and has not reported
+ * an error since it last delivered a message, and that the most recent synchronization did
+ * not fail with a fatal error. Push failures are not considered, as they are retried.
+ *
+ * The Kafka reader does not report when it connects, so the check cannot tell whether it is
+ * connected; only delivering a message shows that it is. The message of a passing check says
+ * whether a message has been delivered yet, and a quiet topic does not fail readiness.
+ */
+
+// Names of the health checks
+const (
+	HealthCheckLoop   = "synchronizer-loop"
+	HealthCheckConfig = "initial-config"
+	HealthCheckKafka  = "kafka-reader"
+	HealthCheckSync   = "last-sync"
+)
+
+// HealthCheck is the result of a single health check
+type HealthCheck struct {
+	Name    string `json:"name"`
+	Healthy bool   `json:"healthy"`
+	Message string `json:"message,omitempty"`
+}
+
+// Health is the combined result of a set of health checks
+type Health struct {
+	Healthy bool          `json:"healthy"`
+	Checks  []HealthCheck `json:"checks"`
+}
+
+// add appends a check, and marks the health as unhealthy if the check failed
+func (h *Health) add(name string, healthy bool, message string) {
+	h.Checks = append(h.Checks, HealthCheck{Name: name, Healthy: healthy, Message: message})
+	h.Healthy = h.Healthy && healthy
+}
+
+// GetLiveness returns the liveness of the synchronizer
+func (s *Synchronizer) GetLiveness() Health {
+	s.statusMu.Lock()
+	defer s.statusMu.Unlock()
+
+	health := Health{Healthy: true}
+	s.checkLoop(&health)
+	return health
+}
+
+// GetReadiness returns whether the synchronizer is ready to serve
+func (s *Synchronizer) GetReadiness() Health {
+	s.statusMu.Lock()
+	defer s.statusMu.Unlock()
+
+	health := Health{Healthy: true}
+	s.checkLoop(&health)
+
+	if s.configReceived {
+		health.add(HealthCheckConfig, true, "")
+	} else {
+		health.add(HealthCheckConfig, false, "no configuration has been received")
+	}
+
+	if s.kafkaURI != "" {
+		switch {
+		case !s.kafkaStarted:
+			health.add(HealthCheckKafka, false, "reader has not started")
+		case s.kafkaError != nil:
+			health.add(HealthCheckKafka, false, fmt.Sprintf("reader error: %v", s.kafkaError))
+		case !s.kafkaReceived:
+			health.add(HealthCheckKafka, true, "reader started; no message has been received yet")
+		default:
+			health.add(HealthCheckKafka, true, "")
+		}
+	}
+
+	if (s.lastSync != nil) && (s.lastSync.Error != "") {
+		health.add(HealthCheckSync, false, s.lastSync.Error)
+	} else {
+		health.add(HealthCheckSync, true, "")
+	}
+
+	return health
+}
+
+// checkLoop adds the synchronizer loop check. Caller must hold statusMu.
+func (s *Synchronizer) checkLoop(health *Health) {
+	if s.standby {
+		health.add(HealthCheckLoop, true, "standby; the loop runs when elected leader")
+	} else if s.loopRunning {
+		health.add(HealthCheckLoop, true, "")
+	} else {
+		health.add(HealthCheckLoop, false, "synchronizer loop is not running")
+	}
+}
+
+// setLoopRunning records whether the synchronizer loop is running
+func (s *Synchronizer) setLoopRunning(running bool) {
+	s.statusMu.Lock()
+	defer s.statusMu.Unlock()
+
+	s.loopRunning = running
+}
+
+// setConfigReceived records that a configuration has been received
+func (s *Synchronizer) setConfigReceived() {
+	s.statusMu.Lock()
+	defer s.statusMu.Unlock()
+
+	s.configReceived = true
+}
+
+// setKafkaState records the state of the Kafka reader: whether it is started, and the error it
+// last reported, which is cleared when it delivers a message
+func (s *Synchronizer) setKafkaState(started bool, err error) {
+	s.statusMu.Lock()
+	defer s.statusMu.Unlock()
+
+	s.kafkaStarted = started
+	s.kafkaError = err
+}
+
+// setKafkaReceived records that the Kafka reader delivered a message, which shows that it is
+// connected
+func (s *Synchronizer) setKafkaReceived() {
+	s.statusMu.Lock()
+	defer s.statusMu.Unlock()
+
+	s.kafkaReceived = true
+	s.kafkaError = nil
+}
@@ -0,0 +1,90 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package synchronizer
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/onosproject/sdcore-adapter/pkg/gnmi"
+	"github.com/stretchr/testify/assert"
+)
+
+// healthCheck returns the named check, or nil if it is not present
+func healthCheck(health Health, name string) *HealthCheck {
+	for i := range health.Checks {
+		if health.Checks[i].Name == name {
+			return &health.Checks[i]
+		}
+	}
+	return nil
+}
+
+func TestHealthLoopAndConfig(t *testing.T) {
+	sync := NewSynchronizer()
+
+	// Nothing is running yet
+	assert.False(t, sync.GetLiveness().Healthy)
+	readiness := sync.GetReadiness()
+	assert.False(t, readiness.Healthy)
+	assert.False(t, healthCheck(readiness, HealthCheckLoop).Healthy)
+	assert.False(t, healthCheck(readiness, HealthCheckConfig).Healthy)
+	assert.True(t, healthCheck(readiness, HealthCheckSync).Healthy)
+	assert.Nil(t, healthCheck(readiness, HealthCheckKafka))
+
+	sync.synchronizeDeviceFunc = mockSynchronizeDevice
+	sync.Start()
+	assert.Eventually(t, func() bool { return sync.GetLiveness().Healthy }, 5*time.Second, 10*time.Millisecond)
+	assert.False(t, sync.GetReadiness().Healthy)
+
+	// Receiving a configuration makes it ready
+	mockSynchronizeDeviceReset(0, 0, 0)
+	err := sync.Synchronize(context.Background(), gnmi.NewConfigForest(), gnmi.Apply, "sample-ent", nil, nil)
+	assert.Nil(t, err)
+	waitForSyncIdle(t, sync, 5*time.Second)
+	assert.True(t, sync.GetReadiness().Healthy)
+
+	// A fatal synchronization error makes it not ready, until a later synchronization succeeds
+	mockSynchronizeDeviceReset(1, 0, 0)
+	err = sync.Synchronize(context.Background(), gnmi.NewConfigForest(), gnmi.Apply, "sample-ent", nil, nil)
+	assert.Nil(t, err)
+	waitForSyncIdle(t, sync, 5*time.Second)
+	readiness = sync.GetReadiness()
+	assert.False(t, readiness.Healthy)
+	assert.Equal(t, "Mock error", healthCheck(readiness, HealthCheckSync).Message)
+	assert.True(t, sync.GetLiveness().Healthy)
+
+	sync.setLastSync(&SyncResult{})
+	assert.True(t, sync.GetReadiness().Healthy)
+}
+
+func TestHealthKafka(t *testing.T) {
+	sync := NewSynchronizer(WithKafka("kafka:9092", DefaultKafkaTopic))
+	sync.setLoopRunning(true)
+	sync.setConfigReceived()
+
+	readiness := sync.GetReadiness()
+	assert.False(t, readiness.Healthy)
+	assert.Equal(t, "reader has not started", healthCheck(readiness, HealthCheckKafka).Message)
+
+	// Started, but not known to be connected until a message is received
+	sync.setKafkaState(true, nil)
+	readiness = sync.GetReadiness()
+	assert.True(t, readiness.Healthy)
+	assert.Equal(t, "reader started; no message has been received yet", healthCheck(readiness, HealthCheckKafka).Message)
+
+	sync.setKafkaState(true, errors.New("connection refused"))
+	readiness = sync.GetReadiness()
+	assert.False(t, readiness.Healthy)
+	assert.Equal(t, "reader error: connection refused", healthCheck(readiness, HealthCheckKafka).Message)
+
+	// A message clears the error, and shows that the reader is connected
+	sync.setKafkaReceived()
+	readiness = sync.GetReadiness()
+	assert.True(t, readiness.Healthy)
+	assert.Equal(t, "", healthCheck(readiness, HealthCheckKafka).Message)
+}
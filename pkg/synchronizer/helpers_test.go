@@ -6,15 +6,18 @@
 package synchronizer
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	models "github.com/onosproject/aether-models/models/aether-2.1.x/v2/api"
 	"github.com/onosproject/sdcore-adapter/pkg/gnmi"
+	"sync"
 	"testing"
 	"time"
 )
 
 var (
+	mockSynchronizeDeviceMu            sync.Mutex           // guards the mock state, which the synchronizer loop changes
 	mockSynchronizeDeviceCalls         []*gnmi.ConfigForest // list of calls to MockSynchronizeDevice that succeeded
 	mockSynchronizeDeviceFails         []*gnmi.ConfigForest // list of calls to MockSynchronizeDevice that failed
 	mockSynchronizeDevicePushFails     []*gnmi.ConfigForest // list of calls to MockSynchronizeDevice that had a push failure
@@ -23,8 +26,14 @@ var (
 	mockSynchronizeDeviceDelay         time.Duration        // Cause MockSynchronizeDevice to take some time
 )
 
-func mockSynchronizeDevice(config *gnmi.ConfigForest) (int, error) {
-	time.Sleep(mockSynchronizeDeviceDelay)
+func mockSynchronizeDevice(ctx context.Context, config *gnmi.ConfigForest, filters ...ResyncFilter) (int, error) {
+	mockSynchronizeDeviceMu.Lock()
+	delay := mockSynchronizeDeviceDelay
+	mockSynchronizeDeviceMu.Unlock()
+	time.Sleep(delay)
+
+	mockSynchronizeDeviceMu.Lock()
+	defer mockSynchronizeDeviceMu.Unlock()
 	if mockSynchronizeDeviceFailCount > 0 {
 		mockSynchronizeDeviceFailCount--
 		mockSynchronizeDeviceFails = append(mockSynchronizeDeviceFails, config)
@@ -45,6 +54,8 @@ func mockSynchronizeDevice(config *gnmi.ConfigForest) (int, error) {
 //	pushFailCount = number of times to fail to push before returning success
 //	delay = amount of time to delay before returning
 func mockSynchronizeDeviceReset(failCount int, pushFailCount int, delay time.Duration) {
+	mockSynchronizeDeviceMu.Lock()
+	defer mockSynchronizeDeviceMu.Unlock()
 	mockSynchronizeDeviceCalls = nil
 	mockSynchronizeDeviceFails = nil
 	mockSynchronizeDevicePushFails = nil
@@ -53,6 +64,14 @@ func mockSynchronizeDeviceReset(failCount int, pushFailCount int, delay time.Dur
 	mockSynchronizeDeviceDelay = delay
 }
 
+// mockSynchronizeDevicePushFailCalls returns the number of calls to mockSynchronizeDevice that
+// had a push failure, while the synchronizer loop may still be calling it
+func mockSynchronizeDevicePushFailCalls() int {
+	mockSynchronizeDeviceMu.Lock()
+	defer mockSynchronizeDeviceMu.Unlock()
+	return len(mockSynchronizeDevicePushFails)
+}
+
 // Wait for the synchronizer to be idle. Used in unit tests to perform asserts
 // when a predictable state is reached.
 func waitForSyncIdle(t *testing.T, s *Synchronizer, timeout time.Duration) {
@@ -6,14 +6,19 @@
 package synchronizer
 
 import (
+	"context"
+
 	"github.com/onosproject/sdcore-adapter/pkg/gnmi"
 	pb "github.com/openconfig/gnmi/proto/gnmi"
 )
 
 // SynchronizerInterface defines the interface that all synchronizers should have.
 type SynchronizerInterface interface { //nolint
-	Synchronize(config *gnmi.ConfigForest, callbackType gnmi.ConfigCallbackType, target string, path *pb.Path) error
+	Synchronize(ctx context.Context, config *gnmi.ConfigForest, callbackType gnmi.ConfigCallbackType, target string, path *pb.Path, scope []*pb.Path) error
 	GetModels() *gnmi.Model
+	GetStatus() Status
+	GetLiveness() Health
+	GetReadiness() Health
 	Start()
 }
 
@@ -24,3 +29,10 @@ type PusherInterface interface {
 	PushUpdate(endpoint string, data []byte) error
 	PushDelete(endpoint string) error
 }
+
+// ContextPusherInterface is optionally implemented by a pusher that can propagate the trace
+// context of a synchronization to the endpoint it pushes to.
+type ContextPusherInterface interface {
+	PushUpdateWithContext(ctx context.Context, endpoint string, data []byte) error
+	PushDeleteWithContext(ctx context.Context, endpoint string) error
+}
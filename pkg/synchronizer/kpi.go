@@ -5,6 +5,11 @@
 package synchronizer
 
 import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 )
@@ -50,6 +55,31 @@ var (
 		[]string{"enterprise", "kind", "destination"},
 	)
 
+	// KpiPushTotal is a count of pushes to southbound endpoints. The status label is the
+	// HTTP status class (2xx, 4xx, 5xx, ...) or "error" if no response was received.
+	KpiPushTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "push_total",
+		Help: "The total number of pushes to southbound endpoints",
+	},
+		[]string{"endpoint", "kind", "method", "status"},
+	)
+
+	// KpiPushDuration is a histogram of duration of pushes to southbound endpoints
+	KpiPushDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "push_duration",
+		Help: "The duration of pushes to southbound endpoints",
+	},
+		[]string{"endpoint", "kind", "method", "status"},
+	)
+
+	// KpiPushBytesTotal is a count of bytes sent to southbound endpoints
+	KpiPushBytesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "push_bytes_total",
+		Help: "The total number of bytes pushed to southbound endpoints",
+	},
+		[]string{"endpoint", "kind", "method"},
+	)
+
 	// KpiSliceBitrate is the Configured MBR for slices
 	KpiSliceBitrate = promauto.NewGaugeVec(prometheus.GaugeOpts{
 		Name: "slice_bitrate",
@@ -79,6 +109,9 @@ var (
 // if we move away from prometheus and toward the Analytics Engine.
 
 func (s *Synchronizer) reportApplicationBitrate(scope *AetherScope, slice *Slice, app *Application, endpoint *ApplicationEndpoint, direction string, value uint64) {
+	if s.metricsDisable {
+		return
+	}
 	KpiApplicationBitrate.WithLabelValues(*scope.EnterpriseId,
 		*scope.Site.SiteId,
 		*slice.SliceId,
@@ -88,6 +121,9 @@ func (s *Synchronizer) reportApplicationBitrate(scope *AetherScope, slice *Slice
 }
 
 func (s *Synchronizer) reportDeviceGroupBitrate(scope *AetherScope, dg *DeviceGroup, direction string, value uint64) {
+	if s.metricsDisable {
+		return
+	}
 	KpiDeviceGroupBitrate.WithLabelValues(*scope.EnterpriseId,
 		*scope.Site.SiteId,
 		*dg.DeviceGroupId,
@@ -95,8 +131,95 @@ func (s *Synchronizer) reportDeviceGroupBitrate(scope *AetherScope, dg *DeviceGr
 }
 
 func (s *Synchronizer) reportSliceBitrate(scope *AetherScope, slice *Slice, direction string, value uint64) {
+	if s.metricsDisable {
+		return
+	}
 	KpiSliceBitrate.WithLabelValues(*scope.EnterpriseId,
 		*scope.Site.SiteId,
 		*slice.SliceId,
 		direction).Set(float64(value))
 }
+
+func (s *Synchronizer) reportSynchronization(entID string) {
+	if s.metricsDisable {
+		return
+	}
+	KpiSynchronizationTotal.WithLabelValues(entID).Inc()
+}
+
+func (s *Synchronizer) reportSynchronizationResource(entID string, kind string) {
+	if s.metricsDisable {
+		return
+	}
+	KpiSynchronizationResourceTotal.WithLabelValues(entID, kind).Inc()
+}
+
+func (s *Synchronizer) reportSynchronizationFailed(entID string, kind string, endpoint string) {
+	if s.metricsDisable {
+		return
+	}
+	KpiSynchronizationFailedTotal.WithLabelValues(entID, kind, endpoint).Inc()
+}
+
+func (s *Synchronizer) reportSynchronizationDuration(entID string, duration time.Duration) {
+	if s.metricsDisable {
+		return
+	}
+	KpiSynchronizationDuration.WithLabelValues(entID).Observe(duration.Seconds())
+}
+
+// pushResourceKind determines the kind of resource from the URL it is pushed to
+func pushResourceKind(u *url.URL) string {
+	switch {
+	case strings.Contains(u.Path, "/v1/network-slice/"):
+		return "network-slice"
+	case strings.Contains(u.Path, "/v1/device-group/"):
+		return "device-group"
+	case strings.HasSuffix(u.Path, "/v1/config/network-slices"):
+		return "upf-slice"
+	}
+	return "unknown"
+}
+
+// pushStatusClass returns the label used for the result of a push
+func pushStatusClass(statusCode int, err error) string {
+	if err != nil {
+		return "error"
+	}
+	return fmt.Sprintf("%dxx", statusCode/100)
+}
+
+// setMetricsDisable stops, or resumes, the reporting of metrics, including those of the pushes
+// made by a REST pusher
+func (s *Synchronizer) setMetricsDisable(disable bool) {
+	s.metricsDisable = disable
+	if p, okay := s.pusher.(*RESTPusher); okay {
+		p.SetMetricsDisable(disable)
+	}
+}
+
+// reportPush reports a push to endpoint. The bytes sent are only counted if a response was
+// received, as a request that failed may not have been sent.
+func (p *RESTPusher) reportPush(endpoint string, method string, bytesSent int, statusCode int, err error, duration time.Duration) {
+	p.mu.Lock()
+	metricsDisable := p.metricsDisable
+	p.mu.Unlock()
+	if metricsDisable {
+		return
+	}
+
+	host := "unknown"
+	kind := "unknown"
+	u, parseErr := url.Parse(endpoint)
+	if parseErr == nil {
+		host = u.Host
+		kind = pushResourceKind(u)
+	}
+
+	status := pushStatusClass(statusCode, err)
+	KpiPushTotal.WithLabelValues(host, kind, method, status).Inc()
+	KpiPushDuration.WithLabelValues(host, kind, method, status).Observe(duration.Seconds())
+	if err == nil {
+		KpiPushBytesTotal.WithLabelValues(host, kind, method).Add(float64(bytesSent))
+	}
+}
@@ -0,0 +1,135 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package synchronizer implements a synchronizer for converting sdcore gnmi to json
+package synchronizer
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/onosproject/sdcore-adapter/pkg/gnmi"
+)
+
+/*
+ * Leader Election
+ *
+ * When several replicas of the adapter are run, only one of them may push to the core and
+ * UPF; otherwise each would push the same configuration with its own cache. With leader
+ * election enabled (see WithLeaderElection), the synchronizer starts on standby. On standby,
+ * configuration is accepted and a copy of the most recent is kept, but the synchronizer loop
+ * does not run and nothing is pushed, including deletes.
+ *
+ * BecomeLeader starts the loop and queues a forced resync of the most recent configuration,
+ * as the push cache does not reflect what the previous leader pushed. BecomeStandby cancels
+ * the update in progress, so that nothing more is pushed once another replica may be leader,
+ * and stops the loop. Each replica must receive every gNMI Set for the standby's copy of the
+ * configuration to be current.
+ */
+
+// ErrStandby is returned when a forced synchronization is requested from a standby replica
+var ErrStandby = errors.New("Synchronizer is on standby; only the leader synchronizes")
+
+// Roles reported in the synchronizer status when leader election is enabled
+const (
+	RoleLeader  = "leader"
+	RoleStandby = "standby"
+)
+
+// WithLeaderElection sets whether the synchronizer only synchronizes while it is the leader
+func WithLeaderElection(electionEnable bool) SynchronizerOption {
+	return func(s *Synchronizer) {
+		s.electionEnable = electionEnable
+		s.standby = electionEnable
+	}
+}
+
+// isLeader returns true if this replica may push, either because it is the leader or because
+// leader election is not enabled
+func (s *Synchronizer) isLeader() bool {
+	s.stopMu.Lock()
+	defer s.stopMu.Unlock()
+	return !s.electionEnable || s.leader
+}
+
+// recordConfig keeps a copy of config, to be resynchronized when this replica is elected
+func (s *Synchronizer) recordConfig(config *gnmi.ConfigForest) error {
+	configCopy, err := copyConfig(config)
+	if err != nil {
+		return err
+	}
+	s.stopMu.Lock()
+	defer s.stopMu.Unlock()
+	s.latestConfig = configCopy
+	return nil
+}
+
+// startLoop starts the synchronizer loop. Caller must hold stopMu.
+func (s *Synchronizer) startLoop() {
+	s.loopCtx, s.loopCancel = context.WithCancel(s.stopCtx)
+	s.loopDone = make(chan struct{})
+	go s.Loop()
+}
+
+// BecomeLeader is called when this replica is elected. It starts the synchronizer loop, and
+// resynchronizes the most recent configuration.
+func (s *Synchronizer) BecomeLeader() {
+	s.stopMu.Lock()
+	if s.leader || s.isStopping() {
+		s.stopMu.Unlock()
+		return
+	}
+	s.leader = true
+	s.startLoop()
+	config := s.latestConfig
+	s.stopMu.Unlock()
+
+	log.Infof("Synchronizer elected leader")
+	s.setStandby(false)
+
+	if config == nil {
+		log.Infof("No configuration has been received; nothing to resynchronize")
+		return
+	}
+	s.CacheInvalidate()
+	if err := s.enqueue(context.Background(), config, gnmi.Forced, "", nil); err != nil {
+		log.Errorf("Failed to queue resync on election: %v", err)
+	}
+}
+
+// BecomeStandby is called when this replica loses leadership. It cancels the update in
+// progress and waits for the synchronizer loop to exit. Queued updates are dropped.
+func (s *Synchronizer) BecomeStandby() {
+	s.stopMu.Lock()
+	if !s.leader {
+		s.stopMu.Unlock()
+		return
+	}
+	s.leader = false
+	s.loopCancel()
+	if s.cancelUpdate != nil {
+		s.cancelUpdate()
+	}
+	loopDone := s.loopDone
+	s.stopMu.Unlock()
+
+	if s.isStopping() {
+		log.Infof("Synchronizer released leadership")
+	} else {
+		log.Warnf("Synchronizer lost leadership; stopping synchronization")
+	}
+	s.setStandby(true)
+	<-loopDone
+	s.drain()
+	s.setRetry(0, time.Time{})
+}
+
+// setStandby records whether the synchronizer is on standby
+func (s *Synchronizer) setStandby(standby bool) {
+	s.statusMu.Lock()
+	defer s.statusMu.Unlock()
+
+	s.standby = standby
+}
@@ -0,0 +1,108 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package synchronizer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/onosproject/sdcore-adapter/pkg/gnmi"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStandbyDoesNotSynchronize(t *testing.T) {
+	sync := NewSynchronizer(WithLeaderElection(true))
+	sync.synchronizeDeviceFunc = mockSynchronizeDevice
+	mockSynchronizeDeviceReset(0, 0, 0)
+	sync.Start()
+
+	assert.Equal(t, RoleStandby, sync.GetStatus().Role)
+	assert.True(t, sync.GetLiveness().Healthy)
+
+	config := gnmi.NewConfigForest()
+	err := sync.Synchronize(context.Background(), config, gnmi.Apply, "sample-ent", nil, nil)
+	assert.Nil(t, err)
+	assert.True(t, sync.GetReadiness().Healthy)
+
+	err = sync.Synchronize(context.Background(), config, gnmi.Forced, "", nil, nil)
+	assert.Equal(t, ErrStandby, err)
+
+	time.Sleep(100 * time.Millisecond)
+	assert.Empty(t, mockSynchronizeDeviceCalls)
+	assert.True(t, sync.isIdle())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	assert.Nil(t, sync.Stop(ctx))
+}
+
+func TestElectedResynchronizes(t *testing.T) {
+	sync := NewSynchronizer(WithLeaderElection(true))
+	sync.synchronizeDeviceFunc = mockSynchronizeDevice
+	mockSynchronizeDeviceReset(0, 0, 0)
+	sync.Start()
+
+	// Elected before any configuration is received; there is nothing to resynchronize
+	sync.BecomeLeader()
+	assert.Equal(t, RoleLeader, sync.GetStatus().Role)
+	assert.Eventually(t, func() bool { return sync.GetLiveness().Healthy }, 5*time.Second, 10*time.Millisecond)
+	waitForSyncIdle(t, sync, 5*time.Second)
+	assert.Empty(t, mockSynchronizeDeviceCalls)
+
+	sync.BecomeStandby()
+	assert.Equal(t, RoleStandby, sync.GetStatus().Role)
+
+	// Configuration received on standby is resynchronized, with a cold cache, on election
+	config := gnmi.NewConfigForest()
+	err := sync.Synchronize(context.Background(), config, gnmi.Apply, "sample-ent", nil, nil)
+	assert.Nil(t, err)
+	sync.CacheUpdate(CacheModelSlice, "sample-slice", "stale")
+
+	sync.BecomeLeader()
+	waitForSyncIdle(t, sync, 5*time.Second)
+	assert.Len(t, mockSynchronizeDeviceCalls, 1)
+	assert.False(t, sync.CacheCheck(CacheModelSlice, "sample-slice", "stale"))
+
+	// Once leader, updates are synchronized as usual
+	err = sync.Synchronize(context.Background(), config, gnmi.Apply, "sample-ent", nil, nil)
+	assert.Nil(t, err)
+	waitForSyncIdle(t, sync, 5*time.Second)
+	assert.Len(t, mockSynchronizeDeviceCalls, 2)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	assert.Nil(t, sync.Stop(ctx))
+}
+
+func TestDeposedCancelsUpdate(t *testing.T) {
+	cancelled := make(chan struct{})
+	sync := NewSynchronizer(WithLeaderElection(true))
+	sync.synchronizeDeviceFunc = func(ctx context.Context, config *gnmi.ConfigForest, filters ...ResyncFilter) (int, error) {
+		<-ctx.Done()
+		close(cancelled)
+		return 1, ctx.Err()
+	}
+	sync.Start()
+	sync.BecomeLeader()
+
+	err := sync.Synchronize(context.Background(), gnmi.NewConfigForest(), gnmi.Apply, "sample-ent", nil, nil)
+	assert.Nil(t, err)
+	assert.Eventually(t, func() bool { return sync.GetStatus().InProgress }, 5*time.Second, 10*time.Millisecond)
+
+	sync.BecomeStandby()
+	<-cancelled
+	assert.False(t, sync.GetStatus().InProgress)
+	assert.True(t, sync.isIdle())
+	assert.True(t, sync.GetLiveness().Healthy)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	assert.Nil(t, sync.Stop(ctx))
+
+	// Once stopped, the replica is not elected again
+	sync.BecomeLeader()
+	assert.Equal(t, RoleStandby, sync.GetStatus().Role)
+}
@@ -7,19 +7,12 @@
 package synchronizer
 
 import (
-	"context"
 	"encoding/json"
-	"flag"
 	"github.com/onosproject/analytics/pkg/kafkaClient"
 	"github.com/onosproject/sdcore-adapter/pkg/gnmi"
 	"github.com/onosproject/sdcore-adapter/pkg/promkafka"
 )
 
-var (
-	kafkaURI   = flag.String("kafka_uri", "", "URI of kafka")
-	kafkaTopic = flag.String("kafka_topic", "sdcore", "kafka topic to fetch from")
-)
-
 // given an IMSI, return the simCard that has that IMSI, or nil if none exists
 func (s *Synchronizer) getSimCardFromSiteByImsi(site *Site, imsi string) *SimCard {
 	for _, sim := range site.SimCard {
@@ -44,26 +37,26 @@ func (s *Synchronizer) getDeviceFromSiteByImsi(site *Site, imsi string) *Device
 	return nil
 }
 
-// given an IMSI, find the device by searching all enterprises and sites
-func (s *Synchronizer) getDeviceByImsi(config *gnmi.ConfigForest, imsi string) *Device {
-	for _, entRoot := range config.Configs {
+// given an IMSI, find the device and its target by searching all enterprises and sites
+func (s *Synchronizer) getDeviceByImsi(config *gnmi.ConfigForest, imsi string) (string, *Device) {
+	for target, entRoot := range config.Configs {
 		enterprise := entRoot.(*RootDevice)
 		for _, site := range enterprise.Site {
 			dev := s.getDeviceFromSiteByImsi(site, imsi)
 			if dev != nil {
-				return dev
+				return target, dev
 			}
 		}
 	}
 
-	return nil
+	return "", nil
 }
 
 func (s *Synchronizer) handleKafkaIPAddress(config *gnmi.ConfigForest, event *promkafka.IPAddressEvent) {
 	config.Mu.Lock()
 	defer config.Mu.Unlock()
 
-	device := s.getDeviceByImsi(config, event.Imsi)
+	target, device := s.getDeviceByImsi(config, event.Imsi)
 	if device == nil {
 		// Can happen if the device is reported in prometheus, but does not exist in
 		// ROC. Rare in real life, but plausible in test infrastructure.
@@ -84,6 +77,9 @@ func (s *Synchronizer) handleKafkaIPAddress(config *gnmi.ConfigForest, event *pr
 	} else {
 		device.State.Connected = aStr("No")
 	}
+
+	// deliver the new state to ON_CHANGE subscribers
+	config.Changed(target)
 }
 
 // Repeatedly loop, receiving messages from Kafka
@@ -99,8 +95,15 @@ func (s *Synchronizer) receiveKafkaLoop(config *gnmi.ConfigForest) {
 			} else {
 				go s.handleKafkaIPAddress(config, &event)
 			}
+			s.setKafkaReceived()
 		case err := <-s.kafkaErrorChannel:
 			log.Warnf("Kafka Error: %v", err)
+			s.setKafkaState(true, err)
+		case <-s.stopCtx.Done():
+			// The reader's context is also cancelled, so it stops reading from Kafka
+			log.Info("stopping kafka receiver loop")
+			s.setKafkaState(false, nil)
+			return
 		}
 	}
 }
@@ -109,20 +112,21 @@ func (s *Synchronizer) receiveKafkaLoop(config *gnmi.ConfigForest) {
 func (s *Synchronizer) startOpstate(config *gnmi.ConfigForest) {
 	s.opstateStarted = true
 
-	if *kafkaURI == "" {
+	if s.kafkaURI == "" {
 		log.Info("no kafkaURI specified; not starting kafka client")
 		return
 	}
 
-	log.Info("starting opstate processor on topic %s for URI %s", *kafkaTopic, *kafkaURI)
+	log.Infof("starting opstate processor on topic %s for URI %s", s.kafkaTopic, s.kafkaURI)
 
-	go kafkaClient.StartTopicReader(context.Background(),
+	go kafkaClient.StartTopicReader(s.stopCtx,
 		s.kafkaMsgChannel,
 		s.kafkaErrorChannel,
-		[]string{*kafkaURI},
-		*kafkaTopic,
+		[]string{s.kafkaURI},
+		s.kafkaTopic,
 		"opstate",
 	)
+	s.setKafkaState(true, nil)
 
 	go s.receiveKafkaLoop(config)
 }
@@ -8,9 +8,14 @@ package synchronizer
 
 import (
 	"bytes"
+	"context"
 	"fmt"
+	"io"
 	"net/http"
+	"sync"
 	"time"
+
+	"github.com/onosproject/sdcore-adapter/pkg/tracing"
 )
 
 // PushError is an error class that is returned for failed POSTs and DELETEs. It
@@ -26,30 +31,99 @@ func (e *PushError) Error() string {
 	return fmt.Sprintf("Push Error op=%s endpoint=%s code=%d status=%s", e.Operation, e.Endpoint, e.StatusCode, e.Status)
 }
 
+// PushCredentials are presented to the endpoint on every push. If BearerToken is set, it is
+// sent as an Authorization bearer token; otherwise if Username is set, basic authentication is
+// used.
+type PushCredentials struct {
+	Username    string
+	Password    string
+	BearerToken string
+}
+
 // RESTPusher implements a pusher that pushes to a rest endpoint.
 type RESTPusher struct {
+	mu             sync.Mutex
+	timeout        time.Duration
+	credentials    PushCredentials
+	metricsDisable bool
 }
 
-// PushUpdate pushes an update to the REST endpoint.
-func (p *RESTPusher) PushUpdate(endpoint string, data []byte) error {
+// SetTimeout sets the timeout of each push. Zero selects DefaultPostTimeout.
+func (p *RESTPusher) SetTimeout(timeout time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.timeout = timeout
+}
+
+// SetMetricsDisable stops, or resumes, the reporting of the pushes to prometheus
+func (p *RESTPusher) SetMetricsDisable(disable bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.metricsDisable = disable
+}
+
+// SetCredentials sets the credentials presented on each push
+func (p *RESTPusher) SetCredentials(credentials PushCredentials) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.credentials = credentials
+}
+
+// newRequest returns a request that carries the credentials, and a client with the timeout
+func (p *RESTPusher) newRequest(ctx context.Context, method string, endpoint string, body io.Reader) (*http.Client, *http.Request, error) {
+	p.mu.Lock()
+	timeout := p.timeout
+	credentials := p.credentials
+	p.mu.Unlock()
+
+	if timeout == 0 {
+		timeout = DefaultPostTimeout
+	}
 	client := &http.Client{
-		Timeout: time.Second * 10,
+		Timeout: timeout,
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+	if err != nil {
+		return nil, nil, err
+	}
+	if credentials.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+credentials.BearerToken)
+	} else if credentials.Username != "" {
+		req.SetBasicAuth(credentials.Username, credentials.Password)
 	}
+	tracing.InjectHTTP(ctx, req.Header)
+	return client, req, nil
+}
+
+// PushUpdate pushes an update to the REST endpoint.
+func (p *RESTPusher) PushUpdate(endpoint string, data []byte) error {
+	return p.PushUpdateWithContext(context.Background(), endpoint, data)
+}
 
+// PushUpdateWithContext pushes an update to the REST endpoint, propagating the trace
+// context in ctx as HTTP headers. The push is aborted if ctx is cancelled.
+func (p *RESTPusher) PushUpdateWithContext(ctx context.Context, endpoint string, data []byte) error {
 	log.Infof("Push Update endpoint=%s data=%s", endpoint, string(data))
 
-	resp, err := client.Post(
-		endpoint,
-		"application/json",
-		bytes.NewBuffer(data))
+	client, req, err := p.newRequest(ctx, "POST", endpoint, bytes.NewBuffer(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	tStart := time.Now()
+	resp, err := client.Do(req)
 
 	/* In the future, PUT will be the correct operation
 	resp, err := httpPut(client, endpoint, "application/json", data)
 	*/
 
 	if err != nil {
+		p.reportPush(endpoint, "POST", len(data), 0, err, time.Since(tStart))
 		return err
 	}
+	p.reportPush(endpoint, "POST", len(data), resp.StatusCode, nil, time.Since(tStart))
 
 	defer resp.Body.Close()
 
@@ -64,21 +138,26 @@ func (p *RESTPusher) PushUpdate(endpoint string, data []byte) error {
 
 // PushDelete pushes a delete to the REST endpoint
 func (p *RESTPusher) PushDelete(endpoint string) error {
-	client := &http.Client{
-		Timeout: time.Second * 10,
-	}
+	return p.PushDeleteWithContext(context.Background(), endpoint)
+}
 
+// PushDeleteWithContext pushes a delete to the REST endpoint, propagating the trace
+// context in ctx as HTTP headers. The push is aborted if ctx is cancelled.
+func (p *RESTPusher) PushDeleteWithContext(ctx context.Context, endpoint string) error {
 	log.Infof("Push Delete endpoint=%s", endpoint)
 
-	req, err := http.NewRequest("DELETE", endpoint, nil)
+	client, req, err := p.newRequest(ctx, "DELETE", endpoint, nil)
 	if err != nil {
 		return err
 	}
+	tStart := time.Now()
 	resp, err := client.Do(req)
 
 	if err != nil {
+		p.reportPush(endpoint, "DELETE", 0, 0, err, time.Since(tStart))
 		return err
 	}
+	p.reportPush(endpoint, "DELETE", 0, resp.StatusCode, nil, time.Since(tStart))
 
 	defer resp.Body.Close()
 
@@ -0,0 +1,129 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package synchronizer
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/onosproject/sdcore-adapter/pkg/tracing"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestPushResourceKind(t *testing.T) {
+	for endpoint, kind := range map[string]string{
+		"http://5gcore/v1/network-slice/sample-slice": "network-slice",
+		"http://5gcore/v1/device-group/sample-dg":     "device-group",
+		"http://upf/v1/config/network-slices":         "upf-slice",
+		"http://upf/v1/something-else":                "unknown",
+	} {
+		u, err := url.Parse(endpoint)
+		assert.Nil(t, err)
+		assert.Equal(t, kind, pushResourceKind(u), endpoint)
+	}
+}
+
+func TestRESTPusherMetrics(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/device-group/broken" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	host := server.Listener.Addr().String()
+
+	p := &RESTPusher{}
+
+	err := p.PushUpdate(server.URL+"/v1/network-slice/sample-slice", []byte("0123456789"))
+	assert.Nil(t, err)
+	assert.Equal(t, 1.0, testutil.ToFloat64(KpiPushTotal.WithLabelValues(host, "network-slice", "POST", "2xx")))
+	assert.Equal(t, 10.0, testutil.ToFloat64(KpiPushBytesTotal.WithLabelValues(host, "network-slice", "POST")))
+
+	err = p.PushUpdate(server.URL+"/v1/device-group/broken", []byte("{}"))
+	assert.Error(t, err)
+	assert.Equal(t, 1.0, testutil.ToFloat64(KpiPushTotal.WithLabelValues(host, "device-group", "POST", "5xx")))
+
+	err = p.PushDelete(server.URL + "/v1/device-group/sample-dg")
+	assert.Nil(t, err)
+	assert.Equal(t, 1.0, testutil.ToFloat64(KpiPushTotal.WithLabelValues(host, "device-group", "DELETE", "2xx")))
+
+	// The server is gone, so no response is received, and no bytes are counted
+	server.Close()
+	err = p.PushUpdate(server.URL+"/v1/config/network-slices", []byte("{}"))
+	assert.Error(t, err)
+	assert.Equal(t, 1.0, testutil.ToFloat64(KpiPushTotal.WithLabelValues(host, "upf-slice", "POST", "error")))
+	assert.Equal(t, 0.0, testutil.ToFloat64(KpiPushBytesTotal.WithLabelValues(host, "upf-slice", "POST")))
+
+	// Nothing is reported once metrics are disabled
+	p.SetMetricsDisable(true)
+	err = p.PushUpdate(server.URL+"/v1/config/network-slices", []byte("{}"))
+	assert.Error(t, err)
+	assert.Equal(t, 1.0, testutil.ToFloat64(KpiPushTotal.WithLabelValues(host, "upf-slice", "POST", "error")))
+}
+
+func TestRESTPusherTraceContext(t *testing.T) {
+	_, err := tracing.Init(context.Background(), tracing.Config{Exporter: tracing.ExporterNone})
+	assert.Nil(t, err)
+
+	traceParents := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceParents = append(traceParents, r.Header.Get("traceparent"))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	traceID, _ := trace.TraceIDFromHex("0af7651916cd43dd8448eb211c80319c")
+	spanID, _ := trace.SpanIDFromHex("b7ad6b7169203331")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+	}))
+
+	p := &RESTPusher{}
+	err = p.PushUpdateWithContext(ctx, server.URL+"/v1/network-slice/sample-slice", []byte("{}"))
+	assert.Nil(t, err)
+	err = p.PushDeleteWithContext(ctx, server.URL+"/v1/network-slice/sample-slice")
+	assert.Nil(t, err)
+	err = p.PushUpdate(server.URL+"/v1/network-slice/sample-slice", []byte("{}"))
+	assert.Nil(t, err)
+
+	assert.Equal(t, []string{
+		"00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01",
+		"00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01",
+		"",
+	}, traceParents)
+}
+
+func TestRESTPusherCredentials(t *testing.T) {
+	authorizations := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorizations = append(authorizations, r.Header.Get("Authorization"))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	s := NewSynchronizer(WithPushCredentials(PushCredentials{Username: "aether", Password: "secret"}))
+	p := s.pusher.(*RESTPusher)
+	err := p.PushUpdate(server.URL+"/v1/network-slice/sample-slice", []byte("{}"))
+	assert.Nil(t, err)
+
+	s.Reconfigure(WithPushCredentials(PushCredentials{BearerToken: "token"}))
+	err = p.PushDelete(server.URL + "/v1/network-slice/sample-slice")
+	assert.Nil(t, err)
+
+	s.Reconfigure(WithPushCredentials(PushCredentials{}))
+	err = p.PushUpdate(server.URL+"/v1/network-slice/sample-slice", []byte("{}"))
+	assert.Nil(t, err)
+
+	assert.Equal(t, []string{"Basic YWV0aGVyOnNlY3JldA==", "Bearer token", ""}, authorizations)
+}
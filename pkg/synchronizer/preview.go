@@ -0,0 +1,193 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package synchronizer implements a synchronizer for converting sdcore gnmi to json
+package synchronizer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/onosproject/sdcore-adapter/pkg/gnmi"
+)
+
+/*
+ * Southbound Preview
+ *
+ * Runs the translators over a configuration and returns the documents that would be pushed,
+ * without pushing them. The translators are run by a separate preview synchronizer that has
+ * the same options, a recording pusher, an empty cache, and metrics disabled, so that the
+ * preview has no side effects on the live synchronizer, the core, or the UPF.
+ */
+
+// Change types of a previewed document, relative to the push cache
+const (
+	PreviewChangeAdded     = "added"
+	PreviewChangeChanged   = "changed"
+	PreviewChangeUnchanged = "unchanged"
+)
+
+// PreviewDocument is a document that would be pushed to a southbound endpoint
+type PreviewDocument struct {
+	Model    string          `json:"model"`
+	ID       string          `json:"id"`
+	Document json.RawMessage `json:"document"`
+	Change   string          `json:"change"`
+	Cached   json.RawMessage `json:"cached,omitempty"` // the previously pushed document, if changed
+}
+
+// Preview is the result of previewing a configuration. Documents are keyed by the URL they
+// would be pushed to. More than one document may be pushed to the same URL; for example,
+// every slice is pushed to its UPF's slice endpoint.
+type Preview struct {
+	Documents map[string][]*PreviewDocument `json:"documents"`
+	Errors    []ResourceFailure             `json:"errors,omitempty"`
+}
+
+// previewPush is an update recorded by the previewPusher
+type previewPush struct {
+	endpoint string
+	data     []byte
+}
+
+// previewPusher is a pusher that records updates instead of pushing them
+type previewPusher struct {
+	pushes []previewPush
+}
+
+func (p *previewPusher) PushUpdate(endpoint string, data []byte) error {
+	p.pushes = append(p.pushes, previewPush{endpoint: endpoint, data: data})
+	return nil
+}
+
+func (p *previewPusher) PushDelete(endpoint string) error {
+	return fmt.Errorf("Delete of %s is not supported in preview", endpoint)
+}
+
+// newPreviewSynchronizer returns a synchronizer with the same translation options as s,
+// that records pushes and has no effect on the live synchronizer.
+func (s *Synchronizer) newPreviewSynchronizer() (*Synchronizer, *previewPusher) {
+	opts := s.currentOptions()
+	pusher := &previewPusher{}
+	p := NewSynchronizer(
+		WithPusher(pusher),
+		WithPartialUpdateEnable(false),
+		WithForeignImsiEnable(opts.foreignImsiEnable),
+		WithPriorityAutoAssignEnable(opts.priorityAutoAssignEnable),
+		WithPortRangeSplitEnable(opts.portRangeSplitEnable),
+		WithDefaultBehaviorPolicy(opts.defaultBehaviors))
+	p.setMetricsDisable(true)
+	return p, pusher
+}
+
+// PreviewJSON previews the configuration of target given as JSON. See Preview.
+func (s *Synchronizer) PreviewJSON(target string, data []byte) (*Preview, error) {
+	rootStruct, err := s.GetModels().NewConfigStruct(data)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse configuration for %s: %v", target, err)
+	}
+	config := gnmi.NewConfigForest()
+	config.Configs[target] = rootStruct
+	return s.Preview(config)
+}
+
+// previewKinds maps the kinds of resources in the synchronizer status to the push cache models
+var previewKinds = map[string]string{
+	StatusKindDeviceGroup: CacheModelDeviceGroup,
+	StatusKindSlice:       CacheModelSlice,
+	StatusKindSliceUPF:    CacheModelSliceUpf,
+}
+
+// Preview returns the documents that synchronizing config would push, and compares each of
+// them to the push cache. Resources that fail to translate are reported in Errors. Neither
+// the push cache nor any southbound endpoint is modified.
+//
+// The preview synchronizer runs SynchronizeDevice, so the preview selects and translates
+// resources exactly as a synchronization does. Each translator records the document it pushed
+// in the preview synchronizer's cache, which identifies the resource of each recorded push.
+func (s *Synchronizer) Preview(config *gnmi.ConfigForest) (*Preview, error) {
+	p, pusher := s.newPreviewSynchronizer()
+	preview := &Preview{Documents: map[string][]*PreviewDocument{}}
+
+	if _, err := p.SynchronizeDevice(context.Background(), config); err != nil {
+		return nil, err
+	}
+
+	endpoints := map[string]string{}
+	for _, push := range pusher.pushes {
+		endpoints[string(push.data)] = push.endpoint
+	}
+	for key, contents := range p.cache {
+		// The translators push indented json
+		data, err := json.MarshalIndent(contents, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("Preview of %s %s failed to Marshal Json: %s", key.model, key.id, err)
+		}
+		endpoint, okay := endpoints[string(data)]
+		if !okay {
+			return nil, fmt.Errorf("Preview of %s %s was not pushed", key.model, key.id)
+		}
+		doc, err := s.previewDocument(key.model, key.id, data)
+		if err != nil {
+			return nil, err
+		}
+		preview.Documents[endpoint] = append(preview.Documents[endpoint], doc)
+	}
+
+	if lastSync := p.GetStatus().LastSync; lastSync != nil {
+		for _, ent := range lastSync.Enterprises {
+			for _, failure := range ent.Failures {
+				failure.Kind = previewKinds[failure.Kind]
+				preview.Errors = append(preview.Errors, failure)
+			}
+		}
+	}
+
+	// be deterministic...
+	for _, docs := range preview.Documents {
+		sort.Slice(docs, func(i, j int) bool {
+			if docs[i].Model != docs[j].Model {
+				return docs[i].Model < docs[j].Model
+			}
+			return docs[i].ID < docs[j].ID
+		})
+	}
+	sort.Slice(preview.Errors, func(i, j int) bool {
+		if preview.Errors[i].Kind != preview.Errors[j].Kind {
+			return preview.Errors[i].Kind < preview.Errors[j].Kind
+		}
+		return preview.Errors[i].ID < preview.Errors[j].ID
+	})
+
+	return preview, nil
+}
+
+// previewDocument compares a rendered document to the push cache
+func (s *Synchronizer) previewDocument(model string, id string, data []byte) (*PreviewDocument, error) {
+	doc := &PreviewDocument{Model: model, ID: id, Document: data, Change: PreviewChangeAdded}
+
+	s.cacheMu.Lock()
+	cached, okay := s.cache[cacheKey{model: model, id: id}]
+	s.cacheMu.Unlock()
+	if !okay {
+		return doc, nil
+	}
+
+	// The translators push indented json; marshal the cached contents the same way so that
+	// the documents are comparable.
+	cachedData, err := json.MarshalIndent(cached, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("Cache entry %s-%s failed to Marshal Json: %s", model, id, err)
+	}
+	if string(cachedData) == string(data) {
+		doc.Change = PreviewChangeUnchanged
+		return doc, nil
+	}
+
+	doc.Change = PreviewChangeChanged
+	doc.Cached = cachedData
+	return doc, nil
+}
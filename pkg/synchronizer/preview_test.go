@@ -0,0 +1,145 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package synchronizer
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/onosproject/sdcore-adapter/pkg/test/mocks"
+	"github.com/openconfig/ygot/ygot"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreview(t *testing.T) {
+	jsonDataDg, err := os.ReadFile("./testdata/sample-dg.json")
+	assert.NoError(t, err)
+	jsonDataUpfSlice, err := os.ReadFile("./testdata/sample-upfslice.json")
+	assert.NoError(t, err)
+
+	// The pusher has no expectations, so any push from the preview fails the test
+	ctrl := gomock.NewController(t)
+	mockPusher := mocks.NewMockPusherInterface(ctrl)
+	s := NewSynchronizer(WithPusher(mockPusher))
+
+	config, device := BuildSampleConfig()
+
+	preview, err := s.Preview(config)
+	assert.Nil(t, err)
+	assert.Empty(t, preview.Errors)
+	require.Len(t, preview.Documents, 3)
+
+	docs := preview.Documents["http://5gcore/v1/device-group/sample-dg"]
+	require.Len(t, docs, 1)
+	assert.Equal(t, CacheModelDeviceGroup, docs[0].Model)
+	assert.Equal(t, "sample-dg", docs[0].ID)
+	assert.Equal(t, PreviewChangeAdded, docs[0].Change)
+	require.JSONEq(t, string(jsonDataDg), string(docs[0].Document))
+
+	docs = preview.Documents["http://upf/v1/config/network-slices"]
+	require.Len(t, docs, 1)
+	assert.Equal(t, CacheModelSliceUpf, docs[0].Model)
+	require.JSONEq(t, string(jsonDataUpfSlice), string(docs[0].Document))
+
+	docs = preview.Documents["http://5gcore/v1/network-slice/sample-slice"]
+	require.Len(t, docs, 1)
+	assert.Equal(t, CacheModelSlice, docs[0].Model)
+
+	// The preview did not populate the cache
+	entries, err := s.CacheList("")
+	assert.Nil(t, err)
+	assert.Empty(t, entries)
+
+	// Synchronize for real, and the preview is unchanged
+	mockPusher.EXPECT().PushUpdate(gomock.Any(), gomock.Any()).Return(nil).Times(3)
+	pushErrors, err := s.SynchronizeDevice(context.Background(), config)
+	assert.Equal(t, 0, pushErrors)
+	assert.Nil(t, err)
+
+	preview, err = s.Preview(config)
+	assert.Nil(t, err)
+	for _, docs := range preview.Documents {
+		for _, doc := range docs {
+			assert.Equal(t, PreviewChangeUnchanged, doc.Change, doc.Model)
+			assert.Nil(t, doc.Cached)
+		}
+	}
+
+	// A change is reported along with the previously pushed document
+	device.Site["sample-site"].IpDomain["sample-ipd"].DnsPrimary = aStr("5.6.7.8")
+	preview, err = s.Preview(config)
+	assert.Nil(t, err)
+	docs = preview.Documents["http://5gcore/v1/device-group/sample-dg"]
+	require.Len(t, docs, 1)
+	assert.Equal(t, PreviewChangeChanged, docs[0].Change)
+	require.JSONEq(t, string(jsonDataDg), string(docs[0].Cached))
+	assert.Contains(t, string(docs[0].Document), "5.6.7.8")
+	assert.Equal(t, PreviewChangeUnchanged, preview.Documents["http://5gcore/v1/network-slice/sample-slice"][0].Change)
+
+	// Translation errors are reported, and the slice's UPF is not rendered
+	device.Site["sample-site"].Slice["sample-slice"].DefaultBehavior = aStr("BOGUS")
+	preview, err = s.Preview(config)
+	assert.Nil(t, err)
+	require.Len(t, preview.Errors, 1)
+	assert.Equal(t, CacheModelSlice, preview.Errors[0].Kind)
+	assert.Equal(t, "sample-slice", preview.Errors[0].ID)
+	assert.Len(t, preview.Documents, 1)
+}
+
+func TestPreviewJSON(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockPusher := mocks.NewMockPusherInterface(ctrl)
+	s := NewSynchronizer(WithPusher(mockPusher))
+
+	preview, err := s.PreviewJSON("sample-ent", []byte("{}"))
+	assert.Nil(t, err)
+	assert.Empty(t, preview.Documents)
+	assert.Empty(t, preview.Errors)
+
+	// The candidate is validated against the schema
+	_, device := BuildSampleConfig()
+	jsonTree, err := ygot.ConstructIETFJSON(device, &ygot.RFC7951JSONConfig{})
+	assert.Nil(t, err)
+	data, err := json.Marshal(jsonTree)
+	assert.Nil(t, err)
+	_, err = s.PreviewJSON("sample-ent", data)
+	assert.ErrorContains(t, err, "Failed to parse configuration for sample-ent")
+
+	_, err = s.PreviewJSON("sample-ent", []byte("{\"bogus\": 1}"))
+	assert.Error(t, err)
+}
+
+func TestPreviewFollowsSynchronizeDevice(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockPusher := mocks.NewMockPusherInterface(ctrl)
+	s := NewSynchronizer(WithPusher(mockPusher))
+
+	// Slices on a core the site does not have are reported, as synchronization reports them,
+	// and in a deterministic order
+	config, device := BuildSampleConfig()
+	for _, id := range []string{"slice-b", "slice-a"} {
+		device.Site["sample-site"].Slice[id] = &Slice{
+			SliceId:             aStr(id),
+			Sst:                 aStr("1"),
+			DefaultBehavior:     aStr("DENY-ALL"),
+			ConnectivityService: ConnectivityService4G,
+		}
+	}
+
+	preview, err := s.Preview(config)
+	assert.Nil(t, err)
+	require.Len(t, preview.Errors, 2)
+	assert.Equal(t, CacheModelSlice, preview.Errors[0].Kind)
+	assert.Equal(t, "slice-a", preview.Errors[0].ID)
+	assert.Equal(t, "slice-b", preview.Errors[1].ID)
+	assert.Len(t, preview.Documents, 3)
+
+	status := s.GetStatus()
+	assert.Nil(t, status.LastSync)
+}
@@ -0,0 +1,265 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package synchronizer implements a synchronizer for converting sdcore gnmi to json
+package synchronizer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	// ProtocolAny is the protocol name that matches any protocol
+	ProtocolAny = "ANY"
+
+	// ProtocolNumberTCP is the IANA protocol number for TCP
+	ProtocolNumberTCP = 6
+
+	// ProtocolNumberUDP is the IANA protocol number for UDP
+	ProtocolNumberUDP = 17
+
+	// ProtocolNumberSCTP is the IANA protocol number for SCTP
+	ProtocolNumberSCTP = 132
+)
+
+// protocolNumbers maps the upper-cased IANA protocol keywords to protocol numbers. See
+// https://www.iana.org/assignments/protocol-numbers/protocol-numbers.xhtml. Numbers that
+// have no keyword in the registry (61, 63, 68, 99, 114, and the unassigned or experimental
+// range) can still be specified numerically.
+var protocolNumbers = map[string]uint8{
+	"HOPOPT":          0,
+	"ICMP":            1,
+	"IGMP":            2,
+	"GGP":             3,
+	"IPV4":            4,
+	"ST":              5,
+	"TCP":             6,
+	"CBT":             7,
+	"EGP":             8,
+	"IGP":             9,
+	"BBN-RCC-MON":     10,
+	"NVP-II":          11,
+	"PUP":             12,
+	"ARGUS":           13,
+	"EMCON":           14,
+	"XNET":            15,
+	"CHAOS":           16,
+	"UDP":             17,
+	"MUX":             18,
+	"DCN-MEAS":        19,
+	"HMP":             20,
+	"PRM":             21,
+	"XNS-IDP":         22,
+	"TRUNK-1":         23,
+	"TRUNK-2":         24,
+	"LEAF-1":          25,
+	"LEAF-2":          26,
+	"RDP":             27,
+	"IRTP":            28,
+	"ISO-TP4":         29,
+	"NETBLT":          30,
+	"MFE-NSP":         31,
+	"MERIT-INP":       32,
+	"DCCP":            33,
+	"3PC":             34,
+	"IDPR":            35,
+	"XTP":             36,
+	"DDP":             37,
+	"IDPR-CMTP":       38,
+	"TP++":            39,
+	"IL":              40,
+	"IPV6":            41,
+	"SDRP":            42,
+	"IPV6-ROUTE":      43,
+	"IPV6-FRAG":       44,
+	"IDRP":            45,
+	"RSVP":            46,
+	"GRE":             47,
+	"DSR":             48,
+	"BNA":             49,
+	"ESP":             50,
+	"AH":              51,
+	"I-NLSP":          52,
+	"SWIPE":           53,
+	"NARP":            54,
+	"MIN-IPV4":        55,
+	"TLSP":            56,
+	"SKIP":            57,
+	"IPV6-ICMP":       58,
+	"IPV6-NONXT":      59,
+	"IPV6-OPTS":       60,
+	"CFTP":            62,
+	"SAT-EXPAK":       64,
+	"KRYPTOLAN":       65,
+	"RVD":             66,
+	"IPPC":            67,
+	"SAT-MON":         69,
+	"VISA":            70,
+	"IPCV":            71,
+	"CPNX":            72,
+	"CPHB":            73,
+	"WSN":             74,
+	"PVP":             75,
+	"BR-SAT-MON":      76,
+	"SUN-ND":          77,
+	"WB-MON":          78,
+	"WB-EXPAK":        79,
+	"ISO-IP":          80,
+	"VMTP":            81,
+	"SECURE-VMTP":     82,
+	"VINES":           83,
+	"TTP":             84,
+	"IPTM":            84,
+	"NSFNET-IGP":      85,
+	"DGP":             86,
+	"TCF":             87,
+	"EIGRP":           88,
+	"OSPFIGP":         89,
+	"SPRITE-RPC":      90,
+	"LARP":            91,
+	"MTP":             92,
+	"AX.25":           93,
+	"IPIP":            94,
+	"MICP":            95,
+	"SCC-SP":          96,
+	"ETHERIP":         97,
+	"ENCAP":           98,
+	"GMTP":            100,
+	"IFMP":            101,
+	"PNNI":            102,
+	"PIM":             103,
+	"ARIS":            104,
+	"SCPS":            105,
+	"QNX":             106,
+	"A/N":             107,
+	"IPCOMP":          108,
+	"SNP":             109,
+	"COMPAQ-PEER":     110,
+	"IPX-IN-IP":       111,
+	"VRRP":            112,
+	"PGM":             113,
+	"L2TP":            115,
+	"DDX":             116,
+	"IATP":            117,
+	"STP":             118,
+	"SRP":             119,
+	"UTI":             120,
+	"SMP":             121,
+	"SM":              122,
+	"PTP":             123,
+	"ISIS":            124,
+	"FIRE":            125,
+	"CRTP":            126,
+	"CRUDP":           127,
+	"SSCOPMCE":        128,
+	"IPLT":            129,
+	"SPS":             130,
+	"PIPE":            131,
+	"SCTP":            132,
+	"FC":              133,
+	"RSVP-E2E-IGNORE": 134,
+	"MOBILITY-HEADER": 135,
+	"UDPLITE":         136,
+	"MPLS-IN-IP":      137,
+	"MANET":           138,
+	"HIP":             139,
+	"SHIM6":           140,
+	"WESP":            141,
+	"ROHC":            142,
+	"ETHERNET":        143,
+	"AGGFRAG":         144,
+	"NSH":             145,
+
+	// Common aliases that are not IANA keywords
+	"ICMPV6": 58,
+	"OSPF":   89,
+}
+
+// ProtoStringToProtoNumber converts a protocol name to a number. Names are IANA keywords
+// and are case-insensitive. A decimal protocol number is also accepted.
+func ProtoStringToProtoNumber(s string) (uint8, error) {
+	n, okay := protocolNumbers[strings.ToUpper(s)]
+	if okay {
+		return n, nil
+	}
+
+	num, err := strconv.ParseUint(s, 10, 8)
+	if err != nil {
+		return 0, fmt.Errorf("Unknown protocol %s", s)
+	}
+	return uint8(num), nil
+}
+
+// ProtoStringToProtoFilter converts a protocol name to the protocol field of a filter rule.
+// ANY converts to nil, which matches any protocol.
+func ProtoStringToProtoFilter(s string) (*uint8, error) {
+	if strings.EqualFold(s, ProtocolAny) {
+		return nil, nil
+	}
+
+	n, err := ProtoStringToProtoNumber(s)
+	if err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+// ProtocolHasPorts returns true if the protocol carries port numbers that a filter rule can match
+func ProtocolHasPorts(n uint8) bool {
+	return (n == ProtocolNumberTCP) || (n == ProtocolNumberUDP) || (n == ProtocolNumberSCTP)
+}
+
+// SplitPortRange splits an inclusive port range into the smallest list of ranges that can each
+// be expressed as a single port value and mask, for cores that cannot match arbitrary ranges.
+func SplitPortRange(start uint16, end uint16) [][2]uint16 {
+	ranges := [][2]uint16{}
+	if end < start {
+		return ranges
+	}
+
+	// Work in uint32 so that a range ending at 65535 does not overflow
+	lo := uint32(start)
+	hi := uint32(end)
+	for lo <= hi {
+		// The largest aligned block starting at lo...
+		size := lo & -lo
+		if lo == 0 {
+			size = 1 << 16
+		}
+		// ...that does not extend past hi
+		for lo+size-1 > hi {
+			size >>= 1
+		}
+		ranges = append(ranges, [2]uint16{uint16(lo), uint16(lo + size - 1)})
+		lo += size
+	}
+
+	return ranges
+}
+
+// splitFilterRulePorts splits a filter rule into one rule per maskable port range. Each of the
+// resulting rules has its index appended to its name if more than one rule is needed.
+func splitFilterRulePorts(rule appFilterRule) []appFilterRule {
+	if rule.DestPortStart == nil {
+		return []appFilterRule{rule}
+	}
+
+	start, end := filterRulePortRange(&rule)
+	ranges := SplitPortRange(start, end)
+	if len(ranges) <= 1 {
+		return []appFilterRule{rule}
+	}
+
+	rules := []appFilterRule{}
+	for i, r := range ranges {
+		split := rule
+		split.Name = fmt.Sprintf("%s-%d", rule.Name, i)
+		split.DestPortStart = aUint16(r[0])
+		split.DestPortEnd = aUint16(r[1])
+		rules = append(rules, split)
+	}
+	return rules
+}
@@ -0,0 +1,107 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package synchronizer
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/onosproject/sdcore-adapter/pkg/test/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProtoStringToProtoFilter(t *testing.T) {
+	p, err := ProtoStringToProtoFilter("ANY")
+	assert.Nil(t, err)
+	assert.Nil(t, p)
+
+	p, err = ProtoStringToProtoFilter("any")
+	assert.Nil(t, err)
+	assert.Nil(t, p)
+
+	p, err = ProtoStringToProtoFilter("UDP")
+	assert.Nil(t, err)
+	assert.Equal(t, aUint8(17), p)
+
+	_, err = ProtoStringToProtoFilter("MQTT")
+	assert.EqualError(t, err, "Unknown protocol MQTT")
+
+	// 255 is reserved, not a named protocol
+	_, err = ProtoStringToProtoFilter("RESERVED")
+	assert.EqualError(t, err, "Unknown protocol RESERVED")
+}
+
+func TestSplitPortRange(t *testing.T) {
+	assert.Equal(t, [][2]uint16{{80, 80}}, SplitPortRange(80, 80))
+	assert.Equal(t, [][2]uint16{{1024, 2047}}, SplitPortRange(1024, 2047))
+	assert.Equal(t, [][2]uint16{{123, 123}, {124, 124}}, SplitPortRange(123, 124))
+	assert.Equal(t, [][2]uint16{{5, 5}, {6, 7}, {8, 15}, {16, 16}}, SplitPortRange(5, 16))
+	assert.Equal(t, [][2]uint16{{0, 65535}}, SplitPortRange(0, 65535))
+	assert.Equal(t, [][2]uint16{{1, 1}, {2, 3}, {4, 7}, {8, 15}, {16, 31}, {32, 63}, {64, 127}, {128, 255},
+		{256, 511}, {512, 1023}, {1024, 2047}, {2048, 4095}, {4096, 8191}, {8192, 16383}, {16384, 32767},
+		{32768, 65535}}, SplitPortRange(1, 65535))
+	assert.Equal(t, [][2]uint16{}, SplitPortRange(10, 9))
+}
+
+func TestSynchronizeVCSPortRangeSplit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockPusher := mocks.NewMockPusherInterface(ctrl)
+	pushes := make(map[string]string)
+	mockPusher.EXPECT().PushUpdate(gomock.Any(), gomock.Any()).DoAndReturn(func(endpoint string, data []byte) error {
+		pushes[endpoint] = string(data)
+		return nil
+	}).AnyTimes()
+
+	config, _ := BuildSampleConfig()
+	s := NewSynchronizer(WithPusher(mockPusher), WithPortRangeSplitEnable(true))
+	pushErrors, err := s.SynchronizeDevice(context.Background(), config)
+	assert.Equal(t, 0, pushErrors)
+	assert.Nil(t, err)
+
+	data, okay := pushes["http://5gcore/v1/network-slice/sample-slice"]
+	require.True(t, okay)
+
+	var pushed coreSlice
+	require.NoError(t, json.Unmarshal([]byte(data), &pushed))
+	rules := pushed.ApplicationFilteringRules
+
+	// Each application's 123-124 range is split into two single ports
+	require.Len(t, rules, 5)
+	assert.Equal(t, "sample-app-sample-app-ep-0", rules[0].Name)
+	assert.Equal(t, aUint16(123), rules[0].DestPortStart)
+	assert.Equal(t, aUint16(123), rules[0].DestPortEnd)
+	assert.Equal(t, "sample-app-sample-app-ep-1", rules[1].Name)
+	assert.Equal(t, aUint16(124), rules[1].DestPortStart)
+	assert.Equal(t, aUint16(124), rules[1].DestPortEnd)
+	assert.Equal(t, uint8(7), rules[1].Priority)
+	assert.Equal(t, "sample-app2-sample-app2-ep-0", rules[2].Name)
+	assert.Equal(t, "sample-app2-sample-app2-ep-1", rules[3].Name)
+	assert.Equal(t, "DENY-ALL", rules[4].Name)
+}
+
+func TestSynchronizeVCSInvalidPorts(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockPusher := mocks.NewMockPusherInterface(ctrl)
+	pushes := make(map[string]string)
+	mockPusher.EXPECT().PushUpdate(gomock.Any(), gomock.Any()).DoAndReturn(func(endpoint string, data []byte) error {
+		pushes[endpoint] = string(data)
+		return nil
+	}).AnyTimes()
+
+	config, device := BuildSampleConfig()
+	device.Application["sample-app"].Endpoint["sample-app-ep"].PortEnd = aUint16(100)
+
+	s := NewSynchronizer(WithPusher(mockPusher))
+	pushErrors, err := s.SynchronizeDevice(context.Background(), config)
+	assert.Equal(t, 0, pushErrors)
+	assert.Nil(t, err)
+
+	// The slice fails with a nonfatal error because PortEnd < PortStart
+	_, okay := pushes["http://5gcore/v1/network-slice/sample-slice"]
+	assert.False(t, okay)
+}
@@ -0,0 +1,42 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package synchronizer implements a synchronizer for converting sdcore gnmi to json
+package synchronizer
+
+import (
+	"sync/atomic"
+
+	"github.com/onosproject/sdcore-adapter/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// pushUpdate pushes an update within a span that is a child of the scope's trace. If the
+// pusher supports it, the trace context is propagated to the endpoint.
+func (s *Synchronizer) pushUpdate(scope *AetherScope, endpoint string, data []byte) (err error) {
+	ctx, span := tracing.StartSpan(scope.Context, "synchronizer.PushUpdate",
+		attribute.String("endpoint", endpoint),
+		attribute.Int("bytes", len(data)))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	atomic.AddUint64(&s.pushCount, 1)
+
+	if contextPusher, okay := s.pusher.(ContextPusherInterface); okay {
+		return contextPusher.PushUpdateWithContext(ctx, endpoint, data)
+	}
+	return s.pusher.PushUpdate(endpoint, data)
+}
+
+// pushDelete pushes a delete within a span that is a child of the scope's trace. If the
+// pusher supports it, the trace context is propagated to the endpoint.
+func (s *Synchronizer) pushDelete(scope *AetherScope, endpoint string) (err error) {
+	ctx, span := tracing.StartSpan(scope.Context, "synchronizer.PushDelete",
+		attribute.String("endpoint", endpoint))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if contextPusher, okay := s.pusher.(ContextPusherInterface); okay {
+		return contextPusher.PushDeleteWithContext(ctx, endpoint)
+	}
+	return s.pusher.PushDelete(endpoint)
+}
@@ -0,0 +1,255 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package synchronizer implements a synchronizer for converting sdcore gnmi to json
+package synchronizer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/onosproject/sdcore-adapter/pkg/gnmi"
+	pb "github.com/openconfig/gnmi/proto/gnmi"
+)
+
+/*
+ * Targeted Resync
+ *
+ * A forced synchronization normally invalidates the whole push cache and re-pushes every
+ * resource of every enterprise. A forced callback whose scope is the paths of a ResyncFilter
+ * (see ResyncFilter.Paths) narrows it: only the push cache entries of the matching resources
+ * are invalidated, and only the matching resources are synchronized.
+ *
+ * An update caused by a gNMI Set is narrowed in the same way, as the scope of its callbacks is
+ * the targets the Set changed.
+ *
+ * If a targeted update is superseded in the queue before it runs, its filter is merged into
+ * the update that supersedes it, so no requested resource is skipped. An unfiltered update
+ * always wins, as it synchronizes everything.
+ */
+
+// ResyncFilter selects the resources to resynchronize. Target and Site narrow the enterprises
+// and sites that are considered. Within them, Slice selects a slice (and its UPF) and
+// DeviceGroup selects a device-group; if neither is set, every resource is selected. Empty
+// fields match everything.
+type ResyncFilter struct {
+	Target      string `json:"target,omitempty"`
+	Site        string `json:"site,omitempty"`
+	Slice       string `json:"slice,omitempty"`
+	DeviceGroup string `json:"device-group,omitempty"`
+}
+
+// IsEmpty returns true if the filter selects every resource
+func (f ResyncFilter) IsEmpty() bool {
+	return f == ResyncFilter{}
+}
+
+func (f ResyncFilter) String() string {
+	parts := []string{}
+	if f.Target != "" {
+		parts = append(parts, "target="+f.Target)
+	}
+	if f.Site != "" {
+		parts = append(parts, "site="+f.Site)
+	}
+	if f.Slice != "" {
+		parts = append(parts, "slice="+f.Slice)
+	}
+	if f.DeviceGroup != "" {
+		parts = append(parts, "device-group="+f.DeviceGroup)
+	}
+	if len(parts) == 0 {
+		return "all"
+	}
+	return strings.Join(parts, ",")
+}
+
+func (f ResyncFilter) matchSite(entID string, site *Site) bool {
+	return ((f.Target == "") || (f.Target == entID)) &&
+		((f.Site == "") || ((site.SiteId != nil) && (f.Site == *site.SiteId)))
+}
+
+func (f ResyncFilter) matchDeviceGroup(entID string, site *Site, dg *DeviceGroup) bool {
+	if !f.matchSite(entID, site) {
+		return false
+	}
+	if (f.Slice == "") && (f.DeviceGroup == "") {
+		return true
+	}
+	return (dg.DeviceGroupId != nil) && (f.DeviceGroup == *dg.DeviceGroupId)
+}
+
+func (f ResyncFilter) matchSlice(entID string, site *Site, slice *Slice) bool {
+	if !f.matchSite(entID, site) {
+		return false
+	}
+	if (f.Slice == "") && (f.DeviceGroup == "") {
+		return true
+	}
+	return (slice.SliceId != nil) && (f.Slice == *slice.SliceId)
+}
+
+// resyncFilters is the set of filters of a synchronization. A resource is synchronized if
+// any filter matches it. A nil set synchronizes every resource.
+type resyncFilters []ResyncFilter
+
+// strings returns the filters as strings, or nil if every resource is selected
+func (fs resyncFilters) strings() []string {
+	if fs == nil {
+		return nil
+	}
+	strs := []string{}
+	for _, f := range fs {
+		strs = append(strs, f.String())
+	}
+	return strs
+}
+
+func (fs resyncFilters) matchEnterprise(entID string) bool {
+	if fs == nil {
+		return true
+	}
+	for _, f := range fs {
+		if (f.Target == "") || (f.Target == entID) {
+			return true
+		}
+	}
+	return false
+}
+
+func (fs resyncFilters) matchDeviceGroup(entID string, site *Site, dg *DeviceGroup) bool {
+	if fs == nil {
+		return true
+	}
+	for _, f := range fs {
+		if f.matchDeviceGroup(entID, site, dg) {
+			return true
+		}
+	}
+	return false
+}
+
+func (fs resyncFilters) matchSlice(entID string, site *Site, slice *Slice) bool {
+	if fs == nil {
+		return true
+	}
+	for _, f := range fs {
+		if f.matchSlice(entID, site, slice) {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeResyncFilters returns the filters that cover the resources of both a and b
+func mergeResyncFilters(a resyncFilters, b resyncFilters) resyncFilters {
+	if (a == nil) || (b == nil) {
+		return nil
+	}
+	merged := resyncFilters{}
+	merged = append(merged, a...)
+	return append(merged, b...)
+}
+
+// Paths returns the paths of the subtrees that the filter selects, as the scope of a callback
+// of the gNMI server (see gnmi.ConfigCallback). A filter of both a slice and a device-group
+// selects two subtrees.
+func (f ResyncFilter) Paths() []*pb.Path {
+	if f.IsEmpty() {
+		return nil
+	}
+	site := &pb.PathElem{Name: "site", Key: map[string]string{"site-id": "*"}}
+	if f.Site != "" {
+		site.Key["site-id"] = f.Site
+	}
+	paths := []*pb.Path{}
+	if f.Slice != "" {
+		paths = append(paths, &pb.Path{Target: f.Target, Elem: []*pb.PathElem{site,
+			{Name: "slice", Key: map[string]string{"slice-id": f.Slice}}}})
+	}
+	if f.DeviceGroup != "" {
+		paths = append(paths, &pb.Path{Target: f.Target, Elem: []*pb.PathElem{site,
+			{Name: "device-group", Key: map[string]string{"device-group-id": f.DeviceGroup}}}})
+	}
+	if len(paths) == 0 {
+		path := &pb.Path{Target: f.Target}
+		if f.Site != "" {
+			path.Elem = []*pb.PathElem{site}
+		}
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+// ResyncFiltersFromScope returns the filters that select the subtrees of scope, the paths
+// passed to a callback of the gNMI server, or nil if every resource is selected. Only the
+// subtrees of targets, sites, slices, and device-groups can be selected.
+func ResyncFiltersFromScope(scope []*pb.Path) ([]ResyncFilter, error) {
+	if scope == nil {
+		return nil, nil
+	}
+	filters := []ResyncFilter{}
+	for _, path := range scope {
+		f := ResyncFilter{Target: path.GetTarget()}
+		if f.Target == gnmi.AllTargets {
+			f.Target = ""
+		}
+		elems := path.GetElem()
+		if len(elems) > 0 {
+			if elems[0].GetName() != "site" || len(elems) > 2 {
+				return nil, fmt.Errorf("Resync of %s is not supported", gnmi.PathToString(path))
+			}
+			f.Site = elems[0].GetKey()["site-id"]
+		}
+		if len(elems) > 1 {
+			switch elems[1].GetName() {
+			case "slice":
+				f.Slice = elems[1].GetKey()["slice-id"]
+			case "device-group":
+				f.DeviceGroup = elems[1].GetKey()["device-group-id"]
+			default:
+				return nil, fmt.Errorf("Resync of %s is not supported", gnmi.PathToString(path))
+			}
+		}
+		for _, value := range []*string{&f.Site, &f.Slice, &f.DeviceGroup} {
+			if *value == "*" {
+				*value = ""
+			}
+		}
+		if f.IsEmpty() {
+			// one of the subtrees is everything
+			return nil, nil
+		}
+		filters = append(filters, f)
+	}
+	return filters, nil
+}
+
+// resyncInvalidate removes the push cache entries of the resources in config that are
+// matched by filters, and returns the number of resources matched.
+func (s *Synchronizer) resyncInvalidate(config *gnmi.ConfigForest, filters resyncFilters) (int, error) {
+	matched := 0
+	for entID, enterpriseConfig := range config.Configs {
+		device, okay := enterpriseConfig.(*RootDevice)
+		if !okay {
+			return 0, fmt.Errorf("Target %s has unexpected configuration type %T", entID, enterpriseConfig)
+		}
+		for _, site := range device.Site {
+			for dgID, dg := range site.DeviceGroup {
+				if filters.matchDeviceGroup(entID, site, dg) {
+					s.CacheDelete(CacheModelDeviceGroup, dgID)
+					matched++
+				}
+			}
+			for sliceID, slice := range site.Slice {
+				if filters.matchSlice(entID, site, slice) {
+					s.CacheDelete(CacheModelSlice, sliceID)
+					s.CacheDelete(CacheModelSliceUpf, sliceID)
+					matched++
+				}
+			}
+		}
+	}
+	return matched, nil
+}
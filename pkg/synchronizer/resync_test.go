@@ -0,0 +1,160 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package synchronizer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/onosproject/sdcore-adapter/pkg/gnmi"
+	"github.com/onosproject/sdcore-adapter/pkg/test/mocks"
+	pb "github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResyncFilterMatch(t *testing.T) {
+	_, device := BuildSampleConfig()
+	site := device.Site["sample-site"]
+	slice := site.Slice["sample-slice"]
+	dg := site.DeviceGroup["sample-dg"]
+
+	all := ResyncFilter{}
+	assert.True(t, all.IsEmpty())
+	assert.True(t, all.matchSlice("sample-ent", site, slice))
+	assert.True(t, all.matchDeviceGroup("sample-ent", site, dg))
+
+	byTarget := ResyncFilter{Target: "sample-ent", Site: "sample-site"}
+	assert.True(t, byTarget.matchSlice("sample-ent", site, slice))
+	assert.True(t, byTarget.matchDeviceGroup("sample-ent", site, dg))
+	assert.False(t, byTarget.matchSlice("other-ent", site, slice))
+	assert.False(t, ResyncFilter{Site: "other-site"}.matchDeviceGroup("sample-ent", site, dg))
+
+	bySlice := ResyncFilter{Slice: "sample-slice"}
+	assert.True(t, bySlice.matchSlice("sample-ent", site, slice))
+	assert.False(t, bySlice.matchDeviceGroup("sample-ent", site, dg))
+	assert.Equal(t, "slice=sample-slice", bySlice.String())
+
+	byDg := ResyncFilter{DeviceGroup: "sample-dg"}
+	assert.False(t, byDg.matchSlice("sample-ent", site, slice))
+	assert.True(t, byDg.matchDeviceGroup("sample-ent", site, dg))
+
+	// A set of filters matches anything matched by one of them, and nil matches everything
+	filters := resyncFilters{bySlice, byDg}
+	assert.True(t, filters.matchSlice("sample-ent", site, slice))
+	assert.True(t, filters.matchDeviceGroup("sample-ent", site, dg))
+	assert.Nil(t, mergeResyncFilters(filters, nil))
+	assert.Len(t, mergeResyncFilters(filters, resyncFilters{byTarget}), 3)
+}
+
+func TestResyncFilterPaths(t *testing.T) {
+	// A filter is passed to the gNMI server's callback as paths, and back
+	for _, f := range []ResyncFilter{
+		{Target: "sample-ent"},
+		{Target: "sample-ent", Site: "sample-site"},
+		{Site: "sample-site", Slice: "sample-slice"},
+		{Target: "sample-ent", DeviceGroup: "sample-dg"},
+	} {
+		filters, err := ResyncFiltersFromScope(f.Paths())
+		assert.Nil(t, err)
+		assert.Equal(t, []ResyncFilter{f}, filters, f.String())
+	}
+	both := ResyncFilter{Target: "sample-ent", Slice: "sample-slice", DeviceGroup: "sample-dg"}
+	filters, err := ResyncFiltersFromScope(both.Paths())
+	assert.Nil(t, err)
+	assert.Equal(t, []ResyncFilter{{Target: "sample-ent", Slice: "sample-slice"}, {Target: "sample-ent", DeviceGroup: "sample-dg"}}, filters)
+
+	// No scope, or a scope that includes a whole forest, selects every resource
+	filters, err = ResyncFiltersFromScope(nil)
+	assert.Nil(t, err)
+	assert.Nil(t, filters)
+	filters, err = ResyncFiltersFromScope([]*pb.Path{{Target: "sample-ent"}, {Target: gnmi.AllTargets}})
+	assert.Nil(t, err)
+	assert.Nil(t, filters)
+
+	_, err = ResyncFiltersFromScope([]*pb.Path{{Target: "sample-ent", Elem: []*pb.PathElem{{Name: "template"}}}})
+	assert.EqualError(t, err, "Resync of template is not supported")
+}
+
+func TestTargetedResync(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockPusher := mocks.NewMockPusherInterface(ctrl)
+	s := NewSynchronizer(WithPusher(mockPusher))
+
+	config, _ := BuildSampleConfig()
+
+	mockPusher.EXPECT().PushUpdate(gomock.Any(), gomock.Any()).Return(nil).Times(3)
+	pushErrors, err := s.SynchronizeDevice(context.Background(), config)
+	assert.Equal(t, 0, pushErrors)
+	assert.Nil(t, err)
+
+	// Only the slice's entries are invalidated, and only the slice and its UPF are pushed
+	filters := resyncFilters{{Slice: "sample-slice"}}
+	matched, err := s.resyncInvalidate(config, filters)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, matched)
+	entries, err := s.CacheList("")
+	assert.Nil(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, CacheModelDeviceGroup, entries[0].Model)
+
+	mockPusher.EXPECT().PushUpdate("http://5gcore/v1/network-slice/sample-slice", gomock.Any()).Return(nil)
+	mockPusher.EXPECT().PushUpdate("http://upf/v1/config/network-slices", gomock.Any()).Return(nil)
+	pushErrors, err = s.SynchronizeDevice(context.Background(), config, filters...)
+	assert.Equal(t, 0, pushErrors)
+	assert.Nil(t, err)
+
+	// The targeted resync is reported apart from the last complete synchronization
+	status := s.GetStatus()
+	require.NotNil(t, status.LastTargetedSync)
+	assert.Equal(t, []string{"slice=sample-slice"}, status.LastTargetedSync.Filters)
+	assert.Equal(t, 2, status.LastTargetedSync.Enterprises["sample-ent"].Attempted)
+	require.NotNil(t, status.LastSync)
+	assert.Empty(t, status.LastSync.Filters)
+	assert.Equal(t, 3, status.LastSync.Enterprises["sample-ent"].Attempted)
+
+	// With partial update disabled, resources that do not match are still not pushed
+	s.options.partialUpdateEnable = false
+	mockPusher.EXPECT().PushUpdate("http://5gcore/v1/device-group/sample-dg", gomock.Any()).Return(nil)
+	pushErrors, err = s.SynchronizeDevice(context.Background(), config, ResyncFilter{DeviceGroup: "sample-dg"})
+	assert.Equal(t, 0, pushErrors)
+	assert.Nil(t, err)
+
+	// A filter that matches nothing is an error
+	err = s.Synchronize(context.Background(), config, gnmi.Forced, "other-ent", nil, ResyncFilter{Target: "other-ent"}.Paths())
+	assert.EqualError(t, err, "No resources match resync filter target=other-ent")
+}
+
+func TestResyncQueueMerge(t *testing.T) {
+	s := NewSynchronizer()
+	config := gnmi.NewConfigForest()
+
+	// Targeted resyncs that supersede each other are merged
+	err := s.enqueue(context.Background(), config, gnmi.Forced, "", resyncFilters{{Slice: "a"}})
+	assert.Nil(t, err)
+	err = s.enqueue(context.Background(), config, gnmi.Forced, "", resyncFilters{{Slice: "b"}})
+	assert.Nil(t, err)
+	update := s.dequeue()
+	s.complete()
+	assert.Equal(t, resyncFilters{{Slice: "b"}, {Slice: "a"}}, update.filters)
+
+	// A targeted resync does not narrow a full update that it supersedes
+	err = s.enqueue(context.Background(), config, gnmi.Apply, "", nil)
+	assert.Nil(t, err)
+	err = s.enqueue(context.Background(), config, gnmi.Forced, "", resyncFilters{{Slice: "a"}})
+	assert.Nil(t, err)
+	update = s.dequeue()
+	s.complete()
+	assert.Nil(t, update.filters)
+	assert.True(t, s.isIdle())
+
+	// An update caused by a Set only synchronizes the targets that the Set changed
+	err = s.Synchronize(context.Background(), config, gnmi.Apply, gnmi.AllTargets, nil, gnmi.TargetPaths([]string{"ent1", "ent2"}))
+	assert.Nil(t, err)
+	update = s.dequeue()
+	s.complete()
+	assert.Equal(t, resyncFilters{{Target: "ent1"}, {Target: "ent2"}}, update.filters)
+}
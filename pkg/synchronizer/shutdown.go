@@ -0,0 +1,99 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package synchronizer implements a synchronizer for converting sdcore gnmi to json
+package synchronizer
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+/*
+ * Graceful Shutdown
+ *
+ * Stop refuses new synchronization requests, stops the Kafka receiver, and lets the update
+ * that is in progress finish its current attempt; no further retries are scheduled. Updates
+ * that are queued but not started are dropped, as aether-config re-sends the configuration
+ * when the adapter restarts. If the update in progress does not finish by the deadline, its
+ * context is cancelled, which aborts any push that is in flight.
+ */
+
+// ErrStopped is returned when a synchronization is requested after Stop
+var ErrStopped = errors.New("Synchronizer is stopped")
+
+// isStopping returns true if Stop has been called
+func (s *Synchronizer) isStopping() bool {
+	return atomic.LoadInt32(&s.stopping) != 0
+}
+
+// startUpdate returns the context for servicing update, which is cancelled if Stop's
+// deadline passes before the update completes.
+func (s *Synchronizer) startUpdate(update *ConfigUpdate) context.Context {
+	ctx, cancel := context.WithCancel(update.ctx)
+	s.stopMu.Lock()
+	s.cancelUpdate = cancel
+	if s.loopCtx.Err() != nil {
+		// leadership was lost, or the synchronizer stopped, as the update was dequeued
+		cancel()
+	}
+	s.stopMu.Unlock()
+	return ctx
+}
+
+// endUpdate releases the context of the update in progress
+func (s *Synchronizer) endUpdate() {
+	s.stopMu.Lock()
+	defer s.stopMu.Unlock()
+	if s.cancelUpdate != nil {
+		s.cancelUpdate()
+		s.cancelUpdate = nil
+	}
+}
+
+// Stop stops the synchronizer, waiting until ctx is done for the update in progress to
+// finish. Returns ctx's error if the update in progress had to be cancelled.
+func (s *Synchronizer) Stop(ctx context.Context) error {
+	if !atomic.CompareAndSwapInt32(&s.stopping, 0, 1) {
+		return nil
+	}
+	log.Infof("Synchronizer stopping")
+	s.stopCancel()
+
+	if dropped := len(s.updateChannel); dropped > 0 {
+		log.Warnf("Dropping %d queued synchronization requests", dropped)
+	}
+
+	s.stopMu.Lock()
+	loopDone := s.loopDone
+	s.stopMu.Unlock()
+	if loopDone == nil {
+		// the loop was never started
+		return nil
+	}
+
+	select {
+	case <-loopDone:
+		log.Infof("Synchronizer stopped")
+		return nil
+	case <-ctx.Done():
+	}
+
+	log.Warnf("Synchronizer did not stop in time; cancelling the update in progress")
+	s.stopMu.Lock()
+	if s.cancelUpdate != nil {
+		s.cancelUpdate()
+	}
+	s.stopMu.Unlock()
+
+	// Pushes honor the cancellation, and are bounded by the post timeout in any case
+	select {
+	case <-loopDone:
+	case <-time.After(s.currentOptions().postTimeout):
+		log.Warnf("Synchronizer loop did not exit after cancellation")
+	}
+	return ctx.Err()
+}
@@ -0,0 +1,100 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package synchronizer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/onosproject/sdcore-adapter/pkg/gnmi"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStopIdle(t *testing.T) {
+	sync := NewSynchronizer()
+	sync.synchronizeDeviceFunc = mockSynchronizeDevice
+	sync.Start()
+	assert.Eventually(t, func() bool { return sync.GetLiveness().Healthy }, 5*time.Second, 10*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	assert.Nil(t, sync.Stop(ctx))
+	assert.False(t, sync.GetLiveness().Healthy)
+
+	err := sync.Synchronize(context.Background(), gnmi.NewConfigForest(), gnmi.Apply, "sample-ent", nil, nil)
+	assert.Equal(t, ErrStopped, err)
+
+	// Stopping twice is harmless
+	assert.Nil(t, sync.Stop(ctx))
+}
+
+func TestStopDrainsUpdateInProgress(t *testing.T) {
+	sync := NewSynchronizer()
+	sync.synchronizeDeviceFunc = mockSynchronizeDevice
+	sync.Start()
+
+	mockSynchronizeDeviceReset(0, 0, 500*time.Millisecond)
+	err := sync.Synchronize(context.Background(), gnmi.NewConfigForest(), gnmi.Apply, "sample-ent", nil, nil)
+	assert.Nil(t, err)
+	assert.Eventually(t, func() bool { return sync.GetStatus().InProgress }, 5*time.Second, 10*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	assert.Nil(t, sync.Stop(ctx))
+	assert.Len(t, mockSynchronizeDeviceCalls, 1)
+}
+
+func TestStopAbandonsRetry(t *testing.T) {
+	sync := NewSynchronizer()
+	sync.synchronizeDeviceFunc = mockSynchronizeDevice
+	sync.options.retryInterval = time.Hour
+	sync.Start()
+
+	mockSynchronizeDeviceReset(0, 1, 0)
+	err := sync.Synchronize(context.Background(), gnmi.NewConfigForest(), gnmi.Apply, "sample-ent", nil, nil)
+	assert.Nil(t, err)
+	assert.Eventually(t, func() bool { return mockSynchronizeDevicePushFailCalls() == 1 }, 5*time.Second, 10*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	assert.Nil(t, sync.Stop(ctx))
+	assert.Empty(t, mockSynchronizeDeviceCalls)
+}
+
+func TestStopCancelsAtDeadline(t *testing.T) {
+	cancelled := make(chan struct{})
+	sync := NewSynchronizer()
+	sync.synchronizeDeviceFunc = func(ctx context.Context, config *gnmi.ConfigForest, filters ...ResyncFilter) (int, error) {
+		<-ctx.Done()
+		close(cancelled)
+		return 1, ctx.Err()
+	}
+	sync.Start()
+
+	err := sync.Synchronize(context.Background(), gnmi.NewConfigForest(), gnmi.Apply, "sample-ent", nil, nil)
+	assert.Nil(t, err)
+	assert.Eventually(t, func() bool { return sync.GetStatus().InProgress }, 5*time.Second, 10*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, sync.Stop(ctx))
+	<-cancelled
+	assert.False(t, sync.GetStatus().InProgress)
+}
+
+func TestStopDropsQueuedUpdate(t *testing.T) {
+	sync := NewSynchronizer()
+	sync.synchronizeDeviceFunc = mockSynchronizeDevice
+	mockSynchronizeDeviceReset(0, 0, 0)
+
+	// An update queued when the loop is stopped is not started, even though the loop could
+	// receive it
+	err := sync.enqueue(context.Background(), gnmi.NewConfigForest(), gnmi.Apply, "sample-ent", nil)
+	assert.Nil(t, err)
+	sync.stopCancel()
+	sync.Loop()
+	assert.Empty(t, mockSynchronizeDeviceCalls)
+}
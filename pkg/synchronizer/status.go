@@ -0,0 +1,177 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package synchronizer implements a synchronizer for converting sdcore gnmi to json
+package synchronizer
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+/*
+ * Synchronizer Status
+ *
+ * Records what the synchronizer is doing, and the outcome of the most recent synchronization,
+ * so that it may be reported by the diagnostic API. The status is written by the synchronizer
+ * loop and read from other goroutines, so all access is through statusMu.
+ */
+
+// Resource kinds reported in the synchronizer status
+const (
+	StatusKindDeviceGroup = "device-group"
+	StatusKindSlice       = "slice"
+	StatusKindSliceUPF    = "slice-upf"
+)
+
+// ResourceFailure describes a resource that failed to synchronize
+type ResourceFailure struct {
+	Kind  string `json:"kind"`
+	ID    string `json:"id"`
+	Error string `json:"error"`
+}
+
+// EnterpriseSyncResult is the outcome of synchronizing the resources of one enterprise
+type EnterpriseSyncResult struct {
+	Attempted int               `json:"attempted"`
+	Pushed    int               `json:"pushed"`
+	Unchanged int               `json:"unchanged"`
+	Failed    int               `json:"failed"`
+	Failures  []ResourceFailure `json:"failures,omitempty"`
+}
+
+// SyncResult is the outcome of a synchronization. Filters are the resync filters of a targeted
+// synchronization, which only synchronized the resources they match.
+type SyncResult struct {
+	Started      time.Time                        `json:"started"`
+	Completed    time.Time                        `json:"completed"`
+	Filters      []string                         `json:"filters,omitempty"`
+	PushFailures int                              `json:"push-failures"`
+	Error        string                           `json:"error,omitempty"`
+	Enterprises  map[string]*EnterpriseSyncResult `json:"enterprises"`
+}
+
+// RetryStatus describes whether the synchronizer is retrying a synchronization that failed to push
+type RetryStatus struct {
+	Retrying  bool       `json:"retrying"`
+	Attempt   int        `json:"attempt"`
+	Interval  string     `json:"interval"`
+	NextRetry *time.Time `json:"next-retry,omitempty"`
+}
+
+// Status is a snapshot of the state of the synchronizer
+type Status struct {
+	Role            string      `json:"role,omitempty"`
+	QueueDepth      int         `json:"queue-depth"`
+	Busy            int32       `json:"busy"`
+	InProgress      bool        `json:"in-progress"`
+	InProgressSince *time.Time  `json:"in-progress-since,omitempty"`
+	LastSync        *SyncResult `json:"last-sync,omitempty"`
+	// LastTargetedSync is the most recent targeted resync, or synchronization of the targets
+	// changed by a Set, which is kept apart from the last complete synchronization, as it only
+	// covers some of the resources
+	LastTargetedSync *SyncResult `json:"last-targeted-sync,omitempty"`
+	Retry            RetryStatus `json:"retry"`
+}
+
+// GetStatus returns a snapshot of the synchronizer's status
+func (s *Synchronizer) GetStatus() Status {
+	retryInterval := s.currentOptions().retryInterval
+
+	s.statusMu.Lock()
+	defer s.statusMu.Unlock()
+
+	status := Status{
+		QueueDepth:       len(s.updateChannel),
+		Busy:             atomic.LoadInt32(&s.busy),
+		InProgress:       s.inProgressSince != nil,
+		LastSync:         s.lastSync,
+		LastTargetedSync: s.lastTargetedSync,
+		Retry:            s.retry,
+	}
+	if s.electionEnable {
+		status.Role = RoleLeader
+		if s.standby {
+			status.Role = RoleStandby
+		}
+	}
+	if s.inProgressSince != nil {
+		since := *s.inProgressSince
+		status.InProgressSince = &since
+	}
+	status.Retry.Interval = retryInterval.String()
+	return status
+}
+
+// setInProgress records whether the synchronizer loop is servicing an update
+func (s *Synchronizer) setInProgress(inProgress bool) {
+	s.statusMu.Lock()
+	defer s.statusMu.Unlock()
+
+	if !inProgress {
+		s.inProgressSince = nil
+		return
+	}
+	now := time.Now()
+	s.inProgressSince = &now
+}
+
+// setRetry records the retry state. A nextRetry of zero means no retry is scheduled.
+func (s *Synchronizer) setRetry(attempt int, nextRetry time.Time) {
+	s.statusMu.Lock()
+	defer s.statusMu.Unlock()
+
+	s.retry = RetryStatus{Attempt: attempt}
+	if !nextRetry.IsZero() {
+		s.retry.Retrying = true
+		s.retry.NextRetry = &nextRetry
+	}
+}
+
+// setLastSync records the outcome of a completed synchronization, as the last targeted resync
+// if it has filters. The result must not be modified after it has been recorded.
+func (s *Synchronizer) setLastSync(result *SyncResult) {
+	s.statusMu.Lock()
+	defer s.statusMu.Unlock()
+
+	if len(result.Filters) > 0 {
+		s.lastTargetedSync = result
+		return
+	}
+	s.lastSync = result
+}
+
+// setLastSyncError records a fatal error from the most recent synchronization, which was
+// targeted if filters is not nil
+func (s *Synchronizer) setLastSyncError(filters resyncFilters, err error) {
+	s.statusMu.Lock()
+	defer s.statusMu.Unlock()
+
+	last := &s.lastSync
+	if filters != nil {
+		last = &s.lastTargetedSync
+	}
+	result := SyncResult{Completed: time.Now(), Filters: filters.strings(), Enterprises: map[string]*EnterpriseSyncResult{}}
+	if *last != nil {
+		result = **last
+	}
+	result.Error = err.Error()
+	*last = &result
+}
+
+// recordResource adds the outcome of synchronizing one resource to an enterprise's result.
+// pushCount is the value of s.pushCount before the resource was synchronized, and is used
+// to tell whether the resource was pushed or was unchanged.
+func (s *Synchronizer) recordResource(result *EnterpriseSyncResult, kind string, id string, pushCount uint64, err error) {
+	result.Attempted++
+	switch {
+	case err != nil:
+		result.Failed++
+		result.Failures = append(result.Failures, ResourceFailure{Kind: kind, ID: id, Error: err.Error()})
+	case atomic.LoadUint64(&s.pushCount) != pushCount:
+		result.Pushed++
+	default:
+		result.Unchanged++
+	}
+}
@@ -0,0 +1,133 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package synchronizer
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/onosproject/sdcore-adapter/pkg/gnmi"
+	"github.com/onosproject/sdcore-adapter/pkg/test/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatusSynchronizeDevice(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockPusher := mocks.NewMockPusherInterface(ctrl)
+	s := NewSynchronizer(WithPusher(mockPusher))
+
+	config, device := BuildSampleConfig()
+
+	upfFails := true
+	mockPusher.EXPECT().PushUpdate("http://5gcore/v1/device-group/sample-dg", gomock.Any()).Return(nil).AnyTimes()
+	mockPusher.EXPECT().PushUpdate("http://5gcore/v1/network-slice/sample-slice", gomock.Any()).Return(nil).AnyTimes()
+	mockPusher.EXPECT().PushUpdate("http://upf/v1/config/network-slices", gomock.Any()).DoAndReturn(func(endpoint string, data []byte) error {
+		if upfFails {
+			return errors.New("upf is down")
+		}
+		return nil
+	}).AnyTimes()
+
+	status := s.GetStatus()
+	assert.Nil(t, status.LastSync)
+	assert.False(t, status.InProgress)
+
+	// Everything is pushed, but the UPF fails
+	pushErrors, err := s.SynchronizeDevice(context.Background(), config)
+	assert.Equal(t, 1, pushErrors)
+	assert.Nil(t, err)
+
+	status = s.GetStatus()
+	require.NotNil(t, status.LastSync)
+	assert.Equal(t, 1, status.LastSync.PushFailures)
+	assert.False(t, status.LastSync.Completed.Before(status.LastSync.Started))
+	ent := status.LastSync.Enterprises["sample-ent"]
+	require.NotNil(t, ent)
+	assert.Equal(t, 3, ent.Attempted)
+	assert.Equal(t, 2, ent.Pushed)
+	assert.Equal(t, 0, ent.Unchanged)
+	assert.Equal(t, 1, ent.Failed)
+	require.Len(t, ent.Failures, 1)
+	assert.Equal(t, StatusKindSliceUPF, ent.Failures[0].Kind)
+	assert.Equal(t, "sample-slice", ent.Failures[0].ID)
+	assert.Contains(t, ent.Failures[0].Error, "upf is down")
+
+	// The core resources are unchanged, and the UPF is retried
+	upfFails = false
+	pushErrors, err = s.SynchronizeDevice(context.Background(), config)
+	assert.Equal(t, 0, pushErrors)
+	assert.Nil(t, err)
+
+	ent = s.GetStatus().LastSync.Enterprises["sample-ent"]
+	assert.Equal(t, 3, ent.Attempted)
+	assert.Equal(t, 1, ent.Pushed)
+	assert.Equal(t, 2, ent.Unchanged)
+	assert.Equal(t, 0, ent.Failed)
+	assert.Empty(t, ent.Failures)
+
+	// A resource that fails to translate is reported as failed
+	device.Site["sample-site"].Slice["sample-slice"].DefaultBehavior = aStr("BOGUS")
+	_, err = s.SynchronizeDevice(context.Background(), config)
+	assert.Nil(t, err)
+
+	ent = s.GetStatus().LastSync.Enterprises["sample-ent"]
+	assert.Equal(t, 2, ent.Attempted)
+	assert.Equal(t, 1, ent.Unchanged)
+	assert.Equal(t, 1, ent.Failed)
+	assert.Equal(t, StatusKindSlice, ent.Failures[0].Kind)
+}
+
+func TestStatusLoop(t *testing.T) {
+	sync := NewSynchronizer()
+	config := gnmi.NewConfigForest()
+
+	sync.options.retryInterval = 100 * time.Millisecond
+	sync.synchronizeDeviceFunc = mockSynchronizeDevice
+	sync.Start()
+
+	// A slow synchronization is reported as in progress
+	mockSynchronizeDeviceReset(0, 0, 300*time.Millisecond)
+	err := sync.Synchronize(context.Background(), config, gnmi.Apply, "sample-ent", nil, nil)
+	assert.Nil(t, err)
+	time.Sleep(100 * time.Millisecond)
+	status := sync.GetStatus()
+	assert.True(t, status.InProgress)
+	require.NotNil(t, status.InProgressSince)
+	assert.Equal(t, int32(1), status.Busy)
+	assert.Equal(t, "100ms", status.Retry.Interval)
+	waitForSyncIdle(t, sync, 5*time.Second)
+	status = sync.GetStatus()
+	assert.False(t, status.InProgress)
+	assert.Nil(t, status.InProgressSince)
+	assert.Equal(t, int32(0), status.Busy)
+
+	// A push failure schedules a retry
+	mockSynchronizeDeviceReset(0, 1, 0)
+	sync.options.retryInterval = 500 * time.Millisecond
+	err = sync.Synchronize(context.Background(), config, gnmi.Apply, "sample-ent", nil, nil)
+	assert.Nil(t, err)
+	time.Sleep(100 * time.Millisecond)
+	status = sync.GetStatus()
+	assert.True(t, status.Retry.Retrying)
+	assert.Equal(t, 1, status.Retry.Attempt)
+	assert.NotNil(t, status.Retry.NextRetry)
+	waitForSyncIdle(t, sync, 5*time.Second)
+	status = sync.GetStatus()
+	assert.False(t, status.Retry.Retrying)
+	assert.Nil(t, status.Retry.NextRetry)
+
+	// A fatal error is recorded in the last sync
+	mockSynchronizeDeviceReset(1, 0, 0)
+	err = sync.Synchronize(context.Background(), config, gnmi.Apply, "sample-ent", nil, nil)
+	assert.Nil(t, err)
+	waitForSyncIdle(t, sync, 5*time.Second)
+	status = sync.GetStatus()
+	require.NotNil(t, status.LastSync)
+	assert.Equal(t, "Mock error", status.LastSync.Error)
+}
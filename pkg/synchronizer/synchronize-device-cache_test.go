@@ -5,6 +5,7 @@
 package synchronizer
 
 import (
+	"context"
 	"github.com/golang/mock/gomock"
 	"github.com/onosproject/sdcore-adapter/pkg/test/mocks"
 	"github.com/stretchr/testify/assert"
@@ -60,14 +61,14 @@ func TestSynchronizeDeviceDeviceGroupCacheTest(t *testing.T) {
 		return nil
 	}).AnyTimes()
 
-	pushErrors, err := s.SynchronizeDevice(config)
+	pushErrors, err := s.SynchronizeDevice(context.Background(), config)
 	assert.Equal(t, 0, pushErrors)
 	assert.Nil(t, err)
 	assert.Equal(t, len(pushes), 1)
 	require.JSONEq(t, jsonData, pushes[0])
 
 	// push it again, should not be any new pushes
-	pushErrors, err = s.SynchronizeDevice(config)
+	pushErrors, err = s.SynchronizeDevice(context.Background(), config)
 	assert.Equal(t, 0, pushErrors)
 	assert.Nil(t, err)
 	assert.Equal(t, len(pushes), 1)
@@ -103,7 +104,7 @@ func TestSynchronizeDeviceDeviceGroupCacheTest(t *testing.T) {
 		}`
 
 	// push it again, this time we should get a new push
-	pushErrors, err = s.SynchronizeDevice(config)
+	pushErrors, err = s.SynchronizeDevice(context.Background(), config)
 	assert.Equal(t, 0, pushErrors)
 	assert.Nil(t, err)
 	assert.Equal(t, len(pushes), 2)
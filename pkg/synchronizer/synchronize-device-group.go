@@ -8,6 +8,7 @@ package synchronizer
 import (
 	"encoding/json"
 	"fmt"
+	"net/netip"
 	"sort"
 )
 
@@ -23,6 +24,11 @@ func (s *Synchronizer) SynchronizeDeviceGroup(scope *AetherScope, dg *DeviceGrou
 		SiteInfo:     *scope.Site.SiteId,
 	}
 
+	// Without an ImsiDefinition, IMSIs cannot be canonicalized, and are passed on as configured
+	if scope.Site.ImsiDefinition == nil {
+		log.Warnf("DeviceGroup %s Site %s has no ImsiDefinition; IMSIs are not canonicalized", *dg.DeviceGroupId, *scope.Site.SiteId)
+	}
+
 	// be deterministic...
 	deviceLinkKeys := []string{}
 	for k := range dg.Device {
@@ -65,7 +71,22 @@ func (s *Synchronizer) SynchronizeDeviceGroup(scope *AetherScope, dg *DeviceGrou
 			log.Infof("SimCard %s with IMSI %s is disabled", *simCard.SimId, *simCard.Imsi)
 			continue
 		}
-		dgCore.Imsis = append(dgCore.Imsis, *simCard.Imsi)
+
+		if scope.Site.ImsiDefinition == nil {
+			dgCore.Imsis = append(dgCore.Imsis, *simCard.Imsi)
+			continue
+		}
+
+		imsi, foreign, err := CanonicalizeImsiDef(scope.Site.ImsiDefinition, *simCard.Imsi)
+		if err != nil {
+			return 0, fmt.Errorf("DeviceGroup %s SimCard %s has invalid IMSI: %s", *dg.DeviceGroupId, *simCard.SimId, err)
+		}
+		if foreign && !s.scopeOptions(scope).foreignImsiEnable {
+			log.Warnf("SimCard %s with IMSI %s does not match the PLMN of Site %s; dropping it", *simCard.SimId, imsi, *scope.Site.SiteId)
+			continue
+		}
+
+		dgCore.Imsis = append(dgCore.Imsis, imsi)
 	}
 
 	ipd, err := s.GetIPDomain(scope, dg.IpDomain)
@@ -78,10 +99,13 @@ func (s *Synchronizer) SynchronizeDeviceGroup(scope *AetherScope, dg *DeviceGrou
 		return 0, fmt.Errorf("DeviceGroup %s IPDomain %s is invalid: %s", *dg.DeviceGroupId, *ipd.IpDomainId, err)
 	}
 
+	// validateIPDomain has already checked that the subnet parses
+	pool, _ := netip.ParsePrefix(*ipd.Subnet)
+
 	dgCore.IPDomainName = *ipd.IpDomainId
 	ipdCore := ipDomain{
 		Dnn:          DerefStrPtr(ipd.Dnn, "internet"),
-		Pool:         *ipd.Subnet,
+		Pool:         pool.String(),
 		DNSPrimary:   DerefStrPtr(ipd.DnsPrimary, ""),
 		DNSSecondary: DerefStrPtr(ipd.DnsSecondary, ""),
 		Mtu:          DerefUint16Ptr(ipd.Mtu, DefaultMTU),
@@ -103,7 +127,7 @@ func (s *Synchronizer) SynchronizeDeviceGroup(scope *AetherScope, dg *DeviceGrou
 		ARP:  DerefUint8Ptr(rocTrafficClass.Arp, 9)}
 	dgCore.IPDomain.Qos.TrafficClass = tcCore
 
-	if s.partialUpdateEnable && s.CacheCheck(CacheModelDeviceGroup, *dg.DeviceGroupId, dgCore) {
+	if s.scopeOptions(scope).partialUpdateEnable && s.CacheCheck(CacheModelDeviceGroup, *dg.DeviceGroupId, dgCore) {
 		log.Infof("Core Device-Group %s has not changed", *dg.DeviceGroupId)
 		return 0, nil
 	}
@@ -118,7 +142,7 @@ func (s *Synchronizer) SynchronizeDeviceGroup(scope *AetherScope, dg *DeviceGrou
 	}
 
 	url := fmt.Sprintf("%s/v1/device-group/%s", *scope.CoreEndpoint, *dg.DeviceGroupId)
-	err = s.pusher.PushUpdate(url, data)
+	err = s.pushUpdate(scope, url, data)
 	if err != nil {
 		return 1, fmt.Errorf("DeviceGroup %s failed to Push update: %s", *dg.DeviceGroupId, err)
 	}
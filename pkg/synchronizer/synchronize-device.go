@@ -6,9 +6,15 @@
 package synchronizer
 
 import (
+	"context"
 	"fmt"
-	"github.com/onosproject/sdcore-adapter/pkg/gnmi"
+	"sync/atomic"
 	"time"
+
+	"github.com/onosproject/sdcore-adapter/pkg/gnmi"
+	"github.com/onosproject/sdcore-adapter/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // updateScopeFromSlice uses a slice object to determine the Generation (4G|5G) and the
@@ -62,28 +68,59 @@ func (s *Synchronizer) updateScopeFromDeviceGroup(scope *AetherScope, dg *Device
 // SynchronizeDevice synchronizes a device. Two sets of error state are returned:
 //  1. pushFailures -- a count of pushes that failed to the core. Synchronizer should retry again later.
 //  2. error -- a fatal error that occurred during synchronization.
-func (s *Synchronizer) SynchronizeDevice(allConfig *gnmi.ConfigForest) (int, error) {
+//
+// Spans are recorded for the device and for each resource, as children of any trace in ctx.
+// If filters are given, only the resources they match are synchronized.
+func (s *Synchronizer) SynchronizeDevice(ctx context.Context, allConfig *gnmi.ConfigForest, selected ...ResyncFilter) (int, error) {
+	ctx, span := tracing.StartSpan(ctx, "synchronizer.SynchronizeDevice")
+	defer span.End()
 
-	// Forget all current metrics. We'll compute and report them inside the sync loop.
-	KpiSliceBitrate.Reset()
-	KpiApplicationBitrate.Reset()
-	KpiDeviceGroupBitrate.Reset()
+	filters := resyncFilters(selected)
+	// Reconfigure may change the options during the attempt; it works from a copy
+	opts := s.currentOptions()
+
+	if (filters == nil) && !s.metricsDisable {
+		// Forget all current metrics. We'll compute and report them inside the sync loop.
+		// A targeted resync leaves the metrics of the resources it skips alone.
+		KpiSliceBitrate.Reset()
+		KpiApplicationBitrate.Reset()
+		KpiDeviceGroupBitrate.Reset()
+	}
+
+	result := &SyncResult{
+		Started:     time.Now(),
+		Filters:     filters.strings(),
+		Enterprises: map[string]*EnterpriseSyncResult{},
+	}
 
 	pushFailures := 0
 	for entID, enterpriseConfig := range allConfig.Configs {
 		device := enterpriseConfig.(*RootDevice)
+		if !filters.matchEnterprise(entID) {
+			continue
+		}
 
 		tStart := time.Now()
-		KpiSynchronizationTotal.WithLabelValues(entID).Inc()
+		s.reportSynchronization(entID)
+
+		entCtx, entSpan := tracing.StartSpan(ctx, "synchronizer.SynchronizeEnterprise",
+			attribute.String("enterprise", entID))
 
 		scope := &AetherScope{
 			EnterpriseId: &entID,
-			Enterprise:   device}
+			Enterprise:   device,
+			options:      &opts}
+
+		entResult := &EnterpriseSyncResult{}
+		result.Enterprises[entID] = entResult
 
 		for _, site := range device.Site {
 			scope.Site = site
 		dgLoop:
 			for _, dg := range site.DeviceGroup {
+				if !filters.matchDeviceGroup(entID, site, dg) {
+					continue dgLoop
+				}
 				err := s.updateScopeFromDeviceGroup(scope, dg)
 				if err != nil {
 					log.Warnf("DG %s error while resolving core endpoint: %s", *dg.DeviceGroupId, err)
@@ -94,19 +131,29 @@ func (s *Synchronizer) SynchronizeDevice(allConfig *gnmi.ConfigForest) (int, err
 					log.Infof("DG %s is not related to any core: %s", *dg.DeviceGroupId, err)
 					continue dgLoop
 				}
-				KpiSynchronizationResourceTotal.WithLabelValues(entID, "device-group").Inc()
+				s.reportSynchronizationResource(entID, "device-group")
+				var dgSpan trace.Span
+				scope.Context, dgSpan = tracing.StartSpan(entCtx, "synchronizer.SynchronizeDeviceGroup",
+					attribute.String("device-group", *dg.DeviceGroupId))
+				pushCount := atomic.LoadUint64(&s.pushCount)
 				dgPushErrors, err := s.SynchronizeDeviceGroup(scope, dg)
+				tracing.EndSpan(dgSpan, err)
+				s.recordResource(entResult, StatusKindDeviceGroup, *dg.DeviceGroupId, pushCount, err)
 				pushFailures += dgPushErrors
 				if err != nil {
 					log.Warnf("DG %s failed to synchronize Core: %s", *dg.DeviceGroupId, err)
-					KpiSynchronizationFailedTotal.WithLabelValues(entID, "device-group", "core").Inc()
+					s.reportSynchronizationFailed(entID, "device-group", "core")
 				}
 			}
 		sliceLoop:
 			for _, slice := range site.Slice {
+				if !filters.matchSlice(entID, site, slice) {
+					continue sliceLoop
+				}
 				err := s.updateScopeFromSlice(scope, slice)
 				if err != nil {
-					log.Warnf("DG %s error while resolving core endpoint: %s", *slice.SliceId, err)
+					log.Warnf("Slice %s error while resolving core endpoint: %s", *slice.SliceId, err)
+					s.recordResource(entResult, StatusKindSlice, *slice.SliceId, atomic.LoadUint64(&s.pushCount), err)
 					continue sliceLoop
 				}
 				if scope.CoreEndpoint == nil {
@@ -114,27 +161,44 @@ func (s *Synchronizer) SynchronizeDevice(allConfig *gnmi.ConfigForest) (int, err
 					log.Warnf("Slice %s is not related to any core: %s", *slice.SliceId, err)
 					continue sliceLoop
 				}
-				KpiSynchronizationResourceTotal.WithLabelValues(entID, "slice").Inc()
+				s.reportSynchronizationResource(entID, "slice")
+				var sliceSpan trace.Span
+				scope.Context, sliceSpan = tracing.StartSpan(entCtx, "synchronizer.SynchronizeSlice",
+					attribute.String("slice", *slice.SliceId))
+				pushCount := atomic.LoadUint64(&s.pushCount)
 				slicePushFailures, err := s.SynchronizeSlice(scope, slice)
+				tracing.EndSpan(sliceSpan, err)
+				s.recordResource(entResult, StatusKindSlice, *slice.SliceId, pushCount, err)
 				pushFailures += slicePushFailures
 				if err != nil {
 					log.Warnf("VCS %s failed to synchronize Core: %s", *slice.SliceId, err)
-					KpiSynchronizationFailedTotal.WithLabelValues(entID, "slice", "core").Inc()
+					s.reportSynchronizationFailed(entID, "slice", "core")
 					// Do not try to synchronize the UPF, if we've already failed
 					continue sliceLoop
 				}
 
+				var upfSpan trace.Span
+				scope.Context, upfSpan = tracing.StartSpan(entCtx, "synchronizer.SynchronizeSliceUPF",
+					attribute.String("slice", *slice.SliceId))
+				pushCount = atomic.LoadUint64(&s.pushCount)
 				upfPushFailures, err := s.SynchronizeSliceUPF(scope, slice)
+				tracing.EndSpan(upfSpan, err)
+				s.recordResource(entResult, StatusKindSliceUPF, *slice.SliceId, pushCount, err)
 				pushFailures += upfPushFailures
 				if err != nil {
 					log.Warnf("Slice %s failed to synchronize UPF: %s", *slice.SliceId, err)
-					KpiSynchronizationFailedTotal.WithLabelValues(entID, "slice", "upf").Inc()
+					s.reportSynchronizationFailed(entID, "slice", "upf")
 				}
 			}
 		}
 
-		KpiSynchronizationDuration.WithLabelValues(entID).Observe(time.Since(tStart).Seconds())
+		s.reportSynchronizationDuration(entID, time.Since(tStart))
+		entSpan.End()
 	}
 
+	result.Completed = time.Now()
+	result.PushFailures = pushFailures
+	s.setLastSync(result)
+
 	return pushFailures, nil
 }
@@ -5,6 +5,7 @@
 package synchronizer
 
 import (
+	"context"
 	"github.com/golang/mock/gomock"
 	"github.com/onosproject/sdcore-adapter/pkg/test/mocks"
 	"github.com/stretchr/testify/assert"
@@ -37,7 +38,7 @@ func TestSynchronizeDeviceDeviceGroupWithQos(t *testing.T) {
 		return nil
 	}).AnyTimes()
 
-	pushErrors, err := s.SynchronizeDevice(config)
+	pushErrors, err := s.SynchronizeDevice(context.Background(), config)
 	assert.Equal(t, 0, pushErrors)
 	assert.Nil(t, err)
 
@@ -74,7 +75,7 @@ func TestSynchronizeDeviceDeviceGroupWithQosSpecifiedPelrPDB(t *testing.T) {
 		return nil
 	}).AnyTimes()
 
-	pushErrors, err := s.SynchronizeDevice(config)
+	pushErrors, err := s.SynchronizeDevice(context.Background(), config)
 	assert.Equal(t, 0, pushErrors)
 	assert.Nil(t, err)
 
@@ -95,7 +96,7 @@ func TestSynchronizeDeviceDeviceGroupWithQosButNoTC(t *testing.T) {
 	config, device := BuildSampleConfig()
 	device.TrafficClass = nil
 
-	pushErrors, err := s.SynchronizeDevice(config)
+	pushErrors, err := s.SynchronizeDevice(context.Background(), config)
 	assert.Equal(t, 0, pushErrors)
 	assert.Nil(t, err)
 
@@ -143,7 +144,7 @@ func TestSynchronizeDeviceDeviceGroupManyIMSIDeterministic(t *testing.T) {
 		s.CacheInvalidate()
 
 		// Do a push
-		pushErrors, err := s.SynchronizeDevice(device)
+		pushErrors, err := s.SynchronizeDevice(context.Background(), device)
 		assert.Equal(t, 0, pushErrors)
 		assert.Nil(t, err)
 	}
@@ -185,7 +186,7 @@ func TestSynchronizeDeviceDeviceGroupLinkedToVCS(t *testing.T) {
 		return nil
 	}).AnyTimes()
 
-	pushErrors, err := s.SynchronizeDevice(config)
+	pushErrors, err := s.SynchronizeDevice(context.Background(), config)
 	assert.Equal(t, 0, pushErrors)
 	assert.Nil(t, err)
 
@@ -233,7 +234,7 @@ func TestSynchronizeVCS(t *testing.T) {
 		pushes[endpoint] = string(data)
 		return nil
 	}).AnyTimes()
-	pushErrors, err := s.SynchronizeDevice(config)
+	pushErrors, err := s.SynchronizeDevice(context.Background(), config)
 	assert.Equal(t, 0, pushErrors)
 	assert.Nil(t, err)
 
@@ -282,7 +283,7 @@ func TestSynchronizeVCSSSTLeadingZero(t *testing.T) {
 		pushes[endpoint] = string(data)
 		return nil
 	}).AnyTimes()
-	pushErrors, err := s.SynchronizeDevice(config)
+	pushErrors, err := s.SynchronizeDevice(context.Background(), config)
 	assert.Equal(t, 0, pushErrors)
 	assert.Nil(t, err)
 
@@ -330,7 +331,7 @@ func TestSynchronizeVCSIMSILeadingZero(t *testing.T) {
 		pushes[endpoint] = string(data)
 		return nil
 	}).AnyTimes()
-	pushErrors, err := s.SynchronizeDevice(config)
+	pushErrors, err := s.SynchronizeDevice(context.Background(), config)
 	assert.Equal(t, 0, pushErrors)
 	assert.Nil(t, err)
 
@@ -351,6 +352,118 @@ func TestSynchronizeVCSIMSILeadingZero(t *testing.T) {
 	}
 }
 
+func TestSynchronizeVCSIMSIShortSubscriber(t *testing.T) {
+	jsonDataDg, err := os.ReadFile("./testdata/sample-dg-imsi-short.json")
+	assert.NoError(t, err)
+	ctrl := gomock.NewController(t)
+	mockPusher := mocks.NewMockPusherInterface(ctrl)
+	pushes := make(map[string]string)
+	s := NewSynchronizer(WithPusher(mockPusher))
+
+	config, device := BuildSampleConfig()
+	device.Site["sample-site"].SimCard["sample-sim"].Imsi = aStr("42")
+
+	mockPusher.EXPECT().PushUpdate(gomock.Any(), gomock.Any()).DoAndReturn(func(endpoint string, data []byte) error {
+		pushes[endpoint] = string(data)
+		return nil
+	}).AnyTimes()
+	pushErrors, err := s.SynchronizeDevice(context.Background(), config)
+	assert.Equal(t, 0, pushErrors)
+	assert.Nil(t, err)
+
+	json, okay := pushes["http://5gcore/v1/device-group/sample-dg"]
+	assert.True(t, okay)
+	if okay {
+		require.JSONEq(t, string(jsonDataDg), json)
+	}
+}
+
+func TestSynchronizeVCSIMSIForeignDropped(t *testing.T) {
+	jsonDataDg, err := os.ReadFile("./testdata/sample-dg-sim-disabled.json")
+	assert.NoError(t, err)
+	jsonDataDgForeign, err := os.ReadFile("./testdata/sample-dg-imsi-leading-zero.json")
+	assert.NoError(t, err)
+	ctrl := gomock.NewController(t)
+	mockPusher := mocks.NewMockPusherInterface(ctrl)
+	pushes := make(map[string]string)
+
+	mockPusher.EXPECT().PushUpdate(gomock.Any(), gomock.Any()).DoAndReturn(func(endpoint string, data []byte) error {
+		pushes[endpoint] = string(data)
+		return nil
+	}).AnyTimes()
+
+	// With foreign IMSIs disabled, the IMSI is dropped from the device-group
+	s := NewSynchronizer(WithPusher(mockPusher), WithForeignImsiEnable(false))
+	config, device := BuildSampleConfig()
+	device.Site["sample-site"].SimCard["sample-sim"].Imsi = aStr("012345678901234")
+	pushErrors, err := s.SynchronizeDevice(context.Background(), config)
+	assert.Equal(t, 0, pushErrors)
+	assert.Nil(t, err)
+
+	json, okay := pushes["http://5gcore/v1/device-group/sample-dg"]
+	assert.True(t, okay)
+	if okay {
+		require.JSONEq(t, string(jsonDataDg), json)
+	}
+
+	// With foreign IMSIs enabled, the IMSI is retained
+	s = NewSynchronizer(WithPusher(mockPusher), WithForeignImsiEnable(true))
+	pushErrors, err = s.SynchronizeDevice(context.Background(), config)
+	assert.Equal(t, 0, pushErrors)
+	assert.Nil(t, err)
+
+	json, okay = pushes["http://5gcore/v1/device-group/sample-dg"]
+	assert.True(t, okay)
+	if okay {
+		require.JSONEq(t, string(jsonDataDgForeign), json)
+	}
+}
+
+func TestSynchronizeVCSNoImsiDefinition(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockPusher := mocks.NewMockPusherInterface(ctrl)
+	pushes := make(map[string]string)
+	s := NewSynchronizer(WithPusher(mockPusher))
+
+	config, device := BuildSampleConfig()
+	device.Site["sample-site"].ImsiDefinition = nil
+	device.Site["sample-site"].SimCard["sample-sim"].Imsi = aStr("42")
+
+	mockPusher.EXPECT().PushUpdate(gomock.Any(), gomock.Any()).DoAndReturn(func(endpoint string, data []byte) error {
+		pushes[endpoint] = string(data)
+		return nil
+	}).AnyTimes()
+	_, err := s.SynchronizeDevice(context.Background(), config)
+	assert.Nil(t, err)
+
+	// Without an ImsiDefinition the device-group is still pushed, with the IMSI as configured
+	json, okay := pushes["http://5gcore/v1/device-group/sample-dg"]
+	assert.True(t, okay)
+	assert.Contains(t, json, `"42"`)
+}
+
+func TestSynchronizeVCSIMSIInvalid(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockPusher := mocks.NewMockPusherInterface(ctrl)
+	pushes := make(map[string]string)
+	s := NewSynchronizer(WithPusher(mockPusher))
+
+	config, device := BuildSampleConfig()
+	device.Site["sample-site"].SimCard["sample-sim"].Imsi = aStr("12345678901234X")
+
+	mockPusher.EXPECT().PushUpdate(gomock.Any(), gomock.Any()).DoAndReturn(func(endpoint string, data []byte) error {
+		pushes[endpoint] = string(data)
+		return nil
+	}).AnyTimes()
+	pushErrors, err := s.SynchronizeDevice(context.Background(), config)
+	assert.Equal(t, 0, pushErrors)
+	assert.Nil(t, err)
+
+	// The above will fail synchronization of the DG with a nonfatal error because the IMSI is malformed
+	_, okay := pushes["http://5gcore/v1/device-group/sample-dg"]
+	assert.False(t, okay)
+}
+
 func TestSynchronizeVCSDisabledSimCard(t *testing.T) {
 	jsonDataDg, err := os.ReadFile("./testdata/sample-dg-sim-disabled.json")
 	assert.NoError(t, err)
@@ -378,7 +491,7 @@ func TestSynchronizeVCSDisabledSimCard(t *testing.T) {
 		pushes[endpoint] = string(data)
 		return nil
 	}).AnyTimes()
-	pushErrors, err := s.SynchronizeDevice(config)
+	pushErrors, err := s.SynchronizeDevice(context.Background(), config)
 	assert.Equal(t, 0, pushErrors)
 	assert.Nil(t, err)
 
@@ -426,7 +539,7 @@ func TestSynchronizeVCSAllowAll(t *testing.T) {
 		pushes[endpoint] = string(data)
 		return nil
 	}).AnyTimes()
-	pushErrors, err := s.SynchronizeDevice(config)
+	pushErrors, err := s.SynchronizeDevice(context.Background(), config)
 	assert.Equal(t, 0, pushErrors)
 	assert.Nil(t, err)
 
@@ -475,7 +588,7 @@ func TestSynchronizeVCSAllowPublic(t *testing.T) {
 		pushes[endpoint] = string(data)
 		return nil
 	}).AnyTimes()
-	pushErrors, err := s.SynchronizeDevice(config)
+	pushErrors, err := s.SynchronizeDevice(context.Background(), config)
 	assert.Equal(t, 0, pushErrors)
 	assert.Nil(t, err)
 
@@ -496,6 +609,61 @@ func TestSynchronizeVCSAllowPublic(t *testing.T) {
 	}
 }
 
+func TestSynchronizeVCSIPv6(t *testing.T) {
+	jsonDataCoreSlice, err := os.ReadFile("./testdata/sample-coreslice-ipv6.json")
+	assert.NoError(t, err)
+	ctrl := gomock.NewController(t)
+	mockPusher := mocks.NewMockPusherInterface(ctrl)
+	pushes := make(map[string]string)
+	s := NewSynchronizer(WithPusher(mockPusher))
+
+	config, device := BuildSampleConfig()
+	device.Site["sample-site"].Slice["sample-slice"].DefaultBehavior = aStr("ALLOW-PUBLIC")
+	device.Application["sample-app"].Address = aStr("2001:DB8::1")
+	device.Site["sample-site"].IpDomain["sample-ipd"].Subnet = aStr("2001:db8:1::/64")
+
+	mockPusher.EXPECT().PushUpdate(gomock.Any(), gomock.Any()).DoAndReturn(func(endpoint string, data []byte) error {
+		pushes[endpoint] = string(data)
+		return nil
+	}).AnyTimes()
+	pushErrors, err := s.SynchronizeDevice(context.Background(), config)
+	assert.Equal(t, 0, pushErrors)
+	assert.Nil(t, err)
+
+	json, okay := pushes["http://5gcore/v1/network-slice/sample-slice"]
+	assert.True(t, okay)
+	if okay {
+		require.JSONEq(t, string(jsonDataCoreSlice), json)
+	}
+	json, okay = pushes["http://5gcore/v1/device-group/sample-dg"]
+	assert.True(t, okay)
+	if okay {
+		assert.Contains(t, json, `"ue-ip-pool": "2001:db8:1::/64"`)
+	}
+}
+
+func TestSynchronizeVCSInvalidAppAddress(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockPusher := mocks.NewMockPusherInterface(ctrl)
+	pushes := make(map[string]string)
+	s := NewSynchronizer(WithPusher(mockPusher))
+
+	config, device := BuildSampleConfig()
+	device.Application["sample-app"].Address = aStr("1.2.3.4/40")
+
+	mockPusher.EXPECT().PushUpdate(gomock.Any(), gomock.Any()).DoAndReturn(func(endpoint string, data []byte) error {
+		pushes[endpoint] = string(data)
+		return nil
+	}).AnyTimes()
+	pushErrors, err := s.SynchronizeDevice(context.Background(), config)
+	assert.Equal(t, 0, pushErrors)
+	assert.Nil(t, err)
+
+	// The slice fails with a nonfatal error because the application address is malformed
+	_, okay := pushes["http://5gcore/v1/network-slice/sample-slice"]
+	assert.False(t, okay)
+}
+
 func TestSynchronizeVCSTwoEnpoints(t *testing.T) {
 	jsonDataDg, err := os.ReadFile("./testdata/sample-dg.json")
 	assert.NoError(t, err)
@@ -538,7 +706,7 @@ func TestSynchronizeVCSTwoEnpoints(t *testing.T) {
 		pushes[endpoint] = string(data)
 		return nil
 	}).AnyTimes()
-	pushErrors, err := s.SynchronizeDevice(config)
+	pushErrors, err := s.SynchronizeDevice(context.Background(), config)
 	assert.Equal(t, 0, pushErrors)
 	assert.Nil(t, err)
 
@@ -588,7 +756,7 @@ func TestSynchronizeVCSEmptySD(t *testing.T) {
 		pushes[endpoint] = string(data)
 		return nil
 	}).AnyTimes()
-	pushErrors, err := s.SynchronizeDevice(config)
+	pushErrors, err := s.SynchronizeDevice(context.Background(), config)
 	assert.Equal(t, 0, pushErrors)
 	assert.Nil(t, err)
 	json, okay := pushes["http://5gcore/v1/device-group/sample-dg"]
@@ -637,7 +805,7 @@ func TestSynchronizeVCSDisabledDG(t *testing.T) {
 		pushes[endpoint] = string(data)
 		return nil
 	}).AnyTimes()
-	pushErrors, err := s.SynchronizeDevice(config)
+	pushErrors, err := s.SynchronizeDevice(context.Background(), config)
 	assert.Equal(t, 0, pushErrors)
 	assert.Nil(t, err)
 	json, okay := pushes["http://5gcore/v1/device-group/sample-dg"]
@@ -684,7 +852,7 @@ func TestSynchronizeVCSMissingDG(t *testing.T) {
 		pushes[endpoint] = string(data)
 		return nil
 	}).AnyTimes()
-	pushErrors, err := s.SynchronizeDevice(config)
+	pushErrors, err := s.SynchronizeDevice(context.Background(), config)
 	assert.Equal(t, 0, pushErrors)
 	assert.Nil(t, err)
 
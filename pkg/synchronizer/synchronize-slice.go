@@ -8,18 +8,36 @@ package synchronizer
 import (
 	"encoding/json"
 	"fmt"
+	"net/netip"
 	"sort"
 	"strconv"
-	"strings"
 )
 
 func (s *Synchronizer) mapPriority(i uint8) uint8 {
 	return i // // At one point priority was flipped but this was incorrect
 }
 
+// sliceUsesIPv6 returns true if any of the slice's device-groups allocates UE addresses
+// from an IPv6 subnet.
+func (s *Synchronizer) sliceUsesIPv6(scope *AetherScope, dgList []*DeviceGroup) bool {
+	for _, dg := range dgList {
+		ipd, err := s.GetIPDomain(scope, dg.IpDomain)
+		if (err != nil) || (ipd.Subnet == nil) {
+			// The device-group's synchronization will report the problem
+			continue
+		}
+		prefix, err := netip.ParsePrefix(*ipd.Subnet)
+		if (err == nil) && prefix.Addr().Is6() {
+			return true
+		}
+	}
+	return false
+}
+
 // SynchronizeSlice synchronizes the VCSes
 // Return a count of push-related errors
 func (s *Synchronizer) SynchronizeSlice(scope *AetherScope, slice *Slice) (int, error) {
+	opts := s.scopeOptions(scope)
 	dgList, err := s.GetSliceDG(scope, slice)
 	if err != nil {
 		return 0, fmt.Errorf("Slice %s unable to determine site: %s", *slice.SliceId, err)
@@ -109,6 +127,9 @@ func (s *Synchronizer) SynchronizeSlice(scope *AetherScope, slice *Slice) (int,
 		coreSlice.DeviceGroup = append(coreSlice.DeviceGroup, *dg.DeviceGroupId)
 	}
 
+	// IPv6 default-behavior rules are only emitted if the slice carries IPv6 traffic
+	ipv6 := s.sliceUsesIPv6(scope, dgList)
+
 	// be deterministic...
 	appKeys := []string{}
 	for k := range slice.Filter {
@@ -128,6 +149,14 @@ func (s *Synchronizer) SynchronizeSlice(scope *AetherScope, slice *Slice) (int,
 			return 0, fmt.Errorf("Slice %s Application %s has empty address", *slice.SliceId, *app.ApplicationId)
 		}
 
+		appPrefix, err := ParseAddressPrefix(*app.Address)
+		if err != nil {
+			return 0, fmt.Errorf("Slice %s Application %s has invalid address: %v", *slice.SliceId, *app.ApplicationId, err)
+		}
+		if appPrefix.Addr().Is6() {
+			ipv6 = true
+		}
+
 		// be deterministic...
 		epKeys := []string{}
 		for k := range app.Endpoint {
@@ -138,13 +167,8 @@ func (s *Synchronizer) SynchronizeSlice(scope *AetherScope, slice *Slice) (int,
 		for _, epName := range epKeys {
 			endpoint := app.Endpoint[epName]
 			appCore := appFilterRule{
-				Name: fmt.Sprintf("%s-%s", *app.ApplicationId, epName),
-			}
-
-			if strings.Contains(*app.Address, "/") {
-				appCore.Endpoint = *app.Address
-			} else {
-				appCore.Endpoint = *app.Address + "/32"
+				Name:     fmt.Sprintf("%s-%s", *app.ApplicationId, epName),
+				Endpoint: appPrefix.String(),
 			}
 
 			if endpoint.PortStart != nil {
@@ -158,11 +182,16 @@ func (s *Synchronizer) SynchronizeSlice(scope *AetherScope, slice *Slice) (int,
 			}
 
 			if endpoint.Protocol != nil {
-				protoNum, err := ProtoStringToProtoNumber(*endpoint.Protocol)
+				protocol, err := ProtoStringToProtoFilter(*endpoint.Protocol)
 				if err != nil {
 					return 0, fmt.Errorf("Slice %s Application %s unable to determine protocol: %s", *slice.SliceId, *app.ApplicationId, err)
 				}
-				appCore.Protocol = &protoNum
+				appCore.Protocol = protocol
+			}
+
+			err = validateApplicationEndpointPorts(endpoint, appCore.Protocol)
+			if err != nil {
+				return 0, fmt.Errorf("Slice %s Application %s Endpoint %s is invalid: %s", *slice.SliceId, *app.ApplicationId, epName, err)
 			}
 
 			if (appRef.Allow != nil) && (*appRef.Allow) {
@@ -203,31 +232,36 @@ func (s *Synchronizer) SynchronizeSlice(scope *AetherScope, slice *Slice) (int,
 			}
 
 			appCore.Priority = s.mapPriority(DerefUint8Ptr(appRef.Priority, 0))
-			coreSlice.ApplicationFilteringRules = append(coreSlice.ApplicationFilteringRules, appCore)
+			if opts.portRangeSplitEnable {
+				coreSlice.ApplicationFilteringRules = append(coreSlice.ApplicationFilteringRules, splitFilterRulePorts(appCore)...)
+			} else {
+				coreSlice.ApplicationFilteringRules = append(coreSlice.ApplicationFilteringRules, appCore)
+			}
+		}
+	}
+
+	defaultRules, err := s.defaultBehaviorRules(scope, *slice.DefaultBehavior, ipv6)
+	if err != nil {
+		return 0, fmt.Errorf("Slice %s %s", *slice.SliceId, err)
+	}
+
+	// Without auto-assignment, conflicting priorities are pushed as configured, as they were
+	// before conflicts were detected, so that an upgrade does not stop a slice from synchronizing
+	floor := defaultBehaviorFloor(defaultRules)
+	if opts.priorityAutoAssignEnable {
+		err = assignFilterPriorities(coreSlice.ApplicationFilteringRules, floor)
+		if err != nil {
+			return 0, fmt.Errorf("Slice %s %s", *slice.SliceId, err)
 		}
 	}
+	err = checkFilterPriorities(coreSlice.ApplicationFilteringRules, floor)
+	if err != nil {
+		log.Warnf("Slice %s has conflicting filter rules, whose order in the core is undefined: %s", *slice.SliceId, err)
+	}
+
+	coreSlice.ApplicationFilteringRules = append(coreSlice.ApplicationFilteringRules, defaultRules...)
 
-	switch *slice.DefaultBehavior {
-	case "ALLOW-ALL":
-		allowAll := appFilterRule{Name: "ALLOW-ALL", Action: "permit", Priority: s.mapPriority(250), Endpoint: "0.0.0.0/0", TrafficClass: &trafficClass{ARP: 6, QCI: 9}}
-		coreSlice.ApplicationFilteringRules = append(coreSlice.ApplicationFilteringRules, allowAll)
-	case "DENY-ALL":
-		denyAll := appFilterRule{Name: "DENY-ALL", Action: "deny", Priority: s.mapPriority(250), Endpoint: "0.0.0.0/0", TrafficClass: &trafficClass{ARP: 6, QCI: 9}}
-		coreSlice.ApplicationFilteringRules = append(coreSlice.ApplicationFilteringRules, denyAll)
-	case "ALLOW-PUBLIC":
-		denyClassA := appFilterRule{Name: "DENY-CLASS-A", Action: "deny", Priority: s.mapPriority(250), Endpoint: "10.0.0.0/8", TrafficClass: &trafficClass{ARP: 6, QCI: 9}}
-		denyClassB := appFilterRule{Name: "DENY-CLASS-B", Action: "deny", Priority: s.mapPriority(251), Endpoint: "172.16.0.0/12", TrafficClass: &trafficClass{ARP: 6, QCI: 9}}
-		denyClassC := appFilterRule{Name: "DENY-CLASS-C", Action: "deny", Priority: s.mapPriority(252), Endpoint: "192.168.0.0/16", TrafficClass: &trafficClass{ARP: 6, QCI: 9}}
-		allowAll := appFilterRule{Name: "ALLOW-ALL", Action: "permit", Priority: s.mapPriority(253), Endpoint: "0.0.0.0/0", TrafficClass: &trafficClass{ARP: 6, QCI: 9}}
-		coreSlice.ApplicationFilteringRules = append(coreSlice.ApplicationFilteringRules, denyClassA)
-		coreSlice.ApplicationFilteringRules = append(coreSlice.ApplicationFilteringRules, denyClassB)
-		coreSlice.ApplicationFilteringRules = append(coreSlice.ApplicationFilteringRules, denyClassC)
-		coreSlice.ApplicationFilteringRules = append(coreSlice.ApplicationFilteringRules, allowAll)
-	default:
-		return 0, fmt.Errorf("Slice %s has invalid defauilt-behavior %s", *slice.SliceId, *slice.DefaultBehavior)
-	}
-
-	if s.partialUpdateEnable && s.CacheCheck(CacheModelSlice, *slice.SliceId, coreSlice) {
+	if opts.partialUpdateEnable && s.CacheCheck(CacheModelSlice, *slice.SliceId, coreSlice) {
 		log.Infof("Core Slice %s has not changed", *slice.SliceId)
 		return 0, nil
 	}
@@ -242,7 +276,7 @@ func (s *Synchronizer) SynchronizeSlice(scope *AetherScope, slice *Slice) (int,
 	}
 
 	url := fmt.Sprintf("%s/v1/network-slice/%s", *scope.CoreEndpoint, *slice.SliceId)
-	err = s.pusher.PushUpdate(url, data)
+	err = s.pushUpdate(scope, url, data)
 	if err != nil {
 		return 1, fmt.Errorf("Slice %s failed to push update: %s", *slice.SliceId, err)
 	}
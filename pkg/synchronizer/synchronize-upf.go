@@ -95,7 +95,7 @@ func (s *Synchronizer) SynchronizeSliceUPF(scope *AetherScope, slice *Slice) (in
 		}
 	}
 
-	if s.partialUpdateEnable && s.CacheCheck(CacheModelSliceUpf, *slice.SliceId, sc) {
+	if s.scopeOptions(scope).partialUpdateEnable && s.CacheCheck(CacheModelSliceUpf, *slice.SliceId, sc) {
 		log.Infof("UPF Slice %s has not changed", *slice.SliceId)
 		return 0, nil
 	}
@@ -106,7 +106,7 @@ func (s *Synchronizer) SynchronizeSliceUPF(scope *AetherScope, slice *Slice) (in
 	}
 
 	url := fmt.Sprintf("%s/v1/config/network-slices", *aUpf.ConfigEndpoint)
-	err = s.pusher.PushUpdate(url, data)
+	err = s.pushUpdate(scope, url, data)
 	if err != nil {
 		return 1, fmt.Errorf("slice %s failed to push UPF JSON: %s", *slice.SliceId, err)
 	}
@@ -5,6 +5,7 @@
 package synchronizer
 
 import (
+	"context"
 	"github.com/onosproject/sdcore-adapter/pkg/gnmi"
 	"github.com/stretchr/testify/assert"
 	"testing"
@@ -15,19 +16,28 @@ func TestNewSynchronizer(t *testing.T) {
 	sync := NewSynchronizer()
 	assert.NotNil(t, sync)
 
-	assert.Equal(t, true, sync.postEnable)
-	assert.Equal(t, 10*time.Second, sync.postTimeout)
-	assert.Equal(t, true, sync.partialUpdateEnable)
+	assert.Equal(t, true, sync.options.postEnable)
+	assert.Equal(t, 10*time.Second, sync.options.postTimeout)
+	assert.Equal(t, true, sync.options.partialUpdateEnable)
+	assert.Equal(t, true, sync.options.foreignImsiEnable)
+	assert.Equal(t, false, sync.options.priorityAutoAssignEnable)
+	assert.Equal(t, false, sync.options.portRangeSplitEnable)
 
 	sync = NewSynchronizer(
 		WithPostEnable(false),
 		WithPostTimeout(7*time.Second),
 		WithPartialUpdateEnable(false),
+		WithForeignImsiEnable(false),
+		WithPriorityAutoAssignEnable(true),
+		WithPortRangeSplitEnable(true),
 	)
 
-	assert.Equal(t, false, sync.postEnable)
-	assert.Equal(t, 7*time.Second, sync.postTimeout)
-	assert.Equal(t, false, sync.partialUpdateEnable)
+	assert.Equal(t, false, sync.options.postEnable)
+	assert.Equal(t, 7*time.Second, sync.options.postTimeout)
+	assert.Equal(t, false, sync.options.partialUpdateEnable)
+	assert.Equal(t, false, sync.options.foreignImsiEnable)
+	assert.Equal(t, true, sync.options.priorityAutoAssignEnable)
+	assert.Equal(t, true, sync.options.portRangeSplitEnable)
 }
 
 func TestSynchronizerLoop(t *testing.T) {
@@ -36,13 +46,13 @@ func TestSynchronizerLoop(t *testing.T) {
 
 	config := gnmi.NewConfigForest()
 
-	sync.retryInterval = 100 * time.Millisecond
+	sync.options.retryInterval = 100 * time.Millisecond
 	sync.synchronizeDeviceFunc = mockSynchronizeDevice
 	sync.Start()
 
 	// Normal synchronization
 	mockSynchronizeDeviceReset(0, 0, 0*time.Second)
-	err := sync.Synchronize(config, gnmi.Apply, "sample-ent", nil)
+	err := sync.Synchronize(context.Background(), config, gnmi.Apply, "sample-ent", nil, nil)
 	assert.Nil(t, err)
 	waitForSyncIdle(t, sync, 5*time.Second)
 	assert.Equal(t, 1, len(mockSynchronizeDeviceCalls))
@@ -51,7 +61,7 @@ func TestSynchronizerLoop(t *testing.T) {
 	// Fail and retry once
 
 	mockSynchronizeDeviceReset(0, 1, 0*time.Second)
-	err = sync.Synchronize(config, gnmi.Apply, "sample-ent", nil)
+	err = sync.Synchronize(context.Background(), config, gnmi.Apply, "sample-ent", nil, nil)
 	assert.Nil(t, err)
 	waitForSyncIdle(t, sync, 5*time.Second)
 	assert.Equal(t, 1, len(mockSynchronizeDevicePushFails))
@@ -60,16 +70,56 @@ func TestSynchronizerLoop(t *testing.T) {
 
 	// several queued changes should only get the last one
 	mockSynchronizeDeviceReset(0, 1, 100*time.Millisecond)
-	err = sync.Synchronize(gnmi.NewConfigForest(), gnmi.Apply, "sample-ent", nil) // this one will fail...
+	err = sync.Synchronize(context.Background(), gnmi.NewConfigForest(), gnmi.Apply, "sample-ent", nil, nil) // this one will fail...
 	assert.Nil(t, err)
-	err = sync.Synchronize(gnmi.NewConfigForest(), gnmi.Apply, "sample-ent", nil) // this one will be ignored...
+	err = sync.Synchronize(context.Background(), gnmi.NewConfigForest(), gnmi.Apply, "sample-ent", nil, nil) // this one will be ignored...
 	assert.Nil(t, err)
-	err = sync.Synchronize(gnmi.NewConfigForest(), gnmi.Apply, "sample-ent", nil) // this one will also be ignored...
+	err = sync.Synchronize(context.Background(), gnmi.NewConfigForest(), gnmi.Apply, "sample-ent", nil, nil) // this one will also be ignored...
 	assert.Nil(t, err)
-	err = sync.Synchronize(config, gnmi.Apply, "sample-ent", nil) // this one will succeed!
+	err = sync.Synchronize(context.Background(), config, gnmi.Apply, "sample-ent", nil, nil) // this one will succeed!
 	assert.Nil(t, err)
 	waitForSyncIdle(t, sync, 5*time.Second)
 	assert.Equal(t, 1, len(mockSynchronizeDevicePushFails))
 	assert.Equal(t, 1, len(mockSynchronizeDeviceCalls))
 	assert.Equal(t, config, mockSynchronizeDeviceCalls[0])
 }
+
+func TestReconfigure(t *testing.T) {
+	sync := NewSynchronizer()
+	assert.Equal(t, DefaultRetryInterval, sync.options.retryInterval)
+	assert.True(t, sync.options.partialUpdateEnable)
+
+	sync.Reconfigure(WithRetryInterval(time.Minute), WithPartialUpdateEnable(false), WithPostTimeout(time.Second))
+	assert.Equal(t, time.Minute, sync.options.retryInterval)
+	assert.False(t, sync.options.partialUpdateEnable)
+	assert.Equal(t, time.Second, sync.pusher.(*RESTPusher).timeout)
+	assert.Equal(t, "1m0s", sync.GetStatus().Retry.Interval)
+}
+
+func TestReconfigureDuringSynchronization(t *testing.T) {
+	release := make(chan struct{})
+	sync := NewSynchronizer()
+	sync.synchronizeDeviceFunc = func(ctx context.Context, config *gnmi.ConfigForest, filters ...ResyncFilter) (int, error) {
+		<-release
+		return 0, nil
+	}
+	sync.Start()
+	defer close(release)
+
+	err := sync.Synchronize(context.Background(), gnmi.NewConfigForest(), gnmi.Apply, "sample-ent", nil, nil)
+	assert.Nil(t, err)
+	assert.Eventually(t, func() bool { return sync.GetStatus().InProgress }, 5*time.Second, 10*time.Millisecond)
+
+	// Neither waits for the synchronization in progress
+	reconfigured := make(chan struct{})
+	go func() {
+		sync.Reconfigure(WithRetryInterval(time.Minute))
+		close(reconfigured)
+	}()
+	select {
+	case <-reconfigured:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Reconfigure waited for the synchronization in progress")
+	}
+	assert.Equal(t, "1m0s", sync.GetStatus().Retry.Interval)
+}
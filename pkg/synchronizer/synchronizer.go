@@ -6,76 +6,182 @@
 package synchronizer
 
 import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
 	models "github.com/onosproject/aether-models/models/aether-2.1.x/v2/api"
 	"github.com/onosproject/onos-lib-go/pkg/logging"
 	"github.com/onosproject/sdcore-adapter/pkg/gnmi"
 	"github.com/onosproject/sdcore-adapter/pkg/metrics"
+	"github.com/onosproject/sdcore-adapter/pkg/tracing"
 	pb "github.com/openconfig/gnmi/proto/gnmi"
 	"github.com/openconfig/ygot/ygot"
-	"reflect"
-	"time"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 var log = logging.GetLogger("synchronizer")
 
 // Synchronize synchronizes the state to the underlying service.
-func (s *Synchronizer) Synchronize(config *gnmi.ConfigForest, callbackType gnmi.ConfigCallbackType, target string, path *pb.Path) error {
-	var err error
+// The resources synchronized are those of scope, the paths of the subtrees the callback covers,
+// or every resource if scope is nil.
+func (s *Synchronizer) Synchronize(ctx context.Context, config *gnmi.ConfigForest, callbackType gnmi.ConfigCallbackType, target string, path *pb.Path, scope []*pb.Path) (err error) {
+	ctx, span := tracing.StartSpan(ctx, "synchronizer.Synchronize",
+		attribute.String("callback-type", callbackType.String()),
+		attribute.String("target", target))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if s.isStopping() {
+		return ErrStopped
+	}
+
 	if callbackType == gnmi.Deleted {
-		return s.HandleDelete(config, path)
+		if !s.isLeader() {
+			log.Infof("Synchronizer is on standby; not pushing delete")
+			return nil
+		}
+		return s.HandleDelete(ctx, config, path)
 	}
 
+	scopeFilters, err := ResyncFiltersFromScope(scope)
+	if err != nil {
+		return err
+	}
+	filters := resyncFilters(scopeFilters)
 	if callbackType == gnmi.Forced {
-		s.CacheInvalidate() // invalidate the post cache if this resync was forced by Diagnostic API
+		if !s.isLeader() {
+			return ErrStandby
+		}
+		if filters == nil {
+			s.CacheInvalidate() // invalidate the post cache if this resync was forced by Diagnostic API
+		} else {
+			// a targeted resync only invalidates, and only synchronizes, the matching resources
+			matched, err := s.resyncInvalidate(config, filters)
+			if err != nil {
+				return err
+			}
+			if matched == 0 {
+				return fmt.Errorf("No resources match resync filter %s", strings.Join(filters.strings(), ", "))
+			}
+			log.Infof("Targeted resync of %d resources matching %s", matched, strings.Join(filters.strings(), ", "))
+		}
 	}
 
+	s.setConfigReceived()
+
 	// we start opstate processing on the first configuration callback. Until then, we can't handle any opstate anyway
 	if !s.opstateStarted {
 		s.startOpstate(config)
 	}
 
-	err = s.enqueue(config, callbackType, target)
+	if !s.isLeader() {
+		// keep the configuration to resynchronize if this replica is elected
+		return s.recordConfig(config)
+	}
+
+	err = s.enqueue(ctx, config, callbackType, target, filters)
 	return err
 }
 
 // SynchronizeAndRetry automatically retries if synchronization fails
 func (s *Synchronizer) SynchronizeAndRetry(update *ConfigUpdate) {
-	for {
+	ctx := update.ctx
+	for attempt := 1; ; attempt++ {
 		// If something new has come along, then don't bother with the one we're working on
 		if s.newUpdatesPending() {
 			log.Infof("Current synchronizer update has been obsoleted")
+			update.obsoleted = true
+			s.setRetry(0, time.Time{})
 			return
 		}
 
-		pushErrors, err := s.synchronizeDeviceFunc(update.config)
+		s.setRetry(attempt, time.Time{})
+
+		pushErrors, err := s.synchronizeDeviceFunc(ctx, update.config, update.filters...)
+		retryInterval := s.currentOptions().retryInterval
+		if ctx.Err() != nil {
+			log.Warnf("Synchronization cancelled")
+			s.setRetry(0, time.Time{})
+			return
+		}
 		if err != nil {
 			log.Errorf("Synchronization error: %v", err)
+			s.setLastSyncError(update.filters, err)
 			return
 		}
 
 		if pushErrors == 0 {
 			log.Infof("Synchronization success")
+			s.setRetry(0, time.Time{})
 			return
 		}
 
 		log.Infof("Synchronization encountered %d push errors, scheduling retry", pushErrors)
+		s.setRetry(attempt, time.Now().Add(retryInterval))
 
 		// We failed to push something to the core. Sleep before trying again.
 		// Implements a fixed interval for now; We can go exponential should it prove to
-		// be a problem.
-		time.Sleep(s.retryInterval)
+		// be a problem. No retry is attempted once the synchronizer is stopping.
+		select {
+		case <-time.After(retryInterval):
+		case <-s.stopCtx.Done():
+			log.Warnf("Synchronizer stopping; abandoning retry")
+			s.setRetry(0, time.Time{})
+			return
+		case <-ctx.Done():
+			log.Warnf("Synchronization cancelled; abandoning retry")
+			s.setRetry(0, time.Time{})
+			return
+		}
 	}
 }
 
 // Loop runs an infitite loop servicing synchronization requests.
 func (s *Synchronizer) Loop() {
 	log.Infof("Starting synchronizer loop")
+	s.stopMu.Lock()
+	loopCtx, loopDone := s.loopCtx, s.loopDone
+	s.stopMu.Unlock()
+
+	s.setLoopRunning(true)
+	defer s.setLoopRunning(false)
+	if loopDone != nil {
+		defer close(loopDone)
+	}
+	var obsoleted *ConfigUpdate
 	for {
-		update := s.dequeue()
+		var update *ConfigUpdate
+		select {
+		case update = <-s.updateChannel:
+		case <-loopCtx.Done():
+			log.Infof("Synchronizer loop stopped")
+			return
+		}
+		// If the loop was stopped while an update was queued, select may have picked either;
+		// once stopped, no new update is started.
+		if loopCtx.Err() != nil {
+			log.Infof("Synchronizer loop stopped; dropping queued update")
+			s.complete()
+			return
+		}
+		if obsoleted != nil {
+			// The newer update must also cover the resources of the one it obsoleted
+			update.filters = mergeResyncFilters(update.filters, obsoleted.filters)
+			obsoleted = nil
+		}
 
 		log.Infof("Synchronize, type=%s", update.callbackType)
 
+		s.setInProgress(true)
+		update.ctx = s.startUpdate(update)
 		s.SynchronizeAndRetry(update)
+		s.endUpdate()
+		s.setInProgress(false)
+		if update.obsoleted {
+			obsoleted = update
+		}
 
 		s.complete()
 	}
@@ -96,34 +202,103 @@ func (s *Synchronizer) GetModels() *gnmi.Model {
 
 // Start the synchronizer by launching the synchronizer loop inside a thread.
 func (s *Synchronizer) Start() {
-	log.Infof("Synchronizer starting (postEnable=%v, postTimeout=%d, retryInterval=%s, partialUpdateEnable=%v)",
-		s.postEnable,
-		s.postTimeout,
-		s.retryInterval,
-		s.partialUpdateEnable)
+	log.Infof("Synchronizer starting (postEnable=%v, postTimeout=%d, retryInterval=%s, partialUpdateEnable=%v, foreignImsiEnable=%v, priorityAutoAssignEnable=%v, portRangeSplitEnable=%v)",
+		s.options.postEnable,
+		s.options.postTimeout,
+		s.options.retryInterval,
+		s.options.partialUpdateEnable,
+		s.options.foreignImsiEnable,
+		s.options.priorityAutoAssignEnable,
+		s.options.portRangeSplitEnable)
+
+	if s.electionEnable {
+		log.Infof("Synchronizer is on standby until elected leader")
+		return
+	}
 
-	// TODO: Eventually we'll create a thread here that waits for config changes
-	go s.Loop()
+	s.stopMu.Lock()
+	defer s.stopMu.Unlock()
+	s.startLoop()
 }
 
 // WithPostEnable sets the postEnable option
 func WithPostEnable(postEnable bool) SynchronizerOption {
 	return func(s *Synchronizer) {
-		s.postEnable = postEnable
+		s.options.postEnable = postEnable
 	}
 }
 
 // WithPostTimeout sets the postTimeout option
 func WithPostTimeout(postTimeout time.Duration) SynchronizerOption {
 	return func(s *Synchronizer) {
-		s.postTimeout = postTimeout
+		s.options.postTimeout = postTimeout
+		if p, okay := s.pusher.(*RESTPusher); okay {
+			p.SetTimeout(postTimeout)
+		}
+	}
+}
+
+// WithRetryInterval sets the interval between attempts when a push fails
+func WithRetryInterval(retryInterval time.Duration) SynchronizerOption {
+	return func(s *Synchronizer) {
+		s.options.retryInterval = retryInterval
+	}
+}
+
+// WithPushCredentials sets the credentials that the REST pusher presents to the core and UPF
+func WithPushCredentials(credentials PushCredentials) SynchronizerOption {
+	return func(s *Synchronizer) {
+		if p, okay := s.pusher.(*RESTPusher); okay {
+			p.SetCredentials(credentials)
+		}
+	}
+}
+
+// WithKafka sets the URI and topic of the Kafka bus that operational state is read from. If
+// uri is empty, operational state is not read.
+func WithKafka(uri string, topic string) SynchronizerOption {
+	return func(s *Synchronizer) {
+		s.kafkaURI = uri
+		s.kafkaTopic = topic
 	}
 }
 
 // WithPartialUpdateEnable sets the partialUpdateEnable option
 func WithPartialUpdateEnable(partialUpdateEnable bool) SynchronizerOption {
 	return func(s *Synchronizer) {
-		s.partialUpdateEnable = partialUpdateEnable
+		s.options.partialUpdateEnable = partialUpdateEnable
+	}
+}
+
+// WithForeignImsiEnable sets the foreignImsiEnable option
+func WithForeignImsiEnable(foreignImsiEnable bool) SynchronizerOption {
+	return func(s *Synchronizer) {
+		s.options.foreignImsiEnable = foreignImsiEnable
+	}
+}
+
+// WithPriorityAutoAssignEnable sets the priorityAutoAssignEnable option
+func WithPriorityAutoAssignEnable(priorityAutoAssignEnable bool) SynchronizerOption {
+	return func(s *Synchronizer) {
+		s.options.priorityAutoAssignEnable = priorityAutoAssignEnable
+	}
+}
+
+// WithPortRangeSplitEnable sets the portRangeSplitEnable option
+func WithPortRangeSplitEnable(portRangeSplitEnable bool) SynchronizerOption {
+	return func(s *Synchronizer) {
+		s.options.portRangeSplitEnable = portRangeSplitEnable
+	}
+}
+
+// WithDefaultBehaviorPolicy sets the policy used to implement slice default behaviors. A nil
+// policy selects the built-in behaviors.
+func WithDefaultBehaviorPolicy(policy *DefaultBehaviorPolicy) SynchronizerOption {
+	return func(s *Synchronizer) {
+		if policy == nil {
+			policy = NewDefaultBehaviorPolicy()
+		}
+		s.options.defaultBehaviors = policy
 	}
 }
 
@@ -134,24 +309,70 @@ func WithPusher(pusher PusherInterface) SynchronizerOption {
 	}
 }
 
+// currentOptions returns a copy of the options, for one synchronization attempt or request
+func (s *Synchronizer) currentOptions() synchronizerOptions {
+	s.optionsMu.RLock()
+	defer s.optionsMu.RUnlock()
+	return s.options
+}
+
+// scopeOptions returns the options of the synchronization attempt that scope belongs to, or the
+// current options if scope was not made by one
+func (s *Synchronizer) scopeOptions(scope *AetherScope) *synchronizerOptions {
+	if (scope != nil) && (scope.options != nil) {
+		return scope.options
+	}
+	opts := s.currentOptions()
+	return &opts
+}
+
+// Reconfigure applies options to a running synchronizer. The options take effect from the next
+// synchronization attempt; an attempt that is in progress completes with the previous options.
+func (s *Synchronizer) Reconfigure(opts ...SynchronizerOption) {
+	s.optionsMu.Lock()
+	defer s.optionsMu.Unlock()
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	log.Infof("Synchronizer reconfigured (postEnable=%v, postTimeout=%s, retryInterval=%s, partialUpdateEnable=%v, foreignImsiEnable=%v, priorityAutoAssignEnable=%v, portRangeSplitEnable=%v)",
+		s.options.postEnable,
+		s.options.postTimeout,
+		s.options.retryInterval,
+		s.options.partialUpdateEnable,
+		s.options.foreignImsiEnable,
+		s.options.priorityAutoAssignEnable,
+		s.options.portRangeSplitEnable)
+}
+
 // NewSynchronizer creates a new Synchronizer
 func NewSynchronizer(opts ...SynchronizerOption) *Synchronizer {
 	// By default, push via REST. Test infrastructure can override this.
 	p := &RESTPusher{}
 
 	s := &Synchronizer{
-		pusher:              p,
-		postEnable:          true,
-		partialUpdateEnable: DefaultPartialUpdateEnable,
-		postTimeout:         DefaultPostTimeout,
-		updateChannel:       make(chan *ConfigUpdate, 1),
-		retryInterval:       5 * time.Second,
-		cache:               map[string]interface{}{},
-		prometheus:          map[string]*metrics.Fetcher{},
+		pusher: p,
+		options: synchronizerOptions{
+			postEnable:               true,
+			partialUpdateEnable:      DefaultPartialUpdateEnable,
+			foreignImsiEnable:        DefaultForeignImsiEnable,
+			priorityAutoAssignEnable: DefaultPriorityAutoAssignEnable,
+			portRangeSplitEnable:     DefaultPortRangeSplitEnable,
+			defaultBehaviors:         NewDefaultBehaviorPolicy(),
+			postTimeout:              DefaultPostTimeout,
+			retryInterval:            DefaultRetryInterval,
+		},
+		updateChannel: make(chan *ConfigUpdate, 1),
+		kafkaTopic:    DefaultKafkaTopic,
+		cache:         map[cacheKey]interface{}{},
+		prometheus:    map[string]*metrics.Fetcher{},
 
 		kafkaMsgChannel:   make(chan string, 10),
 		kafkaErrorChannel: make(chan error, 10),
 	}
+	s.stopCtx, s.stopCancel = context.WithCancel(context.Background())
+	s.loopCtx = s.stopCtx
 
 	for _, opt := range opts {
 		opt(s)
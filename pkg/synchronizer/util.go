@@ -7,6 +7,7 @@ package synchronizer
 import (
 	"errors"
 	"fmt"
+	"net/netip"
 	"strconv"
 	"strings"
 )
@@ -199,13 +200,87 @@ func MaskSubscriberImsiDef(i *ImsiDefinition, sub uint64) (uint64, error) {
 	return MaskSubscriberImsi(format, sub)
 }
 
-// ProtoStringToProtoNumber converts a protocol name to a number
-func ProtoStringToProtoNumber(s string) (uint8, error) {
-	n, okay := map[string]uint8{"TCP": 6, "UDP": 17}[s]
-	if !okay {
-		return 0, fmt.Errorf("Unknown protocol %s", s)
+// CanonicalizeImsiDef normalizes an IMSI string against the site's ImsiDefinition. A full IMSI
+// is zero-padded to the length of the format, and a short subscriber number is expanded using the
+// site's MCC, MNC, and Enterprise. The second return value is true if the MCC/MNC digits of the
+// resulting IMSI do not match the site's PLMN.
+func CanonicalizeImsiDef(i *ImsiDefinition, imsi string) (string, bool, error) {
+	if err := validateImsiDefinition(i); err != nil {
+		return "", false, err
+	}
+
+	format := DerefStrPtr(i.Format, DefaultImsiFormat)
+
+	if imsi == "" {
+		return "", false, errors.New("IMSI is empty")
+	}
+	for _, c := range imsi {
+		if (c < '0') || (c > '9') {
+			return "", false, fmt.Errorf("IMSI %s contains non-digit characters", imsi)
+		}
+	}
+
+	subDigits := strings.Count(format, "S")
+	if (len(imsi) != len(format)) && (len(imsi) > subDigits) {
+		return "", false, fmt.Errorf("IMSI %s has %d digits; expected %d digits or at most %d subscriber digits", imsi, len(imsi), len(format), subDigits)
+	}
+
+	sub, err := strconv.ParseUint(imsi, 10, 64)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to parse IMSI %s: %v", imsi, err)
+	}
+
+	if len(imsi) != len(format) {
+		sub, err = FormatImsiDef(i, sub)
+		if err != nil {
+			return "", false, err
+		}
 	}
-	return n, nil
+	canonical := fmt.Sprintf("%0*d", len(format), sub)
+
+	// Build the site's PLMN using a format that retains only the MCC and MNC positions, then
+	// compare those positions against the canonical IMSI.
+	plmnFormat := strings.Map(func(r rune) rune {
+		if (r == 'C') || (r == 'N') {
+			return r
+		}
+		return '0'
+	}, format)
+	plmn, err := FormatImsi(plmnFormat, DerefStrPtr(i.Mcc, "0"), DerefStrPtr(i.Mnc, "0"), 0, 0)
+	if err != nil {
+		return "", false, err
+	}
+	plmnStr := fmt.Sprintf("%0*d", len(format), plmn)
+
+	foreign := false
+	for pos := range format {
+		if ((format[pos] == 'C') || (format[pos] == 'N')) && (canonical[pos] != plmnStr[pos]) {
+			foreign = true
+			break
+		}
+	}
+
+	return canonical, foreign, nil
+}
+
+// ParseAddressPrefix parses an address or a prefix. A bare address is converted to a host
+// prefix, /32 for IPv4 or /128 for IPv6.
+func ParseAddressPrefix(s string) (netip.Prefix, error) {
+	if strings.Contains(s, "/") {
+		return netip.ParsePrefix(s)
+	}
+
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	if addr.Zone() != "" {
+		return netip.Prefix{}, fmt.Errorf("address %s must not have a zone", s)
+	}
+
+	// An IPv4-mapped IPv6 address is treated as the IPv4 address it represents
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
 }
 
 // aStr facilitates easy declaring of pointers to strings
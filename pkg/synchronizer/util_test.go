@@ -144,6 +144,92 @@ func TestFormatImsiDef(t *testing.T) {
 	assert.EqualError(t, err, "Failed to convert all Subscriber digits")
 }
 
+func TestCanonicalizeImsiDef(t *testing.T) {
+	i := &ImsiDefinition{
+		Mcc:        aStr("123"),
+		Mnc:        aStr("45"),
+		Enterprise: aUint32(789),
+		Format:     aStr("CCCNN0EEESSSSSS"),
+	}
+
+	// full IMSI that matches the site
+	imsi, foreign, err := CanonicalizeImsiDef(i, "123450789000001")
+	assert.Nil(t, err)
+	assert.Equal(t, "123450789000001", imsi)
+	assert.False(t, foreign)
+
+	// short subscriber number is expanded
+	imsi, foreign, err = CanonicalizeImsiDef(i, "42")
+	assert.Nil(t, err)
+	assert.Equal(t, "123450789000042", imsi)
+	assert.False(t, foreign)
+
+	// leading zero is preserved and the PLMN does not match
+	imsi, foreign, err = CanonicalizeImsiDef(i, "012345678901234")
+	assert.Nil(t, err)
+	assert.Equal(t, "012345678901234", imsi)
+	assert.True(t, foreign)
+
+	// enterprise mismatch does not make an IMSI foreign
+	imsi, foreign, err = CanonicalizeImsiDef(i, "123450111000001")
+	assert.Nil(t, err)
+	assert.Equal(t, "123450111000001", imsi)
+	assert.False(t, foreign)
+
+	// non-digits are rejected
+	_, _, err = CanonicalizeImsiDef(i, "12345078900000A")
+	assert.EqualError(t, err, "IMSI 12345078900000A contains non-digit characters")
+
+	// empty is rejected
+	_, _, err = CanonicalizeImsiDef(i, "")
+	assert.EqualError(t, err, "IMSI is empty")
+
+	// neither a full IMSI nor a subscriber number
+	_, _, err = CanonicalizeImsiDef(i, "12345678")
+	assert.EqualError(t, err, "IMSI 12345678 has 8 digits; expected 15 digits or at most 6 subscriber digits")
+
+	// too long
+	_, _, err = CanonicalizeImsiDef(i, "1234507890000012")
+	assert.EqualError(t, err, "IMSI 1234507890000012 has 16 digits; expected 15 digits or at most 6 subscriber digits")
+
+	// invalid definition
+	i.Mcc = nil
+	_, _, err = CanonicalizeImsiDef(i, "42")
+	assert.EqualError(t, err, "Format contains C, yet MCC is nil")
+}
+
+func TestParseAddressPrefix(t *testing.T) {
+	p, err := ParseAddressPrefix("1.2.3.4")
+	assert.Nil(t, err)
+	assert.Equal(t, "1.2.3.4/32", p.String())
+
+	p, err = ParseAddressPrefix("10.0.0.0/8")
+	assert.Nil(t, err)
+	assert.Equal(t, "10.0.0.0/8", p.String())
+
+	p, err = ParseAddressPrefix("2001:DB8::1")
+	assert.Nil(t, err)
+	assert.Equal(t, "2001:db8::1/128", p.String())
+
+	p, err = ParseAddressPrefix("2001:db8::/32")
+	assert.Nil(t, err)
+	assert.Equal(t, "2001:db8::/32", p.String())
+
+	// IPv4-mapped address is treated as IPv4
+	p, err = ParseAddressPrefix("::ffff:1.2.3.4")
+	assert.Nil(t, err)
+	assert.Equal(t, "1.2.3.4/32", p.String())
+
+	_, err = ParseAddressPrefix("fe80::1%eth0")
+	assert.EqualError(t, err, "address fe80::1%eth0 must not have a zone")
+
+	_, err = ParseAddressPrefix("2001:db8::/129")
+	assert.Error(t, err)
+
+	_, err = ParseAddressPrefix("myapp.example.com")
+	assert.Error(t, err)
+}
+
 func TestProtoStringToProtoNumber(t *testing.T) {
 	n, err := ProtoStringToProtoNumber("UDP")
 	assert.Nil(t, err)
@@ -155,4 +241,24 @@ func TestProtoStringToProtoNumber(t *testing.T) {
 
 	_, err = ProtoStringToProtoNumber("MQTT")
 	assert.EqualError(t, err, "Unknown protocol MQTT")
+
+	// names are case-insensitive
+	n, err = ProtoStringToProtoNumber("sctp")
+	assert.Nil(t, err)
+	assert.Equal(t, uint8(132), n)
+
+	n, err = ProtoStringToProtoNumber("IPv6-ICMP")
+	assert.Nil(t, err)
+	assert.Equal(t, uint8(58), n)
+
+	// numeric strings
+	n, err = ProtoStringToProtoNumber("47")
+	assert.Nil(t, err)
+	assert.Equal(t, uint8(47), n)
+
+	_, err = ProtoStringToProtoNumber("256")
+	assert.EqualError(t, err, "Unknown protocol 256")
+
+	_, err = ProtoStringToProtoNumber("-1")
+	assert.EqualError(t, err, "Unknown protocol -1")
 }
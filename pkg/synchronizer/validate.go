@@ -8,6 +8,7 @@ package synchronizer
 import (
 	"errors"
 	"fmt"
+	"net/netip"
 	"strings"
 )
 
@@ -32,6 +33,9 @@ func validateIPDomain(ipd *IpDomain) error {
 	if ipd.Subnet == nil {
 		return fmt.Errorf("Subnet is nil")
 	}
+	if _, err := netip.ParsePrefix(*ipd.Subnet); err != nil {
+		return fmt.Errorf("Subnet %s is invalid: %v", *ipd.Subnet, err)
+	}
 	return nil
 }
 
@@ -57,6 +61,31 @@ func validateUpf(u *Upf) error {
 	return nil
 }
 
+// return error if the port range of an ApplicationEndpoint is malformed, or if the protocol
+// does not have ports
+func validateApplicationEndpointPorts(ep *ApplicationEndpoint, protocol *uint8) error {
+	if ep.PortStart == nil {
+		if ep.PortEnd != nil {
+			return fmt.Errorf("PortEnd is set, yet PortStart is nil")
+		}
+		return nil
+	}
+	if (ep.PortEnd != nil) && (*ep.PortEnd < *ep.PortStart) {
+		return fmt.Errorf("PortEnd %d is less than PortStart %d", *ep.PortEnd, *ep.PortStart)
+	}
+	if protocol == nil {
+		// Protocol is either unset, which defaults to TCP, or ANY
+		if (ep.Protocol != nil) && strings.EqualFold(*ep.Protocol, ProtocolAny) {
+			return fmt.Errorf("Protocol %s does not have ports", *ep.Protocol)
+		}
+		return nil
+	}
+	if !ProtocolHasPorts(*protocol) {
+		return fmt.Errorf("Protocol %d does not have ports", *protocol)
+	}
+	return nil
+}
+
 func validateImsiDefinition(i *ImsiDefinition) error {
 	var format string
 	if i.Format != nil {
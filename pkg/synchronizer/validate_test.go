@@ -46,6 +46,61 @@ func TestValidateIPDomain(t *testing.T) {
 	i = &IpDomain{}
 	err = validateIPDomain(i)
 	assert.EqualError(t, err, "Subnet is nil")
+
+	// IPv6 subnet
+	i = &IpDomain{
+		Subnet: aStr("2001:db8:1::/64"),
+	}
+	err = validateIPDomain(i)
+	assert.Nil(t, err)
+
+	// Malformed subnet
+	i = &IpDomain{
+		Subnet: aStr("1.2.3.4/33"),
+	}
+	err = validateIPDomain(i)
+	assert.EqualError(t, err, `Subnet 1.2.3.4/33 is invalid: netip.ParsePrefix("1.2.3.4/33"): prefix length out of range`)
+}
+
+func TestValidateApplicationEndpointPorts(t *testing.T) {
+	ep := &ApplicationEndpoint{
+		PortStart: aUint16(100),
+		PortEnd:   aUint16(200),
+		Protocol:  aStr("SCTP"),
+	}
+	err := validateApplicationEndpointPorts(ep, aUint8(132))
+	assert.Nil(t, err)
+
+	// Protocol unset
+	err = validateApplicationEndpointPorts(ep, nil)
+	assert.Nil(t, err)
+
+	// Inverted range
+	ep.PortEnd = aUint16(99)
+	err = validateApplicationEndpointPorts(ep, aUint8(6))
+	assert.EqualError(t, err, "PortEnd 99 is less than PortStart 100")
+
+	// Protocol without ports
+	ep.PortEnd = aUint16(200)
+	ep.Protocol = aStr("ICMP")
+	err = validateApplicationEndpointPorts(ep, aUint8(1))
+	assert.EqualError(t, err, "Protocol 1 does not have ports")
+
+	// ANY protocol with ports
+	ep.Protocol = aStr("ANY")
+	err = validateApplicationEndpointPorts(ep, nil)
+	assert.EqualError(t, err, "Protocol ANY does not have ports")
+
+	// ANY protocol without ports
+	ep.PortStart = nil
+	ep.PortEnd = nil
+	err = validateApplicationEndpointPorts(ep, nil)
+	assert.Nil(t, err)
+
+	// End without start
+	ep.PortEnd = aUint16(200)
+	err = validateApplicationEndpointPorts(ep, nil)
+	assert.EqualError(t, err, "PortEnd is set, yet PortStart is nil")
 }
 
 func TestValidateSmallCell(t *testing.T) {
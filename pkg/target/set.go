@@ -6,6 +6,7 @@ package target
 
 import (
 	"github.com/google/gnxi/utils/credentials"
+	"github.com/onosproject/sdcore-adapter/pkg/tracing"
 	pb "github.com/openconfig/gnmi/proto/gnmi"
 	"golang.org/x/net/context"
 	"google.golang.org/grpc/codes"
@@ -20,7 +21,7 @@ func (s *target) Set(ctx context.Context, req *pb.SetRequest) (*pb.SetResponse,
 		return nil, status.Error(codes.PermissionDenied, msg)
 	}
 	log.Infof("allowed a Set request: %v", msg)
-	setResponse, err := s.Server.Set(req)
+	setResponse, err := s.Server.Set(tracing.ExtractIncomingGRPC(ctx), req)
 	log.Infof("set response completed, err=%v", err)
 	return setResponse, err
 }
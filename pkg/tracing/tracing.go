@@ -0,0 +1,169 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package tracing sets up OpenTelemetry tracing for the adapter, and propagates trace
+// context from incoming gRPC requests to outgoing HTTP requests.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/onosproject/onos-lib-go/pkg/logging"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	// ExporterNone disables exporting; spans are still created so that trace context
+	// is propagated, but they are discarded.
+	ExporterNone = "none"
+
+	// ExporterStdout writes spans to stdout. Useful in test environments without a collector.
+	ExporterStdout = "stdout"
+
+	// ExporterOTLP sends spans to an OTLP collector using gRPC.
+	ExporterOTLP = "otlp"
+
+	// TracerName is the instrumentation name used for all spans created by the adapter
+	TracerName = "github.com/onosproject/sdcore-adapter"
+
+	// serviceNameKey is the semantic convention resource attribute for the service name
+	serviceNameKey = attribute.Key("service.name")
+)
+
+var log = logging.GetLogger("tracing")
+
+// Config holds the settings used to initialize tracing
+type Config struct {
+	Exporter    string // one of ExporterNone, ExporterStdout, ExporterOTLP
+	Endpoint    string // OTLP collector address; the exporter's default is used if empty
+	Insecure    bool   // if true, connect to the OTLP collector without TLS
+	ServiceName string
+}
+
+// Init installs a global tracer provider and propagator according to cfg. The returned
+// function flushes and stops the exporter, and should be called on shutdown.
+func Init(ctx context.Context, cfg Config) (func(context.Context) error, error) {
+	// Always install the W3C propagator, so that incoming trace context is forwarded
+	// even when we are not exporting our own spans.
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{}))
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch cfg.Exporter {
+	case "", ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout), stdouttrace.WithPrettyPrint())
+	case ExporterOTLP:
+		opts := []otlptracegrpc.Option{}
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlptracegrpc.WithEndpoint(cfg.Endpoint))
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		exporter, err = otlptracegrpc.New(ctx, opts...)
+	default:
+		return nil, fmt.Errorf("Unknown trace exporter %s", cfg.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create %s trace exporter: %v", cfg.Exporter, err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(serviceNameKey.String(cfg.ServiceName))))
+	otel.SetTracerProvider(provider)
+
+	log.Infof("Tracing enabled (exporter=%s, endpoint=%s)", cfg.Exporter, cfg.Endpoint)
+
+	return provider.Shutdown, nil
+}
+
+// Tracer returns the tracer used for all adapter spans
+func Tracer() trace.Tracer {
+	return otel.Tracer(TracerName)
+}
+
+// StartSpan starts a span named name as a child of any span in ctx. A nil ctx is treated
+// as context.Background().
+func StartSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return Tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// EndSpan records err, if any, on span and ends it
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Detach returns a context that carries the trace of ctx, but none of its deadlines or
+// cancellation. Use it when work started by a request outlives the request.
+func Detach(ctx context.Context) context.Context {
+	if ctx == nil {
+		return context.Background()
+	}
+	return trace.ContextWithSpanContext(context.Background(), trace.SpanContextFromContext(ctx))
+}
+
+// metadataCarrier adapts gRPC metadata to a propagation.TextMapCarrier
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	values := metadata.MD(c).Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+func (c metadataCarrier) Set(key string, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// ExtractIncomingGRPC returns ctx with the remote trace context from the incoming gRPC
+// metadata, if there is any.
+func ExtractIncomingGRPC(ctx context.Context) context.Context {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ctx
+	}
+	return otel.GetTextMapPropagator().Extract(ctx, metadataCarrier(md))
+}
+
+// InjectHTTP adds the trace context in ctx to the headers of an outgoing HTTP request
+func InjectHTTP(ctx context.Context, header http.Header) {
+	if ctx == nil {
+		return
+	}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(header))
+}
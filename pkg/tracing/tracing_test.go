@@ -0,0 +1,69 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/metadata"
+)
+
+const sampleTraceParent = "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"
+
+func TestInitExporters(t *testing.T) {
+	shutdown, err := Init(context.Background(), Config{Exporter: ExporterNone})
+	assert.Nil(t, err)
+	assert.Nil(t, shutdown(context.Background()))
+
+	shutdown, err = Init(context.Background(), Config{Exporter: ExporterStdout, ServiceName: "test"})
+	assert.Nil(t, err)
+	assert.Nil(t, shutdown(context.Background()))
+
+	_, err = Init(context.Background(), Config{Exporter: "bogus"})
+	assert.EqualError(t, err, "Unknown trace exporter bogus")
+}
+
+func TestPropagateGRPCToHTTP(t *testing.T) {
+	_, err := Init(context.Background(), Config{Exporter: ExporterNone})
+	assert.Nil(t, err)
+
+	md := metadata.Pairs("traceparent", sampleTraceParent)
+	ctx := ExtractIncomingGRPC(metadata.NewIncomingContext(context.Background(), md))
+
+	spanContext := trace.SpanContextFromContext(ctx)
+	assert.True(t, spanContext.IsRemote())
+	assert.Equal(t, "0af7651916cd43dd8448eb211c80319c", spanContext.TraceID().String())
+
+	header := http.Header{}
+	InjectHTTP(ctx, header)
+	assert.Equal(t, sampleTraceParent, header.Get("traceparent"))
+
+	// No incoming metadata leaves the context alone
+	ctx = ExtractIncomingGRPC(context.Background())
+	assert.False(t, trace.SpanContextFromContext(ctx).IsValid())
+}
+
+func TestDetach(t *testing.T) {
+	_, err := Init(context.Background(), Config{Exporter: ExporterNone})
+	assert.Nil(t, err)
+
+	md := metadata.Pairs("traceparent", sampleTraceParent)
+	ctx, cancel := context.WithTimeout(metadata.NewIncomingContext(context.Background(), md), time.Minute)
+	ctx = ExtractIncomingGRPC(ctx)
+	cancel()
+
+	detached := Detach(ctx)
+	assert.Nil(t, detached.Err())
+	_, hasDeadline := detached.Deadline()
+	assert.False(t, hasDeadline)
+	assert.Equal(t, trace.SpanContextFromContext(ctx).TraceID(), trace.SpanContextFromContext(detached).TraceID())
+
+	assert.NotNil(t, Detach(nil)) //nolint:staticcheck
+}